@@ -5,23 +5,88 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/andrian0vv/chatgpt-cli/cmd/presets"
 	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	presetstore "github.com/andrian0vv/chatgpt-cli/internal/presets"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/dialog"
+)
+
+var (
+	preset  string
+	choices int
 )
 
 var Command = &cobra.Command{
 	Use:   "ask",
 	Short: "Ask AI with one question",
 	Args:  cobra.MatchAll(cobra.MinimumNArgs(1)),
-	Run:   Run,
+	RunE:  Run,
+}
+
+func init() {
+	Command.Flags().StringVar(&preset, "preset", "", "Preset with the system prompt, examples, model and parameters")
+	_ = Command.RegisterFlagCompletionFunc("preset", presets.CompleteNames)
+	Command.Flags().IntVarP(&choices, "choices", "n", 1, "Number of answers to request in one call")
+	command.AddParamFlags(Command.Flags())
 }
 
-func Run(c *cobra.Command, args []string) {
-	cmd := command.New(c)
+func Run(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	chat := dto.NewChat()
+
+	if preset != "" {
+		p, err := presetstore.New(cmd.Config.ConfigDir).Load(preset)
+		if err != nil {
+			return err
+		}
+
+		p.Apply(chat)
+
+		// The flags are more specific than the preset.
+		params, err := cmd.Params()
+		if err != nil {
+			return failure.New(failure.KindUsage, err)
+		}
+		chat.Params = chat.Params.Merge(params)
+
+		if p.Model != "" && !c.Flags().Changed("model") {
+			if err = c.Flags().Set("model", p.Model); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cmd, err = cmd.Connect(); err != nil {
+		return err
+	}
 
 	question := strings.Join(args, " ")
 
-	answer, err := cmd.Assistant.SendMessage(cmd.Context(), question)
-	cmd.Fail(err)
+	if choices > 1 {
+		chat.AddMessage(dto.RoleUser, question)
+
+		completion, err := cmd.Assistant.Alternatives(cmd.Context(), chat, choices)
+		if err != nil {
+			return err
+		}
+
+		cmd.AI(dialog.FormatChoices(completion.Choices))
+
+		return nil
+	}
+
+	answer, err := cmd.Assistant.SendChatMessage(cmd.Context(), chat, question)
+	if err != nil {
+		return err
+	}
 
 	cmd.AI(answer)
+
+	return nil
 }
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/openai"
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/credentials"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/readline"
+)
+
+const (
+	messageOnLogin    = "The key has been stored in %s."
+	messageOnLogout   = "The key has been removed from %s."
+	messageOnNoStored = "There is no key in the %s."
+	messageOnUsing    = "Using the key %s from the %s source."
+	messageOnVerified = "The key works."
+	promptKey         = "OpenAI API key: "
+)
+
+var (
+	store    string
+	noVerify bool
+)
+
+var Command = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage the OpenAI API key",
+	Long: `Manage the OpenAI API key.
+
+The key is looked up in the sources configured by auth.sources, in order:
+env (OPENAI_API_KEY), keyring (the keychain of macOS or the Secret Service on Linux),
+command (the output of auth.api_key_command) and file (auth.api_key_file).`,
+}
+
+var loginCommand = &cobra.Command{
+	Use:   "login",
+	Short: "Store the API key in the keyring or a file",
+	Long: `Store the API key in the keyring or a file.
+
+The key is read without echo from the terminal or from the first line of stdin,
+and checked with a request unless --no-verify is set.`,
+	Args: cobra.MatchAll(cobra.NoArgs),
+	RunE: RunLogin,
+}
+
+var logoutCommand = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the API key from the keyring or a file",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunLogout,
+}
+
+var statusCommand = &cobra.Command{
+	Use:   "status",
+	Short: "Show which source has the API key and check it",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunStatus,
+}
+
+func init() {
+	Command.AddCommand(loginCommand)
+	Command.AddCommand(logoutCommand)
+	Command.AddCommand(statusCommand)
+
+	for _, c := range []*cobra.Command{loginCommand, logoutCommand} {
+		c.Flags().StringVar(&store, "store", credentials.SourceKeyring, "Where the key is kept, keyring or file")
+	}
+	for _, c := range []*cobra.Command{loginCommand, statusCommand} {
+		c.Flags().BoolVar(&noVerify, "no-verify", false, "Do not check the key with a request")
+	}
+}
+
+func RunLogin(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := cmd.Credentials()
+	if err != nil {
+		return err
+	}
+
+	key, err := readline.ReadSecret(cmd.InOrStdin(), cmd.ErrOrStderr(), promptKey)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return failure.New(failure.KindUsage, errors.New("the key is empty"))
+	}
+
+	if !noVerify {
+		if err := verify(cmd, key); err != nil {
+			return err
+		}
+	}
+
+	where, err := resolver.Save(store, key)
+	if err != nil {
+		if errors.Is(err, credentials.ErrUnavailable) {
+			return failure.New(failure.KindUsage, fmt.Errorf("%w, use --store file", err))
+		}
+		return err
+	}
+
+	cmd.System(fmt.Sprintf(messageOnLogin, where))
+
+	return nil
+}
+
+func RunLogout(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := cmd.Credentials()
+	if err != nil {
+		return err
+	}
+
+	where, err := resolver.Delete(store)
+	if errors.Is(err, credentials.ErrNotFound) {
+		cmd.System(fmt.Sprintf(messageOnNoStored, store))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.System(fmt.Sprintf(messageOnLogout, where))
+
+	return nil
+}
+
+func RunStatus(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := cmd.Credentials()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SOURCE\tSTATUS\tDETAIL")
+	for _, status := range resolver.Inspect() {
+		state := "missing"
+		if status.Found {
+			state = "found"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", status.Source, state, status.Detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	key, err := cmd.APIKey()
+	if err != nil {
+		return err
+	}
+
+	cmd.System(fmt.Sprintf(messageOnUsing, key.Masked(), key.Source))
+
+	if noVerify {
+		return nil
+	}
+
+	if err := verify(cmd, key.Value); err != nil {
+		return err
+	}
+
+	cmd.System(messageOnVerified)
+
+	return nil
+}
+
+// verify lists the models with the key, the cheapest request that needs a valid one.
+func verify(cmd command.Command, key string) error {
+	cfg := cmd.Config
+	cfg.OpenaiApiKey = key
+
+	client := openai.New(cfg, logger.New(io.Discard, logger.WithEnabled(false)))
+
+	stop := cmd.Loading("Checking the key")
+	_, err := client.GetModels(cmd.Context())
+	stop()
+
+	if err != nil {
+		return fmt.Errorf("check the key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/ratelimit"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/batch"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
+)
+
+const (
+	messageOnDone    = "%d requests answered, %d failed, %d skipped as answered before."
+	messageOnNothing = "All %d requests have been answered before."
+)
+
+var (
+	output  string
+	workers int
+	rpm     int
+	tpm     int
+)
+
+var Command = &cobra.Command{
+	Use:   "batch <input.jsonl>",
+	Short: "Send the requests of a JSON lines file concurrently",
+	Long: `Send the requests of a JSON lines file concurrently.
+
+Every line has a prompt or messages and optionally an id, system, model and params:
+  {"id": "a", "prompt": "Translate 'hello' to French", "model": "gpt-4o-mini", "params": {"temperature": 0}}
+  {"messages": [{"role": "user", "content": "Hi"}], "system": "Answer briefly"}
+
+The results are written as JSON lines in the input order, with the usage or the error of every request.
+Use - to read the standard input. Running again with the same --output skips the answered ids.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(1)),
+	RunE: Run,
+}
+
+func init() {
+	Command.Flags().StringVarP(&output, "output", "o", "", "Append the results to the file and skip the ids answered there, the standard output by default")
+	Command.Flags().IntVarP(&workers, "workers", "w", 4, "Number of requests sent at the same time")
+	Command.Flags().IntVar(&rpm, "rpm", 0, "Requests per minute limit, 0 for no limit")
+	Command.Flags().IntVar(&tpm, "tpm", 0, "Tokens per minute limit, 0 for no limit")
+}
+
+func Run(c *cobra.Command, args []string) error {
+	cmd, err := command.New(c)
+	if err != nil {
+		return err
+	}
+
+	requests, err := readRequests(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	total := len(requests)
+
+	out := c.OutOrStdout()
+	if output != "" {
+		answered, err := readAnswered(output)
+		if err != nil {
+			return err
+		}
+
+		requests = pending(requests, answered)
+		if len(requests) == 0 {
+			cmd.System(fmt.Sprintf(messageOnNothing, total))
+			return nil
+		}
+
+		f, err := os.OpenFile(output, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err = batch.TrimPartial(f); err != nil {
+			return err
+		}
+
+		out = f
+	}
+
+	runner := batch.New(
+		cmd.Assistant,
+		batch.WithWorkers(workers),
+		batch.WithLimiter(ratelimit.New(rpm, tpm)),
+	)
+
+	var answered, failed int
+
+	ctx := usage.WithSession(cmd.Context(), sessionName(args[0]))
+
+	err = runner.Run(ctx, requests, func(result dto.BatchResult) error {
+		if result.Error != "" {
+			failed++
+		} else {
+			answered++
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		_, err = out.Write(append(data, '\n'))
+
+		return err
+	})
+
+	if output != "" {
+		cmd.System(fmt.Sprintf(messageOnDone, answered, failed, total-len(requests)))
+	}
+
+	return err
+}
+
+func readRequests(c *cobra.Command, path string) ([]batch.Request, error) {
+	if path == "-" {
+		return batch.ReadRequests(c.InOrStdin())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open input: %w", err)
+	}
+	defer f.Close()
+
+	return batch.ReadRequests(f)
+}
+
+func readAnswered(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open output: %w", err)
+	}
+	defer f.Close()
+
+	return batch.ReadAnswered(f)
+}
+
+func pending(requests []batch.Request, answered map[string]bool) []batch.Request {
+	var list []batch.Request
+	for _, request := range requests {
+		if !answered[request.ID] {
+			list = append(list, request)
+		}
+	}
+
+	return list
+}
+
+// sessionName marks the usage of the batch in the ledger, e.g. batch-prompts for prompts.jsonl.
+func sessionName(path string) string {
+	if path == "-" {
+		return "batch"
+	}
+
+	name := filepath.Base(path)
+
+	return "batch-" + strings.TrimSuffix(name, filepath.Ext(name))
+}
@@ -0,0 +1,289 @@
+package batches
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/batch"
+)
+
+const (
+	messageOnSubmit = "The batch %s has been submitted with %d requests, check it with \"batches status %s\"."
+	messageOnEmpty  = "There are no batches yet."
+	messageOnSaved  = "%d results have been written to %s, %d of them failed."
+)
+
+var (
+	limit    int
+	interval time.Duration
+	output   string
+)
+
+var Command = &cobra.Command{
+	Use:   "batches",
+	Short: "Run requests with the OpenAI Batch API at half the price",
+	Long: `Run requests with the OpenAI Batch API at half the price, the answers take up to 24 hours.
+The input file has the same format as for the batch command.
+The estimated cost of a batch is checked against the budget of the profile before it is submitted,
+its usage is added to the usage ledger when it is downloaded.`,
+}
+
+var submitCommand = &cobra.Command{
+	Use:   "submit <file.jsonl>",
+	Short: "Upload the requests and create a batch",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1)),
+	RunE:  RunSubmit,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the latest batches",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunList,
+}
+
+var statusCommand = &cobra.Command{
+	Use:   "status <id>",
+	Short: "Show the progress of a batch",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1)),
+	RunE:  RunStatus,
+}
+
+var waitCommand = &cobra.Command{
+	Use:   "wait <id>",
+	Short: "Wait until a batch is done",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1)),
+	RunE:  RunWait,
+}
+
+var cancelCommand = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "Cancel a batch",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1)),
+	RunE:  RunCancel,
+}
+
+var downloadCommand = &cobra.Command{
+	Use:   "download <id>",
+	Short: "Write the results of a batch as JSON lines in the order of the requests",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1)),
+	RunE:  RunDownload,
+}
+
+func init() {
+	Command.AddCommand(submitCommand)
+	Command.AddCommand(listCommand)
+	Command.AddCommand(statusCommand)
+	Command.AddCommand(waitCommand)
+	Command.AddCommand(cancelCommand)
+	Command.AddCommand(downloadCommand)
+
+	listCommand.Flags().IntVarP(&limit, "limit", "n", 20, "Number of batches")
+	waitCommand.Flags().DurationVar(&interval, "interval", 30*time.Second, "Time between the status checks")
+	downloadCommand.Flags().StringVarP(&output, "output", "o", "", "Write the results to the file instead of the standard output")
+}
+
+func RunSubmit(c *cobra.Command, args []string) error {
+	cmd, r, err := connect(c)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	requests, err := batch.ReadRequests(f)
+	if err != nil {
+		return err
+	}
+
+	job, err := r.Submit(cmd.Context(), requests)
+	if err != nil {
+		return err
+	}
+
+	cmd.System(fmt.Sprintf(messageOnSubmit, job.ID, len(requests), job.ID))
+
+	return nil
+}
+
+func RunList(c *cobra.Command, _ []string) error {
+	cmd, r, err := connect(c)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := r.List(cmd.Context(), limit)
+	if err != nil {
+		return err
+	}
+
+	if len(jobs) == 0 {
+		cmd.System(messageOnEmpty)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tCREATED\tCOMPLETED\tFAILED\tTOTAL")
+
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n",
+			job.ID, job.Status, job.CreatedAt.Format(time.DateTime), job.Completed, job.Failed, job.Total)
+	}
+
+	return w.Flush()
+}
+
+func RunStatus(c *cobra.Command, args []string) error {
+	cmd, r, err := connect(c)
+	if err != nil {
+		return err
+	}
+
+	job, err := r.Status(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ID\t%s\n", job.ID)
+	fmt.Fprintf(w, "Status\t%s\n", job.Status)
+	fmt.Fprintf(w, "Created\t%s\n", job.CreatedAt.Format(time.DateTime))
+	if !job.DoneAt.IsZero() {
+		fmt.Fprintf(w, "Done\t%s\n", job.DoneAt.Format(time.DateTime))
+	}
+	fmt.Fprintf(w, "Requests\t%d completed, %d failed of %d\n", job.Completed, job.Failed, job.Total)
+	for _, e := range job.Errors {
+		fmt.Fprintf(w, "Error\t%s\n", e)
+	}
+
+	return w.Flush()
+}
+
+func RunWait(c *cobra.Command, args []string) error {
+	cmd, r, err := connect(c)
+	if err != nil {
+		return err
+	}
+
+	job, err := r.Wait(cmd.Context(), args[0], interval, func(job dto.BatchJob) {
+		cmd.System(describe(job))
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(job.Errors) > 0 {
+		return fmt.Errorf("batch %s: %s", job.ID, strings.Join(job.Errors, "; "))
+	}
+
+	return nil
+}
+
+func RunCancel(c *cobra.Command, args []string) error {
+	cmd, r, err := connect(c)
+	if err != nil {
+		return err
+	}
+
+	job, err := r.Cancel(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	cmd.System(describe(job))
+
+	return nil
+}
+
+func RunDownload(c *cobra.Command, args []string) error {
+	cmd, r, err := connect(c)
+	if err != nil {
+		return err
+	}
+
+	results, err := r.Download(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+
+		b.Write(append(data, '\n'))
+	}
+
+	if output == "" {
+		_, err = cmd.OutOrStdout().Write(b.Bytes())
+		return err
+	}
+
+	// The results are written at once, a failed download does not leave a part of them.
+	if err = atomicfile.Write(output, b.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("write results: %w", err)
+	}
+
+	cmd.System(fmt.Sprintf(messageOnSaved, len(results), output, failed))
+
+	return nil
+}
+
+// connect creates the command and the client of the Batch API.
+func connect(c *cobra.Command) (command.Command, *batch.Remote, error) {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return command.Command{}, nil, err
+	}
+
+	client, err := cmd.Client()
+	if err != nil {
+		return command.Command{}, nil, err
+	}
+
+	redactor, err := cmd.Redactor()
+	if err != nil {
+		return command.Command{}, nil, err
+	}
+
+	guard, err := cmd.Budget()
+	if err != nil {
+		return command.Command{}, nil, err
+	}
+
+	opts := []batch.RemoteOption{
+		batch.WithRemoteBudget(guard),
+		batch.WithRemoteRecorder(cmd.Ledger(), cmd.Config.Profile),
+	}
+	if redactor != nil {
+		opts = append(opts, batch.WithRemoteRedactor(redactor))
+	}
+
+	return cmd, batch.NewRemote(client, opts...), nil
+}
+
+func describe(job dto.BatchJob) string {
+	return fmt.Sprintf("%s is %s: %d completed, %d failed of %d.", job.ID, job.Status, job.Completed, job.Failed, job.Total)
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+)
+
+const (
+	messageOnClear    = "%d cached answers have been removed."
+	messageOnDisabled = "The response cache is disabled, set cache.enabled in the config to use it."
+)
+
+var Command = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the response cache",
+}
+
+var statsCommand = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the size of the response cache",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunStats,
+}
+
+var clearCommand = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached answers",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunClear,
+}
+
+func init() {
+	Command.AddCommand(statsCommand)
+	Command.AddCommand(clearCommand)
+}
+
+func RunStats(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	stats, err := cmd.ResponseCache().Stats()
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Config.Cache.Enabled {
+		cmd.System(messageOnDisabled)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Directory\t%s\n", stats.Dir)
+	fmt.Fprintf(w, "Entries\t%d\n", stats.Entries)
+	fmt.Fprintf(w, "Expired\t%d\n", stats.Expired)
+	fmt.Fprintf(w, "Size\t%s of %s\n", formatSize(stats.Size), formatSize(stats.MaxSize))
+	fmt.Fprintf(w, "TTL\t%s\n", stats.TTL)
+	if stats.Entries > 0 {
+		fmt.Fprintf(w, "Oldest\t%s\n", stats.Oldest.Format(time.DateTime))
+		fmt.Fprintf(w, "Newest\t%s\n", stats.Newest.Format(time.DateTime))
+	}
+
+	return w.Flush()
+}
+
+func RunClear(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	n, err := cmd.ResponseCache().Clear()
+	if err != nil {
+		return err
+	}
+
+	cmd.System(fmt.Sprintf(messageOnClear, n))
+
+	return nil
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value, suffix := float64(size)/unit, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -1,71 +1,140 @@
 package chat
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
-	"github.com/andrian0vv/chatgpt-cli/cmd/models"
+	"github.com/andrian0vv/chatgpt-cli/cmd/presets"
+	sessionscmd "github.com/andrian0vv/chatgpt-cli/cmd/sessions"
 	"github.com/andrian0vv/chatgpt-cli/internal/command"
-	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	presetstore "github.com/andrian0vv/chatgpt-cli/internal/presets"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/readline"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/dialog"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/titles"
 )
 
 const (
-	messageOnStart = `Welcome to the dialog with ChatGPT (%s)! 
-1. Type 'model' to get current model. 
-2. Type 'models' to list models. 
-3. Type 'reset' to reset the chat. 
-4. Type 'exit' or cmd+C to stop.
+	messageOnStart = `Welcome to the dialog with ChatGPT (%s)!
+Type /help to list the commands and /exit or cmd+C to stop.
+Use """ to start and end a multi-line message, Alt+Enter or Ctrl+J inserts a new line, Ctrl+R searches the history.
 `
-	messageOnExit    = "Goodbye!"
-	messageOnReset   = "The chat has been reset."
-	messageOnLoading = "Thinking"
+	messageOnExit = "Goodbye!"
 )
 
 const (
-	commandModel  = "model"
-	commandModels = "models"
-	commandReset  = "reset"
-	commandExit   = "exit"
+	prompt      = "[You] "
+	historyFile = "chat_history"
+)
+
+var (
+	preset  string
+	session string
 )
 
 var Command = &cobra.Command{
 	Use:   "chat",
 	Short: "Start chat with AI",
 	Args:  cobra.MatchAll(cobra.MaximumNArgs(1)),
-	Run:   Run,
+	RunE:  Run,
 }
 
-func Run(c *cobra.Command, _ []string) {
-	cmd := command.New(c)
+func init() {
+	Command.Flags().StringVar(&preset, "preset", "", "Preset with the system prompt, examples, model and parameters")
+	_ = Command.RegisterFlagCompletionFunc("preset", presets.CompleteNames)
+	Command.Flags().StringVar(&session, "session", "", "Saved chat to continue, e.g. one brought by sessions import")
+	_ = Command.RegisterFlagCompletionFunc("session", sessionscmd.CompleteNames)
+	command.AddParamFlags(Command.Flags())
+}
 
-	cmd.System(fmt.Sprintf(messageOnStart, cmd.Assistant.Model()))
+func Run(c *cobra.Command, _ []string) (err error) {
+	cmd, err := command.New(c)
+	if err != nil {
+		return err
+	}
+
+	history, err := readline.LoadHistory(filepath.Join(cmd.Config.DataDir, historyFile), readline.DefaultHistoryLimit)
+	if err != nil {
+		return err
+	}
+
+	var input *readline.Editor
+
+	d := dialog.New(
+		cmd.Assistant,
+		cmd,
+		cmd.Sessions(),
+		dialog.WithPrices(pricing.New(cmd.Config.Prices)),
+		dialog.WithPresets(presetstore.New(cmd.Config.ConfigDir)),
+		dialog.WithTitler(titles.New(cmd.Assistant, cmd.Config.Titles.Model), !cmd.Config.Titles.Disabled),
+		dialog.WithComposer(func(initial string) (string, error) {
+			message, err := readline.EditExternal(initial)
+			if err == nil {
+				input.AddHistory(message)
+			}
+			return message, err
+		}),
+	)
+
+	input = readline.New(
+		c.InOrStdin(),
+		c.OutOrStdout(),
+		readline.WithHistory(history),
+		readline.WithCompleter(d.Complete),
+	)
+	defer func() {
+		if closeErr := input.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if session != "" {
+		if err = d.LoadSession(cmd.Context(), session); err != nil {
+			return err
+		}
+	}
+
+	if preset != "" {
+		if err = d.UsePreset(cmd.Context(), preset); err != nil {
+			return err
+		}
+	}
 
-	chat := dto.NewChat()
+	// The flags are more specific than the presets, they are kept in the chat to stay over the ones used later.
+	params, err := cmd.Params()
+	if err != nil {
+		return failure.New(failure.KindUsage, err)
+	}
+	d.Chat().Params = d.Chat().Params.Merge(params)
+
+	cmd.System(fmt.Sprintf(messageOnStart, cmd.Assistant.Model()))
 
 	for {
-		question := cmd.Read()
-
-		switch question {
-		case commandModel:
-			cmd.AI(cmd.Assistant.Model())
-		case commandModels:
-			models.Run(c, nil)
-		case commandReset:
-			chat.Reset()
-			cmd.System(messageOnReset)
-		case commandExit:
+		line, err := input.ReadLine(prompt)
+		if errors.Is(err, io.EOF) || errors.Is(err, readline.ErrInterrupt) {
 			cmd.System(messageOnExit)
-			return
-		case "":
-		default:
-			cancel := cmd.Loading(messageOnLoading)
-
-			answer, err := cmd.Assistant.SendChatMessage(cmd.Context(), chat, question)
-			cancel()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-			cmd.Fail(err)
-			cmd.AI(answer)
+		err = d.Handle(cmd.Context(), line)
+		if errors.Is(err, dialog.ErrExit) {
+			cmd.System(messageOnExit)
+			return nil
+		}
+		// Ctrl+C during a request stops the chat.
+		if ctxErr := cmd.Context().Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			cmd.Error(err)
 		}
 	}
 }
@@ -0,0 +1,165 @@
+package compare
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	modelscmd "github.com/andrian0vv/chatgpt-cli/cmd/models"
+	"github.com/andrian0vv/chatgpt-cli/cmd/presets"
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	presetstore "github.com/andrian0vv/chatgpt-cli/internal/presets"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/compare"
+)
+
+const (
+	minColumnWidth = 40
+	columnGap      = 2
+)
+
+var (
+	models   []string
+	jsonOut  bool
+	preset   string
+	headerSt = lipgloss.NewStyle().Bold(true)
+	statsSt  = lipgloss.NewStyle().Faint(true)
+	errorSt  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+var Command = &cobra.Command{
+	Use:   "compare -m <model> -m <model> <question>",
+	Short: "Ask several models the same question and compare the answers",
+	Args:  cobra.MatchAll(cobra.MinimumNArgs(1)),
+	RunE:  Run,
+}
+
+func init() {
+	Command.Flags().StringArrayVarP(&models, "model", "m", nil, "Model to ask, repeat for every model")
+	Command.Flags().BoolVar(&jsonOut, "json", false, "Print the answers as JSON")
+	Command.Flags().StringVar(&preset, "preset", "", "Preset with the system prompt, examples and parameters, its model is ignored")
+	_ = Command.RegisterFlagCompletionFunc("preset", presets.CompleteNames)
+	_ = Command.RegisterFlagCompletionFunc("model", modelscmd.CompleteNames)
+}
+
+func Run(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	if len(models) < 2 {
+		return failure.New(failure.KindUsage, errors.New("set at least two models with -m"))
+	}
+
+	chat := dto.NewChat()
+	if preset != "" {
+		p, err := presetstore.New(cmd.Config.ConfigDir).Load(preset)
+		if err != nil {
+			return err
+		}
+
+		p.Apply(chat)
+	}
+
+	if cmd, err = cmd.Connect(); err != nil {
+		return err
+	}
+
+	prices := pricing.New(cmd.Config.Prices)
+	question := strings.Join(args, " ")
+
+	// The progress would break the JSON output.
+	cancel := func() {}
+	if !jsonOut {
+		cancel = cmd.Loading(fmt.Sprintf("Asking %d models", len(models)))
+	}
+
+	answers := compare.New(cmd.Assistant, prices).Compare(cmd.Context(), *chat, models, question)
+	cancel()
+
+	if jsonOut {
+		data, err := json.MarshalIndent(answers, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		cmd.Println(string(data))
+		return nil
+	}
+
+	width := terminalWidth(cmd)
+	columnWidth := (width - columnGap*(len(answers)-1)) / len(answers)
+
+	if columnWidth < minColumnWidth {
+		for _, answer := range answers {
+			cmd.Println(headerSt.Render(answer.Model) + "  " + statsSt.Render(stats(answer)))
+			cmd.Println(body(answer))
+			cmd.Println()
+		}
+		return nil
+	}
+
+	columns := make([]string, 0, len(answers))
+	for i, answer := range answers {
+		style := lipgloss.NewStyle().Width(columnWidth)
+		if i < len(answers)-1 {
+			style = style.MarginRight(columnGap)
+		}
+
+		columns = append(columns, style.Render(
+			headerSt.Render(answer.Model)+"\n"+statsSt.Render(stats(answer))+"\n\n"+body(answer),
+		))
+	}
+
+	cmd.Println(lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+
+	return nil
+}
+
+func stats(answer compare.Answer) string {
+	parts := []string{fmt.Sprintf("%.2fs", answer.Latency.Seconds())}
+
+	if answer.Usage != nil {
+		parts = append(parts, fmt.Sprintf("%d tokens", answer.Usage.Total()))
+
+		if answer.Cost != nil {
+			parts = append(parts, fmt.Sprintf("$%.4f", *answer.Cost))
+		} else {
+			parts = append(parts, "price unknown")
+		}
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+func body(answer compare.Answer) string {
+	if answer.Error != "" {
+		return errorSt.Render("Error: " + answer.Error)
+	}
+
+	return answer.Content
+}
+
+// terminalWidth returns the width of the output, zero if it is not a terminal.
+func terminalWidth(cmd command.Command) int {
+	f, ok := cmd.OutOrStdout().(*os.File)
+	if !ok {
+		return 0
+	}
+
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+
+	return width
+}
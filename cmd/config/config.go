@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+)
+
+const messageOnInit = "The config has been created at %s."
+
+var Command = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the config file",
+}
+
+var initCommand = &cobra.Command{
+	Use:   "init",
+	Short: "Create the config file with the default settings",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunInit,
+}
+
+var pathCommand = &cobra.Command{
+	Use:   "path",
+	Short: "Print the location of the config file",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunPath,
+}
+
+func init() {
+	Command.AddCommand(initCommand)
+	Command.AddCommand(pathCommand)
+}
+
+func RunInit(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Config.Init(); err != nil {
+		return err
+	}
+
+	cmd.System(fmt.Sprintf(messageOnInit, cmd.Config.Path()))
+
+	return nil
+}
+
+func RunPath(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	cmd.Println(cmd.Config.Path())
+
+	return nil
+}
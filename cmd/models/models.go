@@ -1,31 +1,237 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/catalog"
+)
+
+const messageOnEmpty = "No models match the filter."
+
+var (
+	filter       string
+	capabilities []string
+	jsonOut      bool
 )
 
 var Command = &cobra.Command{
 	Use:   "models",
-	Short: "Get list of ChatGPT models",
+	Short: "List the models with their capabilities",
 	Args:  cobra.MatchAll(cobra.NoArgs),
-	Run:   Run,
+	RunE:  Run,
+}
+
+var infoCommand = &cobra.Command{
+	Use:               "info <id>",
+	Short:             "Show the details of a model",
+	Args:              cobra.MatchAll(cobra.ExactArgs(1)),
+	ValidArgsFunction: completeInfo,
+	RunE:              RunInfo,
+}
+
+func init() {
+	Command.AddCommand(infoCommand)
+
+	Command.Flags().StringVarP(&filter, "filter", "f", "", "Regular expression the model ID must match")
+	Command.Flags().StringSliceVarP(&capabilities, "capability", "c", nil,
+		fmt.Sprintf("Capability the models must have, repeat for several (%s)", joinCapabilities(dto.Capabilities)))
+	Command.PersistentFlags().BoolVar(&jsonOut, "json", false, "Print JSON")
+
+	_ = Command.RegisterFlagCompletionFunc("capability", completeCapabilities)
+}
+
+func Run(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	f, err := parseFilter()
+	if err != nil {
+		return failure.New(failure.KindUsage, err)
+	}
+
+	models, err := cmd.Catalog()
+	if err != nil {
+		return err
+	}
+
+	list, err := models.List(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	list = f.Apply(list)
+
+	if jsonOut {
+		return printJSON(cmd, list)
+	}
+
+	if len(list) == 0 {
+		cmd.System(messageOnEmpty)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tOWNER\tCREATED\tCONTEXT\tCAPABILITIES")
+
+	for _, model := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			model.ID, model.OwnedBy, formatDate(model.Created), formatContext(model.ContextWindow), joinCapabilities(model.Capabilities))
+	}
+
+	return w.Flush()
+}
+
+func RunInfo(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	models, err := cmd.Catalog()
+	if err != nil {
+		return err
+	}
+
+	model, err := models.Info(cmd.Context(), args[0])
+	if err != nil {
+		return err
+	}
+
+	if jsonOut {
+		return printJSON(cmd, model)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\t%s\n", model.ID)
+	fmt.Fprintf(w, "Owner\t%s\n", model.OwnedBy)
+	fmt.Fprintf(w, "Created\t%s\n", formatDate(model.Created))
+	fmt.Fprintf(w, "Context window\t%s\n", formatContext(model.ContextWindow))
+	fmt.Fprintf(w, "Capabilities\t%s\n", joinCapabilities(model.Capabilities))
+
+	return w.Flush()
+}
+
+// CompleteNames completes the IDs of the chat models from the cached list.
+// The models without known capabilities are offered too, they may be custom chat models.
+func CompleteNames(c *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	return completeModels(c, func(model dto.Model) bool {
+		return model.Can(dto.CapabilityChat) || len(model.Capabilities) == 0
+	}), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeInfo(c *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return completeModels(c, func(dto.Model) bool { return true }), cobra.ShellCompDirectiveNoFileComp
 }
 
-func Run(c *cobra.Command, _ []string) {
-	cmd := command.New(c)
+func completeModels(c *cobra.Command, match func(dto.Model) bool) []string {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return nil
+	}
 
-	models, err := cmd.Assistant.GetModels(cmd.Context())
-	cmd.Fail(err)
+	catalog, err := cmd.Catalog()
+	if err != nil {
+		return nil
+	}
 
-	var answer strings.Builder
+	models, err := catalog.List(c.Context())
+	if err != nil {
+		return nil
+	}
+
+	var names []string
 	for _, model := range models {
-		answer.WriteString(fmt.Sprintf("* %s\n", model))
+		if match(model) {
+			names = append(names, model.ID)
+		}
+	}
+
+	return names
+}
+
+func completeCapabilities(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	names := make([]string, 0, len(dto.Capabilities))
+	for _, capability := range dto.Capabilities {
+		names = append(names, string(capability))
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+func parseFilter() (catalog.Filter, error) {
+	var f catalog.Filter
+
+	if filter != "" {
+		pattern, err := regexp.Compile(filter)
+		if err != nil {
+			return catalog.Filter{}, fmt.Errorf("parse filter: %w", err)
+		}
+		f.Pattern = pattern
+	}
+
+	for _, name := range capabilities {
+		capability, err := catalog.ParseCapability(name)
+		if err != nil {
+			return catalog.Filter{}, err
+		}
+		f.Capabilities = append(f.Capabilities, capability)
+	}
+
+	return f, nil
+}
+
+func printJSON(cmd command.Command, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	cmd.Println(string(data))
+
+	return nil
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() || t.Unix() == 0 {
+		return "-"
+	}
+
+	return t.Format(time.DateOnly)
+}
+
+func formatContext(tokens int) string {
+	if tokens == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d", tokens)
+}
+
+func joinCapabilities(capabilities []dto.Capability) string {
+	if len(capabilities) == 0 {
+		return "-"
+	}
+
+	names := make([]string, len(capabilities))
+	for i, capability := range capabilities {
+		names[i] = string(capability)
 	}
 
-	cmd.AI(answer.String())
+	return strings.Join(names, ", ")
 }
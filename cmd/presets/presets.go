@@ -0,0 +1,105 @@
+package presets
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/presets"
+)
+
+const messageOnEmpty = "There are no presets yet, add YAML files to %s or .chatgpt-cli/presets of your project."
+
+var Command = &cobra.Command{
+	Use:   "presets",
+	Short: "List the presets",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunList,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the presets",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunList,
+}
+
+var showCommand = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "Print a preset",
+	Args:              cobra.MatchAll(cobra.ExactArgs(1)),
+	ValidArgsFunction: CompleteNames,
+	RunE:              RunShow,
+}
+
+func init() {
+	Command.AddCommand(listCommand)
+	Command.AddCommand(showCommand)
+}
+
+func RunList(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	list, err := presets.New(cmd.Config.ConfigDir).List()
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		cmd.System(fmt.Sprintf(messageOnEmpty, presets.UserDir(cmd.Config.ConfigDir)))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION\tMODEL\tEXAMPLES\tPATH")
+
+	for _, p := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", p.Name, p.Description, p.Model, len(p.Examples), p.Path)
+	}
+
+	return w.Flush()
+}
+
+func RunShow(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	p, err := presets.New(cmd.Config.ConfigDir).Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(p.Path)
+	if err != nil {
+		return fmt.Errorf("read preset: %w", err)
+	}
+
+	cmd.System(p.Path)
+	cmd.Print(string(content))
+
+	return nil
+}
+
+// CompleteNames completes the names of the presets.
+func CompleteNames(c *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names, _ := presets.New(cmd.Config.ConfigDir).Names()
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -6,31 +6,129 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/andrian0vv/chatgpt-cli/cmd/ask"
+	"github.com/andrian0vv/chatgpt-cli/cmd/auth"
+	"github.com/andrian0vv/chatgpt-cli/cmd/batch"
+	"github.com/andrian0vv/chatgpt-cli/cmd/batches"
+	"github.com/andrian0vv/chatgpt-cli/cmd/cache"
 	"github.com/andrian0vv/chatgpt-cli/cmd/chat"
+	"github.com/andrian0vv/chatgpt-cli/cmd/compare"
+	"github.com/andrian0vv/chatgpt-cli/cmd/config"
 	"github.com/andrian0vv/chatgpt-cli/cmd/models"
+	"github.com/andrian0vv/chatgpt-cli/cmd/presets"
+	"github.com/andrian0vv/chatgpt-cli/cmd/run"
+	"github.com/andrian0vv/chatgpt-cli/cmd/serve"
+	"github.com/andrian0vv/chatgpt-cli/cmd/sessions"
+	"github.com/andrian0vv/chatgpt-cli/cmd/template"
+	"github.com/andrian0vv/chatgpt-cli/cmd/usage"
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	internalconfig "github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
 )
 
 var (
-	verbose bool
-	model   string
+	verbose      bool
+	model        string
+	profile      string
+	ignoreBudget bool
+	noCache      bool
+	refresh      bool
+	provider     string
+	noRedact     bool
+	logLevel     string
+	logFormat    string
+	logFile      string
+	logPrompts   bool
 )
 
 var rootCommand = &cobra.Command{
 	Use:   "chatgpt-cli",
 	Short: "Smooth interaction with chat gpt",
+	Long:  "Smooth interaction with chat gpt.\n\nExit codes:\n" + failure.Codes(),
+	// The errors are printed with a hint by Execute.
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 func init() {
 	// Commands
 	rootCommand.AddCommand(ask.Command)
 	rootCommand.AddCommand(chat.Command)
+	rootCommand.AddCommand(batch.Command)
+	rootCommand.AddCommand(batches.Command)
+	rootCommand.AddCommand(compare.Command)
 	rootCommand.AddCommand(models.Command)
+	rootCommand.AddCommand(presets.Command)
+	rootCommand.AddCommand(run.Command)
+	rootCommand.AddCommand(serve.Command)
+	rootCommand.AddCommand(sessions.Command)
+	rootCommand.AddCommand(template.Command)
+	rootCommand.AddCommand(usage.Command)
+	rootCommand.AddCommand(config.Command)
+	rootCommand.AddCommand(auth.Command)
+	rootCommand.AddCommand(cache.Command)
 
 	// Flags
-	rootCommand.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCommand.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Log the requests to stderr, the same as --log-level debug")
+	rootCommand.PersistentFlags().StringVar(&logLevel, "log-level", "", "Log the records of the level and above: debug, info, warn or error")
+	rootCommand.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Format of the log, text or json")
+	rootCommand.PersistentFlags().StringVar(&logFile, "log-file", "", "Write the log to the file rotated by size rather than stderr")
+	rootCommand.PersistentFlags().BoolVar(&logPrompts, "log-prompts", false, "Log the whole requests and answers, they may hold secrets")
 	rootCommand.PersistentFlags().StringVarP(&model, "model", "m", "", "ChatGPT model")
+	rootCommand.PersistentFlags().StringVarP(&profile, "profile", "p", "", "Config profile, \"default\" unless set in the config")
+	rootCommand.PersistentFlags().BoolVar(&ignoreBudget, "ignore-budget", false, "Send requests over the budget of the profile with a warning")
+	rootCommand.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Do not use the response cache")
+	rootCommand.PersistentFlags().BoolVar(&refresh, "refresh", false, "Send cached requests again and replace the cached answers and models")
+	rootCommand.PersistentFlags().BoolVar(&noRedact, "no-redact", false, "Send the secrets and the personal data in the messages as they are")
+
+	rootCommand.PersistentFlags().StringVar(&provider, "provider", "", "Provider of the answers, openai or mock to answer offline without an API key")
+
+	_ = rootCommand.RegisterFlagCompletionFunc("model", models.CompleteNames)
+	_ = rootCommand.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions(
+		[]string{"debug", "info", "warn", "error"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCommand.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions(
+		[]string{logger.FormatText, logger.FormatJSON}, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCommand.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions(
+		[]string{internalconfig.ProviderOpenAI, internalconfig.ProviderMock}, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCommand.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return failure.New(failure.KindUsage, err)
+	})
+	markArgsErrors(rootCommand)
 }
 
+// Execute runs the command and prints the error it failed with, see failure.ExitCode for the exit code.
 func Execute(ctx context.Context) error {
-	return rootCommand.ExecuteContext(ctx)
+	c, err := rootCommand.ExecuteContextC(ctx)
+	if err == nil {
+		return nil
+	}
+
+	// The root command only runs the others, e.g. it fails on an unknown command.
+	if c == rootCommand {
+		err = failure.New(failure.KindUsage, err)
+	}
+
+	// Ctrl+C needs no explanation.
+	if failure.KindOf(err) != failure.KindCanceled {
+		command.Command{Command: c}.Error(err)
+	}
+
+	return err
+}
+
+// markArgsErrors marks the errors of the argument checks of the command and its subcommands as usage errors.
+func markArgsErrors(c *cobra.Command) {
+	if args := c.Args; args != nil {
+		c.Args = func(c *cobra.Command, a []string) error {
+			if err := args(c, a); err != nil {
+				return failure.New(failure.KindUsage, err)
+			}
+			return nil
+		}
+	}
+
+	for _, sub := range c.Commands() {
+		markArgsErrors(sub)
+	}
 }
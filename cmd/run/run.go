@@ -0,0 +1,137 @@
+package run
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/readline"
+	"github.com/andrian0vv/chatgpt-cli/internal/templates"
+)
+
+const messageOnMissing = `Enter the missing variables, use """ to start and end a multi-line value.`
+
+var (
+	vars   []string
+	dryRun bool
+)
+
+var Command = &cobra.Command{
+	Use:   "run <template>",
+	Short: "Render a prompt template and ask AI with it",
+	Long: `Render a prompt template and ask AI with it.
+
+Variables are set with --var name=value, name=@path reads a file, name=@- reads
+the standard input and name=!command takes the output of a shell command.
+The missing variables are asked for when the input is a terminal, the ones only
+tested with {{if .name}} or given a fallback with {{or .name "x"}} are optional.`,
+	Args:              cobra.MatchAll(cobra.ExactArgs(1)),
+	ValidArgsFunction: CompleteNames,
+	RunE:              Run,
+}
+
+func init() {
+	Command.Flags().StringArrayVar(&vars, "var", nil, "Template variable as name=value, name=@file, name=@- or name=!command")
+	Command.Flags().BoolVar(&dryRun, "dry-run", false, "Print the rendered prompt without sending it")
+}
+
+func Run(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	t, err := templates.New(cmd.Config.ConfigDir).Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		name, value, err := templates.ParseVar(cmd.Context(), v, c.InOrStdin())
+		if err != nil {
+			return err
+		}
+
+		values[name] = value
+	}
+
+	if err := ask(cmd, t, values); err != nil {
+		return err
+	}
+
+	prompt, err := t.Render(values)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		cmd.Println(prompt)
+		return nil
+	}
+
+	if t.Model != "" && !c.Flags().Changed("model") {
+		if err := c.Flags().Set("model", t.Model); err != nil {
+			return err
+		}
+	}
+
+	if cmd, err = cmd.Connect(); err != nil {
+		return err
+	}
+
+	answer, err := cmd.Assistant.SendMessage(cmd.Context(), prompt)
+	if err != nil {
+		return err
+	}
+
+	cmd.AI(answer)
+
+	return nil
+}
+
+// ask prompts for the missing variables if the input is a terminal.
+func ask(cmd command.Command, t *templates.Template, values map[string]string) error {
+	missing := t.Missing(values)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if !readline.IsTerminal(cmd.InOrStdin()) {
+		return failure.New(failure.KindUsage, fmt.Errorf("missing variables: %s, set them with --var", strings.Join(missing, ", ")))
+	}
+
+	cmd.System(messageOnMissing)
+
+	input := readline.New(cmd.InOrStdin(), cmd.OutOrStdout())
+
+	for _, name := range missing {
+		value, err := input.ReadLine(name + ": ")
+		if err != nil {
+			return fmt.Errorf("read variable %s: %w", name, err)
+		}
+
+		values[name] = value
+	}
+
+	return nil
+}
+
+// CompleteNames completes the names of the templates.
+func CompleteNames(c *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names, _ := templates.New(cmd.Config.ConfigDir).Names()
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
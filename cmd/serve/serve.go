@@ -0,0 +1,121 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/proxy"
+)
+
+const (
+	messageOnStart = "Serving the OpenAI API on http://%s/v1, stop it with Ctrl+C."
+	messageOnOpen  = "Anyone who can reach the address can spend the API key, set --token to require it as the API key."
+
+	shutdownTimeout   = 10 * time.Second
+	readHeaderTimeout = 10 * time.Second
+)
+
+var (
+	listen string
+	token  string
+)
+
+var Command = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve an OpenAI compatible API for other tools",
+	Long: `Serve /v1/chat/completions and /v1/models for other tools, e.g. with OPENAI_BASE_URL=http://localhost:8080/v1.
+The requests go through the configured provider with the response cache, the budget and the usage ledger
+of the profile, their usage is recorded for the session "serve".
+Every request is redacted with its own placeholders, so a client never gets the values of another one.
+With the mock provider the requests may have tools, the fixtures decide the tool calls of the answers,
+and the answers are streamed in their chunks.`,
+	Args: cobra.MatchAll(cobra.NoArgs),
+	RunE: Run,
+}
+
+func init() {
+	Command.Flags().StringVar(&listen, "listen", "localhost:8080", "Address to listen on, e.g. :8080 for all interfaces")
+	Command.Flags().StringVar(&token, "token", "", "Token the clients must send as their API key, CHATGPT_CLI_SERVE_TOKEN sets it too")
+}
+
+func Run(c *cobra.Command, _ []string) error {
+	cmd, err := command.New(c)
+	if err != nil {
+		return err
+	}
+
+	models, err := cmd.Catalog()
+	if err != nil {
+		return err
+	}
+
+	if token == "" {
+		token = os.Getenv("CHATGPT_CLI_SERVE_TOKEN")
+	}
+
+	log, err := cmd.Logger()
+	if err != nil {
+		return err
+	}
+	// The requests are logged to stderr unless the log is configured.
+	if !log.Enabled() {
+		log = logger.New(c.ErrOrStderr(), logger.WithEnabled(true), logger.WithLevel(slog.LevelInfo))
+	}
+
+	redactor, err := cmd.Redactor()
+	if err != nil {
+		return err
+	}
+
+	opts := []proxy.Option{proxy.WithToken(token)}
+	if redactor != nil {
+		opts = append(opts, proxy.WithRedactor(redactor))
+	}
+	if cmd.Config.Provider == config.ProviderMock {
+		opts = append(opts, proxy.WithToolCalls())
+	}
+
+	server := &http.Server{
+		Handler:           proxy.New(cmd.Assistant, models, log, opts...),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	cmd.System(fmt.Sprintf(messageOnStart, listener.Addr()))
+	if token == "" {
+		cmd.System(messageOnOpen)
+	}
+
+	ctx := cmd.Context()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	// Ctrl+C is the way to stop the server, it is not a failure.
+	if err = server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+
+	return nil
+}
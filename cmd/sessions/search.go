@@ -0,0 +1,194 @@
+package sessions
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/search"
+)
+
+const (
+	messageOnNoMatches = "No saved chats match the query."
+	messageOnEmbed     = "Embedding %d messages, about %d tokens, they are embedded once for the next searches."
+
+	snippetWidth = 100
+)
+
+var (
+	roles    []string
+	models   []string
+	since    string
+	until    string
+	limit    int
+	semantic bool
+)
+
+var labels = map[dto.Role]string{
+	dto.RoleUser:      "You",
+	dto.RoleAssistant: "AI",
+	dto.RoleSystem:    "System",
+}
+
+var searchCommand = &cobra.Command{
+	Use:   "search <query>...",
+	Short: "Find saved chats by their words or, with --semantic, by their meaning",
+	Long: `Find the saved chats containing all the words of the query, in any of their branches.
+Quote a phrase to match its words in a row, e.g. sessions search '"consumer group"' kafka.
+The words are looked up in an index kept next to the chats and updated when they are saved.
+
+With --semantic the chats are ranked by the message closest in meaning to the query, the messages
+are sent once to the embeddings endpoint of the provider and their vectors are kept in the data directory.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: RunSearch,
+}
+
+func init() {
+	searchCommand.Flags().StringSliceVar(&roles, "role", nil, "Match only the messages of the role: user, assistant or system, repeat for several")
+	searchCommand.Flags().StringSliceVar(&models, "model", nil, "Match only the messages of a model, by a part of its name, repeat for several")
+	searchCommand.Flags().StringVar(&since, "since", "", "Match only the messages from the date, e.g. 2024-05-01")
+	searchCommand.Flags().StringVar(&until, "until", "", "Match only the messages up to the date, inclusive")
+	searchCommand.Flags().IntVarP(&limit, "limit", "n", 10, "Maximum number of chats to show")
+	searchCommand.Flags().BoolVar(&semantic, "semantic", false, "Rank by meaning with embeddings instead of matching the words")
+
+	_ = searchCommand.RegisterFlagCompletionFunc("role", cobra.FixedCompletions(
+		[]string{string(dto.RoleUser), string(dto.RoleAssistant), string(dto.RoleSystem)}, cobra.ShellCompDirectiveNoFileComp))
+
+	Command.AddCommand(searchCommand)
+}
+
+func RunSearch(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseFilter()
+	if err != nil {
+		return failure.New(failure.KindUsage, err)
+	}
+
+	query := search.ParseQuery(args)
+	if len(query.Clauses) == 0 {
+		return failure.New(failure.KindUsage, fmt.Errorf("the query has no words"))
+	}
+
+	store := cmd.Sessions()
+
+	var results []search.Result
+	if semantic {
+		list, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		text := strings.Join(args, " ")
+		vectors := search.NewVectors(cmd.Config.DataDir, search.WithEmbedReporter(func(texts, tokens int) {
+			cmd.System(fmt.Sprintf(messageOnEmbed, texts, tokens))
+		}))
+
+		results, err = search.Semantic(cmd.Context(), cmd, vectors, list, text, filter, limit)
+		if err != nil {
+			return err
+		}
+	} else {
+		index := search.New(cmd.Config.DataDir)
+		if err = index.Sync(store); err != nil {
+			return fmt.Errorf("update search index: %w", err)
+		}
+
+		if results, err = index.Search(query, filter, limit); err != nil {
+			return err
+		}
+	}
+
+	if len(results) == 0 {
+		cmd.System(messageOnNoMatches)
+		return nil
+	}
+
+	bold := color.New(color.Bold)
+	highlight := color.New(color.Bold, color.FgYellow)
+	faint := color.New(color.FgHiBlack)
+
+	out := cmd.OutOrStdout()
+	for i, r := range results {
+		session, err := store.Load(r.Session)
+		if err != nil {
+			return err
+		}
+
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		details := []string{r.Model, r.UpdatedAt.Local().Format(time.DateTime)}
+		if semantic {
+			details = append(details, fmt.Sprintf("%.2f", r.Score))
+		}
+		name := bold.Sprint(r.Session)
+		if session.Title != "" {
+			name += "  " + session.Title
+		}
+		fmt.Fprintf(out, "%s  %s\n", name, faint.Sprint(strings.Join(details, "  ")))
+
+		for _, id := range r.Messages {
+			role, content := dto.RoleSystem, session.Chat.System
+			if node, ok := session.Chat.Node(id); ok {
+				role, content = node.Role, node.Content
+			}
+
+			var line strings.Builder
+			for _, segment := range search.Snippet(content, query.Terms(), snippetWidth) {
+				if segment.Match {
+					line.WriteString(highlight.Sprint(segment.Text))
+				} else {
+					line.WriteString(segment.Text)
+				}
+			}
+
+			fmt.Fprintf(out, "  %s: %s\n", labels[role], line.String())
+		}
+	}
+
+	return nil
+}
+
+func parseFilter() (search.Filter, error) {
+	var f search.Filter
+
+	for _, role := range roles {
+		switch r := dto.Role(strings.ToLower(role)); r {
+		case dto.RoleUser, dto.RoleAssistant, dto.RoleSystem:
+			f.Roles = append(f.Roles, r)
+		default:
+			return search.Filter{}, fmt.Errorf("unknown role %q, use user, assistant or system", role)
+		}
+	}
+
+	f.Models = models
+
+	if since != "" {
+		t, err := time.ParseInLocation(time.DateOnly, since, time.Local)
+		if err != nil {
+			return search.Filter{}, fmt.Errorf("parse --since: %w", err)
+		}
+		f.Since = t
+	}
+
+	if until != "" {
+		t, err := time.ParseInLocation(time.DateOnly, until, time.Local)
+		if err != nil {
+			return search.Filter{}, fmt.Errorf("parse --until: %w", err)
+		}
+		f.Until = t.AddDate(0, 0, 1)
+	}
+
+	return f, nil
+}
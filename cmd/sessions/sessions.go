@@ -0,0 +1,269 @@
+package sessions
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/export"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/importer"
+	"github.com/andrian0vv/chatgpt-cli/internal/search"
+	"github.com/andrian0vv/chatgpt-cli/internal/sessions"
+)
+
+const (
+	messageOnEmpty  = "There are no saved chats yet, save one with /save in the chat."
+	messageOnExport = "The chat %s has been exported to %s."
+	messageOnImport = "Imported %d chats, %d are up to date, continue one with chat --session <name>."
+)
+
+var (
+	format  string
+	output  string
+	flatten bool
+)
+
+var Command = &cobra.Command{
+	Use:   "sessions",
+	Short: "List the saved chats",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunList,
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the saved chats",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunList,
+}
+
+var exportCommand = &cobra.Command{
+	Use:   "export <name>",
+	Short: "Export a saved chat to Markdown, HTML, JSON or plain text",
+	Long: `Export the active branch of a saved chat with the roles, the times and the models of the messages.
+The HTML page is self-contained with the code highlighted, it can be shared as one file.`,
+	Args:              cobra.MatchAll(cobra.ExactArgs(1)),
+	ValidArgsFunction: CompleteNames,
+	RunE:              RunExport,
+}
+
+var importCommand = &cobra.Command{
+	Use:   "import <file>...",
+	Short: "Import ChatGPT data exports and Markdown transcripts as saved chats",
+	Long: `Import the conversations of a ChatGPT data export, the zip archive or its conversations.json,
+or Markdown transcripts with **User** and **Assistant** lines starting the messages.
+A conversation imported before is replaced when the export has a newer version of it,
+a chat continued here since then is kept.`,
+	Args: cobra.MatchAll(cobra.MinimumNArgs(1)),
+	ValidArgsFunction: func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"zip", "json", "md", "markdown"}, cobra.ShellCompDirectiveFilterFileExt
+	},
+	RunE: RunImport,
+}
+
+func init() {
+	exportCommand.Flags().StringVarP(&format, "format", "f", "", "Format: md, html, json or txt, by default the extension of the output or md")
+	exportCommand.Flags().StringVarP(&output, "output", "o", "", "File to write, stdout by default")
+	_ = exportCommand.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(export.Formats(), cobra.ShellCompDirectiveNoFileComp))
+
+	importCommand.Flags().BoolVar(&flatten, "flatten", false, "Keep only the active branch of the ChatGPT conversations")
+
+	Command.AddCommand(listCommand)
+	Command.AddCommand(exportCommand)
+	Command.AddCommand(importCommand)
+}
+
+func RunList(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	list, err := cmd.Sessions().List()
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		cmd.System(messageOnEmpty)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTITLE\tTAGS\tMODEL\tMESSAGES\tUPDATED")
+
+	for _, s := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", s.Name, orDash(s.Title), orDash(strings.Join(s.Tags, ",")),
+			s.Model, len(s.Chat.Messages()), s.UpdatedAt.Local().Format(time.DateTime))
+	}
+
+	return w.Flush()
+}
+
+func RunExport(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	if format == "" {
+		if format, err = export.FormatOf(output); err != nil {
+			return failure.New(failure.KindUsage, err)
+		}
+	}
+
+	session, err := cmd.Sessions().Load(args[0])
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	if err = export.Write(&b, session, format); err != nil {
+		return failure.New(failure.KindUsage, err)
+	}
+
+	if output == "" {
+		_, err = cmd.OutOrStdout().Write(b.Bytes())
+		return err
+	}
+
+	// The chat may hold secrets, the export is private like the saved chats.
+	if err = atomicfile.Write(output, b.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+
+	cmd.System(fmt.Sprintf(messageOnExport, session.Name, output))
+
+	return nil
+}
+
+func RunImport(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	var conversations []importer.Conversation
+	for _, name := range args {
+		read, err := importer.ReadFile(name, importer.Options{Flatten: flatten})
+		if errors.Is(err, importer.ErrUnknownFormat) {
+			return failure.New(failure.KindUsage, err)
+		}
+		if err != nil {
+			return fmt.Errorf("import %s: %w", name, err)
+		}
+
+		conversations = append(conversations, read...)
+	}
+
+	// The index is updated once after the import instead of on every saved chat.
+	store := sessions.New(cmd.Config.DataDir)
+
+	existing, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	taken := make(map[string]bool, len(existing))
+	bySource := make(map[string]*dto.Session, len(existing))
+	for _, s := range existing {
+		taken[s.Name] = true
+		if s.Source != "" {
+			bySource[s.Source] = s
+		}
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMESSAGES\tTITLE")
+
+	var imported, skipped int
+	for _, conversation := range conversations {
+		session := conversation.Session
+
+		if previous, ok := bySource[session.Source]; ok {
+			if !previous.UpdatedAt.Before(session.UpdatedAt) {
+				skipped++
+				continue
+			}
+			session.Name = previous.Name
+		} else {
+			session.Name = freeName(importer.Name(conversation.Title), taken)
+		}
+
+		if session.CreatedAt.IsZero() || session.UpdatedAt.IsZero() {
+			now := time.Now()
+			session.CreatedAt, session.UpdatedAt = now, now
+		}
+
+		if err = store.Put(session); err != nil {
+			return fmt.Errorf("save %s: %w", session.Name, err)
+		}
+
+		taken[session.Name] = true
+		if session.Source != "" {
+			bySource[session.Source] = session
+		}
+		imported++
+
+		fmt.Fprintf(w, "%s\t%d\t%s\n", session.Name, len(session.Chat.Messages()), conversation.Title)
+	}
+
+	if imported > 0 {
+		if err = w.Flush(); err != nil {
+			return err
+		}
+
+		// The chats are imported anyway, a failed update is caught up by the next search.
+		_ = search.New(cmd.Config.DataDir).Sync(store)
+	}
+
+	cmd.System(fmt.Sprintf(messageOnImport, imported, skipped))
+
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+// freeName returns the name or, when it is taken, the name with the first free number, e.g. trip-2.
+func freeName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+
+	for i := 2; ; i++ {
+		if candidate := fmt.Sprintf("%s-%d", name, i); !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// CompleteNames completes the names of the saved chats.
+func CompleteNames(c *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names, _ := cmd.Sessions().Names()
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,132 @@
+package template
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/cmd/run"
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/readline"
+	"github.com/andrian0vv/chatgpt-cli/internal/templates"
+)
+
+const (
+	messageOnEmpty  = "There are no templates yet, create one with \"template new <name>\"."
+	messageOnCreate = "The template has been created at %s."
+)
+
+var description string
+
+var Command = &cobra.Command{
+	Use:   "template",
+	Short: "Manage the prompt templates",
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the prompt templates",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  RunList,
+}
+
+var showCommand = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "Print a prompt template",
+	Args:              cobra.MatchAll(cobra.ExactArgs(1)),
+	ValidArgsFunction: run.CompleteNames,
+	RunE:              RunShow,
+}
+
+var newCommand = &cobra.Command{
+	Use:   "new <name>",
+	Short: "Create a prompt template, in $EDITOR if the input is a terminal",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1)),
+	RunE:  RunNew,
+}
+
+func init() {
+	Command.AddCommand(listCommand)
+	Command.AddCommand(showCommand)
+	Command.AddCommand(newCommand)
+
+	newCommand.Flags().StringVarP(&description, "description", "d", "", "Description of the template")
+}
+
+func RunList(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	list, err := templates.New(cmd.Config.ConfigDir).List()
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		cmd.System(messageOnEmpty)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDESCRIPTION\tMODEL\tVARIABLES")
+
+	for _, t := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", t.Name, t.Description, t.Model, strings.Join(t.Variables(), ", "))
+	}
+
+	return w.Flush()
+}
+
+func RunShow(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	store := templates.New(cmd.Config.ConfigDir)
+
+	if _, err := store.Load(args[0]); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(store.Path(args[0]))
+	if err != nil {
+		return err
+	}
+
+	cmd.Print(string(content))
+
+	return nil
+}
+
+func RunNew(c *cobra.Command, args []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	store := templates.New(cmd.Config.ConfigDir)
+
+	content := templates.Skeleton(description)
+	if readline.IsTerminal(c.InOrStdin()) {
+		edited, err := readline.EditExternal(content)
+		if err != nil {
+			return err
+		}
+
+		content = edited + "\n"
+	}
+
+	if err := store.Create(args[0], content); err != nil {
+		return err
+	}
+
+	cmd.System(fmt.Sprintf(messageOnCreate, store.Path(args[0])))
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package usage
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/command"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
+)
+
+const messageOnEmpty = "No usage has been recorded yet."
+
+var groups = map[string]usage.GroupBy{
+	"day":     usage.ByDay,
+	"model":   usage.ByModel,
+	"session": usage.BySession,
+}
+
+var (
+	by   string
+	days int
+)
+
+var Command = &cobra.Command{
+	Use:   "usage",
+	Short: "Report the tokens spent and their estimated cost",
+	Args:  cobra.MatchAll(cobra.NoArgs),
+	RunE:  Run,
+}
+
+func init() {
+	Command.Flags().StringVar(&by, "by", "day", "Group the usage by day, model or session")
+	Command.Flags().IntVar(&days, "days", 30, "Report the last number of days, 0 for all time")
+}
+
+func Run(c *cobra.Command, _ []string) error {
+	cmd, err := command.NewOffline(c)
+	if err != nil {
+		return err
+	}
+
+	groupBy, ok := groups[by]
+	if !ok {
+		return failure.New(failure.KindUsage, fmt.Errorf("unknown grouping %q, use day, model or session", by))
+	}
+
+	var since time.Time
+	if days > 0 {
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, now.Location())
+	}
+
+	records, err := usage.NewLedger(cmd.Config.DataDir).Records(since)
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		cmd.System(messageOnEmpty)
+		return nil
+	}
+
+	rows, total := usage.Summarize(records, groupBy, pricing.New(cmd.Config.Prices))
+	total.Key = "TOTAL"
+
+	var out strings.Builder
+
+	w := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tREQUESTS\tPROMPT\tCACHED\tCOMPLETION\tREASONING\tCOST\n", strings.ToUpper(by))
+
+	for _, row := range append(rows, total) {
+		key := row.Key
+		if key == "" {
+			key = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t$%.4f\n",
+			key, row.Requests, row.PromptTokens, row.CachedTokens, row.CompletionTokens, row.ReasoningTokens, row.Cost)
+	}
+	_ = w.Flush()
+
+	cmd.Println(strings.TrimRight(out.String(), "\n"))
+
+	if len(total.Unpriced) > 0 {
+		cmd.System(fmt.Sprintf("The cost does not include %s, set their prices in the config.", strings.Join(total.Unpriced, ", ")))
+	}
+
+	return nil
+}
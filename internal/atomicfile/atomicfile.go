@@ -0,0 +1,51 @@
+// Package atomicfile writes files through a temporary file in the same directory,
+// so a crash never leaves a truncated file and the readers see the old or the new content.
+package atomicfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Write replaces the file with the data. The file gets the permissions whatever the umask
+// or the permissions of the file it replaces.
+func Write(path string, data []byte, perm fs.FileMode) error {
+	return write(path, data, perm, os.Rename)
+}
+
+// Create writes a new file like Write, it fails with fs.ErrExist if the file exists.
+func Create(path string, data []byte, perm fs.FileMode) error {
+	// A hard link never replaces an existing file, unlike a rename.
+	return write(path, data, perm, os.Link)
+}
+
+func write(path string, data []byte, perm fs.FileMode, place func(tmp, path string) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// The temporary file is gone after a rename and left behind after a link.
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return place(f.Name(), path)
+}
@@ -0,0 +1,260 @@
+// Package budget enforces the spending limits of a profile before requests are sent.
+package budget
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+)
+
+const (
+	defaultWarnAt             = 0.8
+	defaultCompletionEstimate = 1000
+)
+
+var (
+	ErrExceeded = errors.New("budget exceeded")
+	// ErrUnknownPrice is returned for a model without a price when the profile limits the spend.
+	ErrUnknownPrice = errors.New("unknown price")
+)
+
+type ledger interface {
+	Stat() (int64, time.Time, error)
+	Records(since time.Time) ([]dto.UsageRecord, error)
+}
+
+type pricer interface {
+	Cost(model string, usage dto.Usage) (float64, bool)
+}
+
+// Guard checks the requests of a profile against its budget. It keeps the spending of
+// the requests in flight so concurrent requests cannot exceed the budget together.
+type Guard struct {
+	profile  string
+	limits   config.Budget
+	ledger   ledger
+	prices   pricer
+	warn     func(message string)
+	override bool
+	now      func() time.Time
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	// size and modTime are the ones of the ledger when it was loaded.
+	size     int64
+	modTime  time.Time
+	day      amount
+	month    amount
+	inFlight amount
+	warned   map[string]bool
+}
+
+type amount struct {
+	cost   float64
+	tokens int
+}
+
+func (a amount) add(other amount) amount {
+	return amount{cost: a.cost + other.cost, tokens: a.tokens + other.tokens}
+}
+
+func (a amount) sub(other amount) amount {
+	return amount{cost: a.cost - other.cost, tokens: a.tokens - other.tokens}
+}
+
+func New(profile string, limits config.Budget, ledger ledger, prices pricer, opts ...Option) *Guard {
+	g := &Guard{
+		profile: profile,
+		limits:  limits,
+		ledger:  ledger,
+		prices:  prices,
+		warn:    func(string) {},
+		now:     time.Now,
+		warned:  make(map[string]bool),
+	}
+
+	if g.limits.WarnAt <= 0 {
+		g.limits.WarnAt = defaultWarnAt
+	}
+	if g.limits.CompletionEstimate <= 0 {
+		g.limits.CompletionEstimate = defaultCompletionEstimate
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g
+}
+
+// Reserve checks that a request with the estimated number of prompt tokens fits the budget
+// and reserves its cost. The returned release must be called with the actual usage
+// once the request is done, or with the zero usage if it failed.
+func (g *Guard) Reserve(model string, promptTokens int) (func(actual dto.Usage), error) {
+	return g.reserve(model, dto.Usage{PromptTokens: promptTokens, CompletionTokens: g.limits.CompletionEstimate}, 1)
+}
+
+// ReserveBatch checks that a batch of requests to the model with the estimated number of prompt tokens
+// fits the budget at the price of the Batch API and reserves its cost like Reserve.
+func (g *Guard) ReserveBatch(model string, promptTokens, requests int) (func(actual dto.Usage), error) {
+	usage := dto.Usage{PromptTokens: promptTokens, CompletionTokens: g.limits.CompletionEstimate * requests}
+
+	return g.reserve(model, usage, pricing.BatchDiscount)
+}
+
+// ReserveEmbeddings checks that an embeddings request with the estimated number of tokens
+// fits the budget and reserves its cost like Reserve, the request has no completion.
+func (g *Guard) ReserveEmbeddings(model string, promptTokens int) (func(actual dto.Usage), error) {
+	return g.reserve(model, dto.Usage{PromptTokens: promptTokens}, 1)
+}
+
+// reserve checks the estimated usage paid at the share of the price given by discount.
+func (g *Guard) reserve(model string, usage dto.Usage, discount float64) (func(actual dto.Usage), error) {
+	if !g.enabled() {
+		return func(dto.Usage) {}, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.load(); err != nil {
+		return nil, err
+	}
+
+	cost, priced := g.prices.Cost(model, usage)
+	if !priced && (g.limits.DailySpend > 0 || g.limits.MonthlySpend > 0) {
+		// Counting the request as free would let any spend through the limits.
+		message := fmt.Sprintf("the price of %s is unknown, the spend limits of profile %s cannot be checked", model, g.profile)
+		if !g.override {
+			return nil, fmt.Errorf("%w: %s, add it to the prices in the config or use --ignore-budget", ErrUnknownPrice, message)
+		}
+		if !g.warned["price "+model] {
+			g.warned["price "+model] = true
+			g.warn(message + ", ignored with --ignore-budget")
+		}
+	}
+	estimate := amount{cost: cost * discount, tokens: usage.Total()}
+
+	day := g.day.add(g.inFlight)
+	month := g.month.add(g.inFlight)
+
+	checks := []struct {
+		name    string
+		spent   float64
+		request float64
+		limit   float64
+		format  string
+	}{
+		{"daily spend", day.cost, estimate.cost, g.limits.DailySpend, "$%.2f"},
+		{"monthly spend", month.cost, estimate.cost, g.limits.MonthlySpend, "$%.2f"},
+		{"daily tokens", float64(day.tokens), float64(estimate.tokens), float64(g.limits.DailyTokens), "%.0f"},
+		{"monthly tokens", float64(month.tokens), float64(estimate.tokens), float64(g.limits.MonthlyTokens), "%.0f"},
+	}
+
+	for _, c := range checks {
+		if c.limit <= 0 {
+			continue
+		}
+
+		limit := fmt.Sprintf(c.format, c.limit)
+
+		switch {
+		case c.spent+c.request > c.limit:
+			message := fmt.Sprintf("the %s limit %s of profile %s would be exceeded: "+c.format+" spent, the request adds about "+c.format,
+				c.name, limit, g.profile, c.spent, c.request)
+			if !g.override {
+				return nil, fmt.Errorf("%w: %s, use --ignore-budget to send it anyway", ErrExceeded, message)
+			}
+			g.warn(message + ", ignored with --ignore-budget")
+		case c.spent+c.request >= c.limit*g.limits.WarnAt && !g.warned[c.name]:
+			g.warned[c.name] = true
+			g.warn(fmt.Sprintf("the request brings the %s of profile %s to "+c.format+" of the %s limit",
+				c.name, g.profile, c.spent+c.request, limit))
+		}
+	}
+
+	g.inFlight = g.inFlight.add(estimate)
+
+	var once sync.Once
+
+	return func(actual dto.Usage) {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+
+			cost, _ := g.prices.Cost(model, actual)
+			spent := amount{cost: cost * discount, tokens: actual.Total()}
+
+			g.inFlight = g.inFlight.sub(estimate)
+			g.day = g.day.add(spent)
+			g.month = g.month.add(spent)
+		})
+	}, nil
+}
+
+func (g *Guard) enabled() bool {
+	return g.limits.DailySpend > 0 || g.limits.MonthlySpend > 0 || g.limits.DailyTokens > 0 || g.limits.MonthlyTokens > 0
+}
+
+// load sums up the recorded usage of the profile again when the ledger has new records,
+// e.g. of the other processes, or when the day changes.
+func (g *Guard) load() error {
+	size, modTime, err := g.ledger.Stat()
+	if err != nil {
+		return fmt.Errorf("read usage: %w", err)
+	}
+
+	now := g.now()
+	if !g.loadedAt.IsZero() && sameDay(g.loadedAt, now) && size == g.size && modTime.Equal(g.modTime) {
+		return nil
+	}
+
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	records, err := g.ledger.Records(monthStart)
+	if err != nil {
+		return fmt.Errorf("read usage: %w", err)
+	}
+
+	g.day, g.month = amount{}, amount{}
+
+	for _, record := range records {
+		if !g.owns(record) {
+			continue
+		}
+
+		cost, _ := g.prices.Cost(record.Model, record.Usage)
+		spent := amount{cost: cost * pricing.Discount(record), tokens: record.Total()}
+
+		g.month = g.month.add(spent)
+		if !record.Time.Before(dayStart) {
+			g.day = g.day.add(spent)
+		}
+	}
+
+	g.loadedAt, g.size, g.modTime = now, size, modTime
+
+	return nil
+}
+
+// owns reports whether the record belongs to the profile, records without a profile belong to the default one.
+func (g *Guard) owns(record dto.UsageRecord) bool {
+	if record.Profile == "" {
+		return g.profile == config.DefaultProfile
+	}
+
+	return record.Profile == g.profile
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,153 @@
+package budget_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/budget"
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
+)
+
+func TestGuard(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+	ledger := usage.NewLedger(t.TempDir())
+	for _, record := range []dto.UsageRecord{
+		{Time: now.AddDate(0, -1, 0), Model: "test", Usage: dto.Usage{PromptTokens: 1_000_000}},
+		{Time: now.AddDate(0, 0, -10), Model: "test", Usage: dto.Usage{PromptTokens: 100_000}},
+		{Time: now.Add(-time.Hour), Model: "test", Profile: "default", Usage: dto.Usage{PromptTokens: 20_000}},
+		{Time: now.Add(-time.Hour), Model: "test", Profile: "work", Usage: dto.Usage{PromptTokens: 1_000_000}},
+	} {
+		assert.NoError(t, ledger.Record(record))
+	}
+
+	// $1 per 100k tokens.
+	prices := pricing.New(map[string]config.Price{"test": {Input: 10, Output: 10}})
+	limits := config.Budget{DailySpend: 0.5, MonthlySpend: 2, CompletionEstimate: 10_000}
+
+	var warnings []string
+	warn := func(message string) {
+		warnings = append(warnings, message)
+	}
+
+	guard := budget.New("default", limits, ledger, prices,
+		budget.WithWarner(warn),
+		budget.WithClock(func() time.Time { return now }),
+	)
+
+	// $0.2 spent today and $0.2 more estimated reach 80% of the daily limit.
+	release, err := guard.Reserve("test", 10_000)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"the request brings the daily spend of profile default to $0.40 of the $0.50 limit"}, warnings)
+
+	// The request in flight counts too.
+	_, err = guard.Reserve("test", 10_000)
+	assert.ErrorIs(t, err, budget.ErrExceeded)
+	assert.ErrorContains(t, err, "the daily spend limit $0.50 of profile default would be exceeded: $0.40 spent")
+
+	// The actual usage replaces the estimate, the warning is shown once.
+	release(dto.Usage{PromptTokens: 5_000})
+	_, err = guard.Reserve("test", 10_000)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+
+	guard = budget.New("default", limits, ledger, prices,
+		budget.WithWarner(warn),
+		budget.WithClock(func() time.Time { return now }),
+		budget.WithOverride(true),
+	)
+
+	_, err = guard.Reserve("test", 50_000)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"the daily spend limit $0.50 of profile default would be exceeded: $0.20 spent, the request adds about $0.60, ignored with --ignore-budget",
+		"the request brings the monthly spend of profile default to $1.80 of the $2.00 limit",
+	}, warnings[1:])
+
+	// A model without a price cannot be checked against the spend limits.
+	_, err = budget.New("default", limits, ledger, prices).Reserve("mystery", 10)
+	assert.ErrorIs(t, err, budget.ErrUnknownPrice)
+	assert.ErrorContains(t, err, "the price of mystery is unknown")
+
+	_, err = budget.New("default", config.Budget{DailyTokens: 100_000}, ledger, prices).Reserve("mystery", 10)
+	assert.NoError(t, err)
+
+	warnings = nil
+	guard = budget.New("default", config.Budget{DailySpend: 10}, ledger, prices,
+		budget.WithWarner(warn),
+		budget.WithOverride(true),
+	)
+	for range 2 {
+		_, err = guard.Reserve("mystery", 10)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{
+		"the price of mystery is unknown, the spend limits of profile default cannot be checked, ignored with --ignore-budget",
+	}, warnings)
+
+	// No limits, nothing to check.
+	guard = budget.New("work", config.Budget{}, ledger, prices)
+	_, err = guard.Reserve("test", 1_000_000)
+	assert.NoError(t, err)
+}
+
+func TestGuard_Batch(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+
+	// The batch usage of 400k tokens is billed as 200k.
+	ledger := usage.NewLedger(t.TempDir())
+	assert.NoError(t, ledger.Record(dto.UsageRecord{
+		Time: now.Add(-time.Hour), Model: "test", Batch: "batch-1", Usage: dto.Usage{PromptTokens: 400_000},
+	}))
+
+	// $1 per 100k tokens.
+	prices := pricing.New(map[string]config.Price{"test": {Input: 10, Output: 10}})
+	limits := config.Budget{DailySpend: 5, CompletionEstimate: 10_000}
+
+	guard := budget.New("default", limits, ledger, prices, budget.WithClock(func() time.Time { return now }))
+
+	// $2 spent and 10 requests of 10k prompt and 10k completion tokens at half the price add $1.
+	release, err := guard.ReserveBatch("test", 100_000, 10)
+	assert.NoError(t, err)
+
+	_, err = guard.ReserveBatch("test", 400_000, 10)
+	assert.ErrorIs(t, err, budget.ErrExceeded)
+	assert.ErrorContains(t, err, "$3.00 spent, the request adds about $2.50")
+
+	release(dto.Usage{})
+	_, err = guard.ReserveBatch("test", 400_000, 10)
+	assert.NoError(t, err)
+}
+
+func TestGuard_OtherProcesses(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	dir := t.TempDir()
+
+	// $1 per 100k tokens.
+	prices := pricing.New(map[string]config.Price{"test": {Input: 10, Output: 10}})
+	limits := config.Budget{DailySpend: 1, CompletionEstimate: 10_000}
+
+	ledger := usage.NewLedger(dir)
+	guard := budget.New("default", limits, ledger, prices, budget.WithClock(func() time.Time { return now }))
+
+	// The process spends $0.20 and records it like the assistant does.
+	spent := dto.Usage{PromptTokens: 10_000, CompletionTokens: 10_000}
+
+	release, err := guard.Reserve("test", 10_000)
+	assert.NoError(t, err)
+	release(spent)
+	assert.NoError(t, ledger.Record(dto.UsageRecord{Time: now, Model: "test", Usage: spent}))
+
+	// Another process spends $0.70 meanwhile, the next request would bring the day to $1.10.
+	other := usage.NewLedger(dir)
+	assert.NoError(t, other.Record(dto.UsageRecord{Time: now, Model: "test", Usage: dto.Usage{PromptTokens: 70_000}}))
+
+	_, err = guard.Reserve("test", 10_000)
+	assert.ErrorIs(t, err, budget.ErrExceeded)
+	assert.ErrorContains(t, err, "$0.90 spent")
+}
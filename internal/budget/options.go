@@ -0,0 +1,28 @@
+package budget
+
+import "time"
+
+type Option func(*Guard)
+
+// WithOverride turns the hard stop into a warning.
+func WithOverride(override bool) Option {
+	return func(g *Guard) {
+		g.override = override
+	}
+}
+
+// WithWarner sets the function that shows the warnings to the user.
+func WithWarner(warn func(message string)) Option {
+	return func(g *Guard) {
+		if warn != nil {
+			g.warn = warn
+		}
+	}
+}
+
+// WithClock replaces the current time, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(g *Guard) {
+		g.now = now
+	}
+}
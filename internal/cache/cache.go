@@ -0,0 +1,306 @@
+// Package cache keeps values on disk under the hash of their key.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+)
+
+const (
+	defaultTTL     = 24 * time.Hour
+	defaultMaxSize = 100 << 20
+
+	fileExt = ".json"
+)
+
+// Cache stores every value in its own file named after the hash of the key.
+// Entries expire the TTL after they were stored, the least recently used ones are evicted
+// once the cache grows over the max size. The creation time of an entry is kept in the entry,
+// the modification time of its file only tracks the last use.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+	now     func() time.Time
+
+	mu sync.Mutex
+}
+
+type entry struct {
+	CreatedAt time.Time       `json:"created_at"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// Stats describes the entries of the cache.
+type Stats struct {
+	Dir     string
+	Entries int
+	// Expired counts the entries stored longer than the TTL ago, they are removed by the next Put.
+	Expired int
+	Size    int64
+	MaxSize int64
+	TTL     time.Duration
+	// Oldest and Newest are the creation times of the entries.
+	Oldest time.Time
+	Newest time.Time
+}
+
+func New(dir string, opts ...Option) *Cache {
+	c := &Cache{
+		dir:     dir,
+		ttl:     defaultTTL,
+		maxSize: defaultMaxSize,
+		now:     time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Key hashes the JSON encoding of the value, equal values always have the same key.
+func Key(value any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("marshal cache key: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Get reads the value stored under the key into value and reports whether it was found.
+func (c *Cache) Get(key string, value any) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(key)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read cache entry: %w", err)
+	}
+
+	var e entry
+	if err = json.Unmarshal(data, &e); err != nil || c.expired(e.CreatedAt) {
+		// A broken or stale entry is a miss, it is replaced by the next Put.
+		_ = os.Remove(path)
+		return false, nil
+	}
+
+	if err = json.Unmarshal(e.Value, value); err != nil {
+		return false, fmt.Errorf("unmarshal cache entry: %w", err)
+	}
+
+	// The modification time tracks the last use for the eviction.
+	now := c.now()
+	_ = os.Chtimes(path, now, now)
+
+	return true, nil
+}
+
+// Put stores the value under the key and evicts the entries over the max size.
+func (c *Cache) Put(key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+
+	data, err := json.Marshal(entry{CreatedAt: c.now(), Value: raw})
+	if err != nil {
+		return fmt.Errorf("marshal cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+
+	if err = atomicfile.Write(c.path(key), data, 0o600); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+
+	now := c.now()
+	_ = os.Chtimes(c.path(key), now, now)
+
+	return c.evict()
+}
+
+// Stats counts the entries of the cache.
+func (c *Cache) Stats() (Stats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := Stats{
+		Dir:     c.dir,
+		MaxSize: c.maxSize,
+		TTL:     c.ttl,
+	}
+
+	files, err := c.files()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	for _, file := range files {
+		stats.Entries++
+		stats.Size += file.size
+
+		if c.expired(file.createdAt) {
+			stats.Expired++
+		}
+		if stats.Oldest.IsZero() || file.createdAt.Before(stats.Oldest) {
+			stats.Oldest = file.createdAt
+		}
+		if file.createdAt.After(stats.Newest) {
+			stats.Newest = file.createdAt
+		}
+	}
+
+	return stats, nil
+}
+
+// Clear removes all entries and returns how many there were.
+func (c *Cache) Clear() (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, file := range files {
+		if err = os.Remove(file.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, fmt.Errorf("remove cache entry: %w", err)
+		}
+	}
+
+	return len(files), nil
+}
+
+type file struct {
+	path string
+	size int64
+	// createdAt expires the entry, usedAt orders the entries for the eviction.
+	createdAt time.Time
+	usedAt    time.Time
+}
+
+// evict removes the expired entries and then the least recently used ones until the cache fits the max size.
+func (c *Cache) evict() error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].usedAt.Before(files[j].usedAt)
+	})
+
+	var size int64
+	for _, file := range files {
+		size += file.size
+	}
+
+	for _, file := range files {
+		if size <= c.maxSize && !c.expired(file.createdAt) {
+			continue
+		}
+
+		if err = os.Remove(file.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("evict cache entry: %w", err)
+		}
+
+		size -= file.size
+	}
+
+	return nil
+}
+
+func (c *Cache) files() ([]file, error) {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read cache dir: %w", err)
+	}
+
+	files := make([]file, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), fileExt) {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(c.dir, e.Name())
+
+		files = append(files, file{
+			path:      path,
+			size:      info.Size(),
+			createdAt: createdAt(path),
+			usedAt:    info.ModTime(),
+		})
+	}
+
+	return files, nil
+}
+
+// createdAt reads the creation time at the start of the entry without reading its value.
+// A broken entry gets the zero time, so it counts as expired.
+func createdAt(path string) time.Time {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+
+	// The entry starts with the creation time, the value after it is not needed.
+	dec := json.NewDecoder(f)
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return time.Time{}
+	}
+	if token, err := dec.Token(); err != nil || token != "created_at" {
+		return time.Time{}
+	}
+	var created time.Time
+	if err = dec.Decode(&created); err != nil {
+		return time.Time{}
+	}
+
+	return created
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+fileExt)
+}
+
+// expired reports whether an entry created at t is stale.
+func (c *Cache) expired(t time.Time) bool {
+	return c.ttl > 0 && c.now().Sub(t) > c.ttl
+}
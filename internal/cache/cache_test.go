@@ -0,0 +1,130 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/cache"
+)
+
+type value struct {
+	Text string `json:"text"`
+}
+
+func TestKey(t *testing.T) {
+	a, err := cache.Key(value{Text: "a"})
+	assert.NoError(t, err)
+
+	same, err := cache.Key(value{Text: "a"})
+	assert.NoError(t, err)
+
+	b, err := cache.Key(value{Text: "b"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, a, same)
+	assert.NotEqual(t, a, b)
+	assert.Len(t, a, 64)
+}
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+
+	c := cache.New(t.TempDir(), cache.WithTTL(time.Hour), cache.WithClock(clock))
+
+	var v value
+
+	found, err := c.Get("a", &v)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, c.Put("a", value{Text: "hello"}))
+
+	found, err = c.Get("a", &v)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, value{Text: "hello"}, v)
+
+	stats, err := c.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Positive(t, stats.Size)
+
+	// The entry expires after the TTL.
+	now = now.Add(2 * time.Hour)
+
+	found, err = c.Get("a", &v)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	assert.NoError(t, c.Put("b", value{Text: "b"}))
+	assert.NoError(t, c.Put("c", value{Text: "c"}))
+
+	n, err := c.Clear()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	stats, err = c.Stats()
+	assert.NoError(t, err)
+	assert.Zero(t, stats.Entries)
+}
+
+func TestCache_Evict(t *testing.T) {
+	dir := t.TempDir()
+
+	// Every entry takes about 70 bytes, two of them fit.
+	c := cache.New(dir, cache.WithMaxSize(150))
+
+	assert.NoError(t, c.Put("a", value{Text: "a"}))
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, c.Put("b", value{Text: "b"}))
+	time.Sleep(10 * time.Millisecond)
+
+	// Using an entry keeps it from the eviction.
+	var v value
+	found, err := c.Get("a", &v)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	assert.NoError(t, c.Put("c", value{Text: "c"}))
+
+	for key, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+		found, err = c.Get(key, &v)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, found, key)
+	}
+}
+
+func TestCache_Expired(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	c := cache.New(t.TempDir(), cache.WithTTL(time.Hour), cache.WithClock(clock))
+
+	assert.NoError(t, c.Put("a", value{Text: "a"}))
+	now = now.Add(40 * time.Minute)
+	assert.NoError(t, c.Put("b", value{Text: "b"}))
+
+	// Using an entry does not extend its life.
+	now = now.Add(30 * time.Minute)
+	var v value
+	found, err := c.Get("b", &v)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	stats, err := c.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 1, stats.Expired)
+	assert.Equal(t, time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), stats.Oldest.UTC())
+	assert.Equal(t, time.Date(2026, 10, 18, 12, 40, 0, 0, time.UTC), stats.Newest.UTC())
+
+	// The next Put removes the expired entry.
+	assert.NoError(t, c.Put("c", value{Text: "c"}))
+	stats, err = c.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Zero(t, stats.Expired)
+}
@@ -0,0 +1,30 @@
+package cache
+
+import "time"
+
+type Option func(*Cache)
+
+// WithTTL sets how long the entries are kept, zero keeps the default.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// WithMaxSize bounds the total size of the entries in bytes, zero keeps the default.
+func WithMaxSize(size int64) Option {
+	return func(c *Cache) {
+		if size > 0 {
+			c.maxSize = size
+		}
+	}
+}
+
+// WithClock replaces the current time, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
@@ -0,0 +1,292 @@
+// Package mock is an offline provider with deterministic answers for demos and tests of scripts.
+// It echoes the questions or answers them from fixtures, with an optional latency, injected errors,
+// calls of tools and answers streamed in chunks.
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/stream"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+)
+
+const (
+	defaultModel    = "mock"
+	finishStop      = "stop"
+	finishToolCalls = "tool_calls"
+)
+
+type Client struct {
+	model    string
+	log      *logger.Logger
+	params   dto.Params
+	fixtures Fixtures
+	latency  time.Duration
+	err      string
+
+	mu sync.Mutex
+	// steps are the positions in the scripts of the rules.
+	steps map[int]int
+	// calls numbers the tool calls for their IDs.
+	calls int
+}
+
+func New(log *logger.Logger, opts ...Option) *Client {
+	c := &Client{
+		model: defaultModel,
+		log:   log,
+		steps: make(map[int]int),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Client) Model() string {
+	return c.model
+}
+
+func (c *Client) SetModel(model string) {
+	c.model = model
+}
+
+// Params returns the default sampling parameters.
+func (c *Client) Params() dto.Params {
+	return c.params.Clone()
+}
+
+// CreateChatCompletion answers the last user message, every choice takes the next step of the script.
+// A single answer is streamed to the handler of the context if there is one, see stream.WithHandler.
+func (c *Client) CreateChatCompletion(ctx context.Context, chat *dto.Chat) (dto.Completion, error) {
+	question := lastQuestion(chat)
+
+	rule, index := c.match(question)
+
+	if c.err != "" {
+		if err := sleep(ctx, c.latencyOf(rule, Step{})); err != nil {
+			return dto.Completion{}, fmt.Errorf("create chat completion: %w", err)
+		}
+
+		return dto.Completion{}, fmt.Errorf("create chat completion: %w", inject(c.err))
+	}
+
+	// The first step sets the latency, the next ones are taken only if it is not an error.
+	first := c.next(rule, index, question)
+	if err := sleep(ctx, c.latencyOf(rule, first)); err != nil {
+		return dto.Completion{}, fmt.Errorf("create chat completion: %w", err)
+	}
+
+	n := max(c.params.Merge(chat.Params).N, 1)
+
+	choices := make([]dto.Choice, 0, n)
+	for i := range n {
+		step := first
+		if i > 0 {
+			step = c.next(rule, index, question)
+		}
+		if step.Error != "" {
+			return dto.Completion{}, fmt.Errorf("create chat completion: %w", inject(step.Error))
+		}
+
+		choices = append(choices, c.choiceOf(step))
+	}
+
+	if handler, ok := stream.FromContext(ctx); ok && n == 1 {
+		if err := send(ctx, first, handler); err != nil {
+			return dto.Completion{}, fmt.Errorf("create chat completion: %w", err)
+		}
+	}
+
+	model := c.model
+	if chat.Model != "" {
+		model = chat.Model
+	}
+
+	completion := dto.Completion{
+		Content:      choices[0].Content,
+		FinishReason: choices[0].FinishReason,
+		ToolCalls:    choices[0].ToolCalls,
+		Model:        model,
+		Usage: dto.Usage{
+			PromptTokens:     tokens.EstimateMessages(append(chat.Preamble(), chat.Messages()...)),
+			CompletionTokens: tokens.Estimate(choices[0].Content) * n,
+		},
+	}
+	if n > 1 {
+		completion.Choices = choices
+	}
+
+	fields := []logger.Field{logger.WithField("rule", index)}
+	if c.log.Prompts() {
+		fields = append(fields, logger.WithField("question", question))
+	}
+	c.log.Debug("mock CreateChatCompletion", fields...)
+
+	return completion, nil
+}
+
+func (c *Client) GetModels(ctx context.Context) ([]string, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0, len(models))
+	for _, model := range models {
+		list = append(list, model.ID)
+	}
+
+	return list, nil
+}
+
+// ListModels returns the models of the fixtures, or the current model without them.
+func (c *Client) ListModels(context.Context) ([]dto.Model, error) {
+	ids := c.fixtures.Models
+	if len(ids) == 0 {
+		ids = []string{c.model}
+	}
+
+	list := make([]dto.Model, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, dto.Model{ID: id, OwnedBy: "mock"})
+	}
+
+	return list, nil
+}
+
+// match returns the first rule matching the question and its index, or nil if there is none.
+func (c *Client) match(question string) (*Rule, int) {
+	for i := range c.fixtures.Rules {
+		rule := &c.fixtures.Rules[i]
+		if rule.pattern.MatchString(question) {
+			return rule, i
+		}
+	}
+
+	return nil, -1
+}
+
+// next returns the next step of the script of the rule, the last step repeats.
+// Without a rule the question is echoed unless the fixtures have a default answer.
+func (c *Client) next(rule *Rule, index int, question string) Step {
+	if rule == nil {
+		if c.fixtures.Default != "" {
+			return Step{Answer: c.fixtures.Default}
+		}
+		return Step{Answer: question}
+	}
+
+	c.mu.Lock()
+	i := c.steps[index]
+	if i < len(rule.Script)-1 {
+		c.steps[index]++
+	}
+	c.mu.Unlock()
+
+	step := rule.Script[i]
+	if rule.Expand {
+		match := rule.pattern.FindStringSubmatchIndex(question)
+		expand := func(template string) string {
+			return string(rule.pattern.ExpandString(nil, template, question, match))
+		}
+
+		step.Answer = expand(step.Answer)
+
+		chunks := make([]string, 0, len(step.Chunks))
+		for _, chunk := range step.Chunks {
+			chunks = append(chunks, expand(chunk))
+		}
+		step.Chunks = chunks
+	}
+
+	return step
+}
+
+// latencyOf returns the latency of the step, the one of the rule or the one of the provider.
+func (c *Client) latencyOf(rule *Rule, step Step) time.Duration {
+	switch {
+	case step.Latency > 0:
+		return step.Latency
+	case rule != nil && rule.Latency > 0:
+		return rule.Latency
+	default:
+		return c.latency
+	}
+}
+
+// choiceOf returns the answer of the step, the tool calls get the next IDs.
+func (c *Client) choiceOf(step Step) dto.Choice {
+	choice := dto.Choice{Content: step.content(), FinishReason: finishStop}
+	if len(step.ToolCalls) == 0 {
+		return choice
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, call := range step.ToolCalls {
+		c.calls++
+		choice.ToolCalls = append(choice.ToolCalls, dto.ToolCall{
+			ID:        fmt.Sprintf("call_%d", c.calls),
+			Name:      call.Name,
+			Arguments: call.arguments,
+		})
+	}
+	choice.FinishReason = finishToolCalls
+
+	return choice
+}
+
+func lastQuestion(chat *dto.Chat) string {
+	messages := chat.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == dto.RoleUser {
+			return messages[i].Content
+		}
+	}
+
+	return ""
+}
+
+// send passes the chunks of the step to the handler with the interval between them, an answer is one chunk.
+func send(ctx context.Context, step Step, handler stream.Handler) error {
+	chunks := step.Chunks
+	if len(chunks) == 0 && step.Answer != "" {
+		chunks = []string{step.Answer}
+	}
+
+	for i, chunk := range chunks {
+		if i > 0 {
+			if err := sleep(ctx, step.Interval); err != nil {
+				return err
+			}
+		}
+		handler(chunk)
+	}
+
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
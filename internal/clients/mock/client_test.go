@@ -0,0 +1,279 @@
+package mock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/mock"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/stream"
+)
+
+const fixtures = `
+default: I do not know.
+models: [mock, mock-large]
+rules:
+  - match: (?i)capital of (\w+)
+    expand: true
+    answer: The capital of $1 is a secret.
+  - match: (?i)price of (\w+)
+    answer: The $1 costs $5.
+  - match: flaky
+    script:
+      - error: rate_limit
+      - answer: first
+      - answer: second
+  - match: slow
+    latency: 1h
+    answer: too late
+  - match: weather in (\w+)
+    expand: true
+    script:
+      - tool_calls:
+          - name: get_weather
+            arguments: {city: Paris, days: 2}
+          - name: get_time
+            arguments: '{"zone": "CET"}'
+      - chunks: [It is sunny, " in $1."]
+        interval: 1ms
+  - match: retry
+    script:
+      - error: server
+        latency: 1h
+      - answer: done
+`
+
+func newChat(question string) *dto.Chat {
+	chat := dto.NewChat()
+	chat.AddMessage(dto.RoleUser, question)
+
+	return chat
+}
+
+func newClient(t *testing.T, opts ...mock.Option) *mock.Client {
+	t.Helper()
+
+	f, err := mock.ParseFixtures([]byte(fixtures))
+	assert.NoError(t, err)
+
+	return mock.New(logger.New(nil, logger.WithEnabled(false)), append([]mock.Option{mock.WithFixtures(f)}, opts...)...)
+}
+
+func TestClient_Echo(t *testing.T) {
+	c := mock.New(logger.New(nil, logger.WithEnabled(false)))
+
+	completion, err := c.CreateChatCompletion(context.Background(), newChat("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", completion.Content)
+	assert.Equal(t, "stop", completion.FinishReason)
+	assert.Equal(t, "mock", completion.Model)
+	assert.Positive(t, completion.Usage.PromptTokens)
+	assert.Positive(t, completion.Usage.CompletionTokens)
+}
+
+func TestClient_Fixtures(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+
+	completion, err := c.CreateChatCompletion(ctx, newChat("What is the capital of France?"))
+	assert.NoError(t, err)
+	assert.Equal(t, "The capital of France is a secret.", completion.Content)
+
+	// The answers of a rule without expand are literal.
+	completion, err = c.CreateChatCompletion(ctx, newChat("What is the price of bread?"))
+	assert.NoError(t, err)
+	assert.Equal(t, "The $1 costs $5.", completion.Content)
+
+	completion, err = c.CreateChatCompletion(ctx, newChat("anything else"))
+	assert.NoError(t, err)
+	assert.Equal(t, "I do not know.", completion.Content)
+}
+
+func TestClient_Script(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+
+	_, err := c.CreateChatCompletion(ctx, newChat("flaky"))
+	assert.Equal(t, failure.KindRateLimit, failure.KindOf(err))
+
+	var answers []string
+	for range 3 {
+		completion, err := c.CreateChatCompletion(ctx, newChat("flaky"))
+		assert.NoError(t, err)
+		answers = append(answers, completion.Content)
+	}
+
+	// The last step repeats.
+	assert.Equal(t, []string{"first", "second", "second"}, answers)
+}
+
+func TestClient_Choices(t *testing.T) {
+	c := newClient(t)
+
+	chat := newChat("flaky")
+	chat.Params.N = 2
+
+	_, err := c.CreateChatCompletion(context.Background(), chat)
+	assert.Error(t, err)
+
+	completion, err := c.CreateChatCompletion(context.Background(), chat)
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.Choice{{Content: "first", FinishReason: "stop"}, {Content: "second", FinishReason: "stop"}}, completion.Choices)
+}
+
+func TestClient_Error(t *testing.T) {
+	tests := map[string]failure.Kind{
+		"auth":            failure.KindAuth,
+		"quota":           failure.KindQuota,
+		"rate_limit":      failure.KindRateLimit,
+		"model_not_found": failure.KindModelNotFound,
+		"context_length":  failure.KindContextLength,
+		"content_filter":  failure.KindContentFilter,
+		"server":          failure.KindUnknown,
+		"unavailable":     failure.KindNetwork,
+		"network":         failure.KindNetwork,
+		"timeout":         failure.KindTimeout,
+	}
+
+	assert.Len(t, mock.ErrorNames(), len(tests))
+
+	for name, kind := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, mock.CheckError(name))
+
+			c := mock.New(logger.New(nil, logger.WithEnabled(false)), mock.WithError(name))
+
+			_, err := c.CreateChatCompletion(context.Background(), newChat("hello"))
+			assert.Equal(t, kind, failure.KindOf(err))
+		})
+	}
+
+	assert.ErrorIs(t, mock.CheckError("bogus"), mock.ErrUnknownError)
+}
+
+func TestClient_Latency(t *testing.T) {
+	c := newClient(t, mock.WithLatency(time.Millisecond))
+
+	_, err := c.CreateChatCompletion(context.Background(), newChat("hello"))
+	assert.NoError(t, err)
+
+	// The latency of the rule overrides the one of the client.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = c.CreateChatCompletion(ctx, newChat("slow"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_ToolCalls(t *testing.T) {
+	ctx := context.Background()
+	c := newClient(t)
+
+	completion, err := c.CreateChatCompletion(ctx, newChat("What is the weather in Paris?"))
+	assert.NoError(t, err)
+	assert.Equal(t, "tool_calls", completion.FinishReason)
+	assert.Empty(t, completion.Content)
+	assert.Equal(t, []dto.ToolCall{
+		{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Paris","days":2}`},
+		{ID: "call_2", Name: "get_time", Arguments: `{"zone": "CET"}`},
+	}, completion.ToolCalls)
+
+	// The next step answers with the results of the calls, its chunks are joined without a stream.
+	completion, err = c.CreateChatCompletion(ctx, newChat("What is the weather in Paris?"))
+	assert.NoError(t, err)
+	assert.Equal(t, "stop", completion.FinishReason)
+	assert.Equal(t, "It is sunny in Paris.", completion.Content)
+	assert.Empty(t, completion.ToolCalls)
+}
+
+func TestClient_Stream(t *testing.T) {
+	c := newClient(t)
+
+	var pieces []string
+	ctx := stream.WithHandler(context.Background(), func(piece string) {
+		pieces = append(pieces, piece)
+	})
+
+	_, err := c.CreateChatCompletion(ctx, newChat("weather in Rome"))
+	assert.NoError(t, err)
+	assert.Empty(t, pieces)
+
+	completion, err := c.CreateChatCompletion(ctx, newChat("weather in Rome"))
+	assert.NoError(t, err)
+	assert.Equal(t, "It is sunny in Rome.", completion.Content)
+	assert.Equal(t, []string{"It is sunny", " in Rome."}, pieces)
+
+	// An answer without chunks is streamed in one piece.
+	pieces = nil
+
+	_, err = c.CreateChatCompletion(ctx, newChat("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"I do not know."}, pieces)
+
+	// Several answers are not streamed.
+	pieces = nil
+
+	chat := newChat("hello")
+	chat.Params.N = 2
+
+	_, err = c.CreateChatCompletion(ctx, chat)
+	assert.NoError(t, err)
+	assert.Empty(t, pieces)
+}
+
+func TestClient_StepLatency(t *testing.T) {
+	c := newClient(t)
+
+	// The first step of the script is slow, the retry is not.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.CreateChatCompletion(ctx, newChat("retry"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	completion, err := c.CreateChatCompletion(context.Background(), newChat("retry"))
+	assert.NoError(t, err)
+	assert.Equal(t, "done", completion.Content)
+}
+
+func TestClient_ListModels(t *testing.T) {
+	ctx := context.Background()
+
+	models, err := newClient(t).GetModels(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mock", "mock-large"}, models)
+
+	models, err = mock.New(logger.New(nil, logger.WithEnabled(false)), mock.WithModel("gpt-4o")).GetModels(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gpt-4o"}, models)
+}
+
+func TestParseFixtures(t *testing.T) {
+	_, err := mock.ParseFixtures([]byte("rules:\n  - match: \"(\"\n"))
+	assert.Error(t, err)
+
+	_, err = mock.ParseFixtures([]byte("rules:\n  - match: x\n    error: bogus\n"))
+	assert.ErrorIs(t, err, mock.ErrUnknownError)
+
+	// A misspelled key fails rather than being ignored.
+	_, err = mock.ParseFixtures([]byte("rules:\n  - match: x\n    anwser: y\n"))
+	assert.ErrorContains(t, err, "anwser")
+
+	_, err = mock.ParseFixtures([]byte("rules:\n  - match: x\n    answer: y\n    chunks: [y]\n"))
+	assert.Error(t, err)
+
+	_, err = mock.ParseFixtures([]byte("rules:\n  - match: x\n    tool_calls: [{arguments: {}}]\n"))
+	assert.ErrorContains(t, err, "no name")
+
+	_, err = mock.ParseFixtures([]byte("rules:\n  - match: x\n    tool_calls: [{name: f, arguments: '{'}]\n"))
+	assert.ErrorContains(t, err, "JSON")
+
+	f, err := mock.ParseFixtures(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, f.Rules)
+}
@@ -0,0 +1,69 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+)
+
+const (
+	embeddingModel      = "mock-embedding"
+	embeddingDimensions = 64
+)
+
+// EmbeddingModel returns the model of the embeddings.
+func (c *Client) EmbeddingModel() string {
+	return embeddingModel
+}
+
+// CreateEmbeddings returns vectors of the hashed words of the texts, the texts sharing words are close.
+func (c *Client) CreateEmbeddings(ctx context.Context, texts []string) (dto.Embeddings, error) {
+	if err := sleep(ctx, c.latency); err != nil {
+		return dto.Embeddings{}, fmt.Errorf("create embeddings: %w", err)
+	}
+
+	if c.err != "" {
+		return dto.Embeddings{}, fmt.Errorf("create embeddings: %w", inject(c.err))
+	}
+
+	embeddings := dto.Embeddings{Model: embeddingModel, Vectors: make([][]float32, 0, len(texts))}
+	for _, text := range texts {
+		embeddings.Vectors = append(embeddings.Vectors, embed(text))
+		embeddings.Usage.PromptTokens += tokens.Estimate(text)
+	}
+
+	return embeddings, nil
+}
+
+func embed(text string) []float32 {
+	vector := make([]float32, embeddingDimensions)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(word))
+		vector[h.Sum32()%embeddingDimensions]++
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		return vector
+	}
+
+	for i := range vector {
+		vector[i] /= float32(math.Sqrt(norm))
+	}
+
+	return vector
+}
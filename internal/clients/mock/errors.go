@@ -0,0 +1,74 @@
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrUnknownError is returned for an error name the mock provider cannot inject.
+var ErrUnknownError = errors.New("unknown error")
+
+// injected are the errors the mock provider can fail with, they look like the ones of the OpenAI API.
+var injected = map[string]func() error{
+	"auth":            apiError(http.StatusUnauthorized, "invalid_api_key", "Incorrect API key provided."),
+	"quota":           apiError(http.StatusTooManyRequests, "insufficient_quota", "You exceeded your current quota."),
+	"rate_limit":      apiError(http.StatusTooManyRequests, "rate_limit_exceeded", "Rate limit reached for requests."),
+	"model_not_found": apiError(http.StatusNotFound, "model_not_found", "The model does not exist."),
+	"context_length":  apiError(http.StatusBadRequest, "context_length_exceeded", "This model's maximum context length is exceeded."),
+	"content_filter":  apiError(http.StatusBadRequest, "content_filter", "The request was rejected by the content filter."),
+	"server":          apiError(http.StatusInternalServerError, "server_error", "The server had an error."),
+	"unavailable":     apiError(http.StatusServiceUnavailable, "", "The server is overloaded."),
+	"network": func() error {
+		return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	},
+	"timeout": func() error {
+		return &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	},
+}
+
+// ErrorNames returns the names of the errors that can be injected.
+func ErrorNames() []string {
+	names := make([]string, 0, len(injected))
+	for name := range injected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// CheckError checks that the error with the name can be injected.
+func CheckError(name string) error {
+	if _, ok := injected[name]; !ok {
+		return fmt.Errorf("%w %q, use %s", ErrUnknownError, name, strings.Join(ErrorNames(), ", "))
+	}
+
+	return nil
+}
+
+// inject returns the error with the name, e.g. rate_limit, the name is checked beforehand.
+func inject(name string) error {
+	return injected[name]()
+}
+
+func apiError(status int, code, message string) func() error {
+	return func() error {
+		err := &openai.APIError{
+			HTTPStatusCode: status,
+			Message:        message,
+			Type:           "mock_error",
+		}
+		if code != "" {
+			err.Code = code
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,188 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures are the canned answers of the mock provider, e.g.
+//
+//	default: I do not know.    # the question is echoed without it
+//	models: [mock, mock-large] # the model is the only one without them
+//	rules:
+//	  - match: (?i)capital of (\w+)
+//	    expand: true           # $1 is the first group of the pattern, $$ is a dollar sign
+//	    answer: The capital of $1 is a secret.
+//	  - match: flaky
+//	    latency: 2s
+//	    script:                # the answers in turn, the last one repeats
+//	      - error: rate_limit
+//	      - answer: It worked the second time.
+//	  - match: (?i)weather
+//	    script:
+//	      - tool_calls:        # the next question is the same, the answer comes with the result of the call
+//	          - name: get_weather
+//	            arguments: {city: Paris}
+//	      - answer: It is sunny in Paris.
+//	  - match: (?i)story
+//	    chunks: [Once, " upon", " a time."]
+//	    interval: 100ms        # between the chunks when the answer is streamed
+//
+// The rules are checked in order, the first one matching the last user message answers. Unknown keys are refused.
+type Fixtures struct {
+	Default string   `yaml:"default"`
+	Models  []string `yaml:"models"`
+	Rules   []Rule   `yaml:"rules"`
+}
+
+// Rule answers the questions matching the pattern.
+type Rule struct {
+	Match string `yaml:"match"`
+	// Expand replaces $1 or ${name} in the answers with the groups of the pattern, the answers are literal without it.
+	Expand bool `yaml:"expand"`
+	// Step is a script of one step, its latency is the one of every step of the script.
+	Step   `yaml:",inline"`
+	Script []Step `yaml:"script"`
+
+	pattern *regexp.Regexp
+}
+
+// Step is an answer, the calls of tools or an error.
+type Step struct {
+	// Answer is the content, or Chunks are its pieces streamed Interval apart.
+	Answer    string        `yaml:"answer"`
+	Chunks    []string      `yaml:"chunks"`
+	Interval  time.Duration `yaml:"interval"`
+	ToolCalls []ToolCall    `yaml:"tool_calls"`
+	Error     string        `yaml:"error"`
+	// Latency delays the step, it overrides the latency of the rule and the one of the provider.
+	Latency time.Duration `yaml:"latency"`
+}
+
+// ToolCall is a call of a function tool, the arguments are a map or a JSON object in a string.
+type ToolCall struct {
+	Name      string `yaml:"name"`
+	Arguments any    `yaml:"arguments"`
+
+	arguments string
+}
+
+// content returns the answer or its pieces joined.
+func (s Step) content() string {
+	if len(s.Chunks) > 0 {
+		return strings.Join(s.Chunks, "")
+	}
+
+	return s.Answer
+}
+
+// LoadFixtures reads the fixtures from a YAML file.
+func LoadFixtures(path string) (Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixtures{}, fmt.Errorf("read fixtures: %w", err)
+	}
+
+	f, err := ParseFixtures(data)
+	if err != nil {
+		return Fixtures{}, fmt.Errorf("fixtures %s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// ParseFixtures parses the YAML of the fixtures and checks the patterns and the errors of the rules.
+func ParseFixtures(data []byte) (Fixtures, error) {
+	var f Fixtures
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return Fixtures{}, fmt.Errorf("parse: %w", err)
+	}
+
+	if err := f.compile(); err != nil {
+		return Fixtures{}, err
+	}
+
+	return f, nil
+}
+
+func (f *Fixtures) compile() error {
+	for i := range f.Rules {
+		rule := &f.Rules[i]
+
+		pattern, err := regexp.Compile(rule.Match)
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		rule.pattern = pattern
+
+		if len(rule.Script) == 0 {
+			rule.Script = []Step{rule.Step}
+		}
+
+		for j := range rule.Script {
+			if err = rule.Script[j].compile(); err != nil {
+				return fmt.Errorf("rule %d: %w", i+1, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Step) compile() error {
+	if s.Answer != "" && len(s.Chunks) > 0 {
+		return errors.New("a step has either an answer or chunks")
+	}
+
+	if s.Error != "" {
+		if err := CheckError(s.Error); err != nil {
+			return err
+		}
+	}
+
+	for i := range s.ToolCalls {
+		call := &s.ToolCalls[i]
+		if call.Name == "" {
+			return fmt.Errorf("tool call %d has no name", i+1)
+		}
+
+		arguments, err := argumentsOf(call.Arguments)
+		if err != nil {
+			return fmt.Errorf("tool call %s: %w", call.Name, err)
+		}
+		call.arguments = arguments
+	}
+
+	return nil
+}
+
+// argumentsOf returns the arguments of a tool call as a JSON object.
+func argumentsOf(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "{}", nil
+	case string:
+		if !json.Valid([]byte(v)) {
+			return "", errors.New("the arguments are not valid JSON")
+		}
+		return v, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("arguments: %w", err)
+		}
+		return string(data), nil
+	}
+}
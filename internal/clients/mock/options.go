@@ -0,0 +1,45 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+type Option func(*Client)
+
+func WithModel(model string) Option {
+	return func(c *Client) {
+		if model != "" {
+			c.model = model
+		}
+	}
+}
+
+// WithParams sets the default sampling parameters, the parameters of a chat override them.
+func WithParams(params dto.Params) Option {
+	return func(c *Client) {
+		c.params = params
+	}
+}
+
+// WithFixtures answers the questions from the fixtures instead of echoing them.
+func WithFixtures(fixtures Fixtures) Option {
+	return func(c *Client) {
+		c.fixtures = fixtures
+	}
+}
+
+// WithLatency delays every answer, the latency of a rule overrides it.
+func WithLatency(latency time.Duration) Option {
+	return func(c *Client) {
+		c.latency = latency
+	}
+}
+
+// WithError fails every request with the error, e.g. rate_limit, see CheckError.
+func WithError(name string) Option {
+	return func(c *Client) {
+		c.err = name
+	}
+}
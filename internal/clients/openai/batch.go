@@ -0,0 +1,251 @@
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+)
+
+const batchFileName = "chatgpt-cli-batch.jsonl"
+
+// CreateBatch uploads the requests and creates a batch answering them within 24 hours.
+func (c *Client) CreateBatch(ctx context.Context, items []dto.BatchItem) (dto.BatchJob, error) {
+	upload := openai.UploadBatchFileRequest{FileName: batchFileName}
+	for _, item := range items {
+		upload.AddChatCompletion(item.ID, c.toCreateChatCompletionIn(item.Chat))
+	}
+
+	file, err := c.client.UploadBatchFile(ctx, upload)
+	if err != nil {
+		return dto.BatchJob{}, fmt.Errorf("upload batch file: %w", err)
+	}
+
+	c.log.Debug("openai out UploadBatchFile", logger.WithField("out", file))
+
+	out, err := c.client.CreateBatch(ctx, openai.CreateBatchRequest{
+		InputFileID: file.ID,
+		Endpoint:    openai.BatchEndpointChatCompletions,
+	})
+	if err != nil {
+		return dto.BatchJob{}, fmt.Errorf("create batch: %w", err)
+	}
+
+	c.log.Debug("openai out CreateBatch", logger.WithField("out", out))
+
+	return toBatchJob(out.Batch), nil
+}
+
+// Batches returns the latest batches, the newest first.
+func (c *Client) Batches(ctx context.Context, limit int) ([]dto.BatchJob, error) {
+	out, err := c.client.ListBatch(ctx, nil, &limit)
+	if err != nil {
+		return nil, fmt.Errorf("list batches: %w", err)
+	}
+
+	c.log.Debug("openai out ListBatch", logger.WithField("out", out))
+
+	jobs := make([]dto.BatchJob, 0, len(out.Data))
+	for _, batch := range out.Data {
+		jobs = append(jobs, toBatchJob(batch))
+	}
+
+	return jobs, nil
+}
+
+func (c *Client) Batch(ctx context.Context, id string) (dto.BatchJob, error) {
+	out, err := c.client.RetrieveBatch(ctx, id)
+	if err != nil {
+		return dto.BatchJob{}, fmt.Errorf("retrieve batch: %w", err)
+	}
+
+	c.log.Debug("openai out RetrieveBatch", logger.WithField("out", out))
+
+	return toBatchJob(out.Batch), nil
+}
+
+func (c *Client) CancelBatch(ctx context.Context, id string) (dto.BatchJob, error) {
+	out, err := c.client.CancelBatch(ctx, id)
+	if err != nil {
+		return dto.BatchJob{}, fmt.Errorf("cancel batch: %w", err)
+	}
+
+	c.log.Debug("openai out CancelBatch", logger.WithField("out", out))
+
+	return toBatchJob(out.Batch), nil
+}
+
+// BatchResults merges the output and the error files of the batch into a result per request
+// in the order of the input file. The requests without an answer get an error.
+func (c *Client) BatchResults(ctx context.Context, job dto.BatchJob) ([]dto.BatchResult, error) {
+	if job.OutputFileID == "" && job.ErrorFileID == "" {
+		return nil, fmt.Errorf("batch %s has no results, its status is %s", job.ID, job.Status)
+	}
+
+	input, err := c.fileLines(ctx, job.InputFileID)
+	if err != nil {
+		return nil, fmt.Errorf("read input file: %w", err)
+	}
+
+	results := make(map[string]dto.BatchResult)
+	for _, fileID := range []string{job.OutputFileID, job.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+
+		lines, err := c.fileLines(ctx, fileID)
+		if err != nil {
+			return nil, fmt.Errorf("read result file: %w", err)
+		}
+
+		for _, line := range lines {
+			result, err := toBatchResult(line)
+			if err != nil {
+				return nil, err
+			}
+
+			results[result.ID] = result
+		}
+	}
+
+	list := make([]dto.BatchResult, 0, len(input))
+	for _, line := range input {
+		var request struct {
+			CustomID string `json:"custom_id"`
+		}
+		if err = json.Unmarshal(line, &request); err != nil {
+			return nil, fmt.Errorf("parse input line: %w", err)
+		}
+
+		result, ok := results[request.CustomID]
+		if !ok {
+			result = dto.BatchResult{ID: request.CustomID, Error: fmt.Sprintf("no answer, the batch is %s", job.Status)}
+		}
+
+		list = append(list, result)
+	}
+
+	return list, nil
+}
+
+// fileLines downloads the JSON lines file.
+func (c *Client) fileLines(ctx context.Context, fileID string) ([][]byte, error) {
+	content, err := c.client.GetFileContent(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("get file content: %w", err)
+	}
+	defer content.Close()
+
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+
+	var lines [][]byte
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			lines = append(lines, bytes.Clone(line))
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read file content: %w", err)
+	}
+
+	return lines, nil
+}
+
+// batchLine is a line of the output and the error files of a batch.
+type batchLine struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func toBatchResult(data []byte) (dto.BatchResult, error) {
+	var line batchLine
+	if err := json.Unmarshal(data, &line); err != nil {
+		return dto.BatchResult{}, fmt.Errorf("parse result line: %w", err)
+	}
+
+	result := dto.BatchResult{ID: line.CustomID}
+
+	switch {
+	case line.Error != nil:
+		result.Error = fmt.Sprintf("%s: %s", line.Error.Code, line.Error.Message)
+	case line.Response == nil:
+		result.Error = "no response"
+	case line.Response.StatusCode != http.StatusOK:
+		var body struct {
+			Error openai.APIError `json:"error"`
+		}
+		_ = json.Unmarshal(line.Response.Body, &body)
+		result.Error = fmt.Sprintf("status %d: %s", line.Response.StatusCode, body.Error.Message)
+	default:
+		var out openai.ChatCompletionResponse
+		if err := json.Unmarshal(line.Response.Body, &out); err != nil {
+			return dto.BatchResult{}, fmt.Errorf("parse answer of %s: %w", line.CustomID, err)
+		}
+		if len(out.Choices) == 0 {
+			result.Error = "empty answer"
+			break
+		}
+
+		usage := toUsage(out.Usage)
+
+		result.Model = out.Model
+		result.Content = out.Choices[0].Message.Content
+		result.Usage = &usage
+	}
+
+	return result, nil
+}
+
+func toBatchJob(batch openai.Batch) dto.BatchJob {
+	job := dto.BatchJob{
+		ID:          batch.ID,
+		Status:      dto.BatchStatus(batch.Status),
+		InputFileID: batch.InputFileID,
+		CreatedAt:   time.Unix(int64(batch.CreatedAt), 0),
+		Total:       batch.RequestCounts.Total,
+		Completed:   batch.RequestCounts.Completed,
+		Failed:      batch.RequestCounts.Failed,
+	}
+
+	if batch.OutputFileID != nil {
+		job.OutputFileID = *batch.OutputFileID
+	}
+	if batch.ErrorFileID != nil {
+		job.ErrorFileID = *batch.ErrorFileID
+	}
+
+	for _, at := range []*int{batch.CompletedAt, batch.FailedAt, batch.ExpiredAt, batch.CancelledAt} {
+		if at != nil {
+			job.DoneAt = time.Unix(int64(*at), 0)
+		}
+	}
+
+	if batch.Errors != nil {
+		for _, e := range batch.Errors.Data {
+			message := e.Message
+			if e.Line != nil {
+				message = fmt.Sprintf("line %d: %s", *e.Line, message)
+			}
+			job.Errors = append(job.Errors, message)
+		}
+	}
+
+	return job
+}
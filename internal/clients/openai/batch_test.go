@@ -0,0 +1,203 @@
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/openai"
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/batch"
+)
+
+const (
+	outputFile = `{"id": "r1", "custom_id": "a", "response": {"status_code": 200, "body": {"model": "gpt-4o-mini", "choices": [{"message": {"role": "assistant", "content": "Bonjour"}}], "usage": {"prompt_tokens": 10, "completion_tokens": 2}}}, "error": null}
+`
+	errorFile = `{"id": "r2", "custom_id": "b", "response": {"status_code": 400, "body": {"error": {"message": "Invalid model"}}}, "error": null}
+`
+)
+
+// batchServer stands in for the files and batches endpoints of the OpenAI API.
+type batchServer struct {
+	mu     sync.Mutex
+	files  map[string]string
+	status string
+}
+
+func (s *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		s.files["file-in"] = string(data)
+		writeJSON(w, map[string]any{"id": "file-in", "purpose": r.FormValue("purpose")})
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/batches":
+		s.status = "validating"
+		writeJSON(w, s.batch())
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/batches":
+		writeJSON(w, map[string]any{"object": "list", "data": []any{s.batch()}})
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/batches/batch-1":
+		writeJSON(w, s.batch())
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/batches/batch-1/cancel":
+		s.status = "cancelling"
+		writeJSON(w, s.batch())
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/files/"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/files/"), "/content")
+		_, _ = io.WriteString(w, s.files[id])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *batchServer) batch() map[string]any {
+	batch := map[string]any{
+		"id":             "batch-1",
+		"status":         s.status,
+		"input_file_id":  "file-in",
+		"created_at":     1700000000,
+		"request_counts": map[string]int{"total": 3, "completed": 1, "failed": 1},
+	}
+
+	if s.status == "completed" {
+		batch["output_file_id"] = "file-out"
+		batch["error_file_id"] = "file-err"
+		batch["completed_at"] = 1700000100
+	}
+
+	return batch
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestClient_Batch(t *testing.T) {
+	ctx := context.Background()
+
+	server := &batchServer{files: map[string]string{"file-out": outputFile, "file-err": errorFile}}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	client := openai.New(config.Config{OpenaiApiKey: "test"}, logger.New(nil, logger.WithEnabled(false)),
+		openai.WithBaseURL(srv.URL+"/v1"),
+		openai.WithModel("gpt-4o-mini"),
+	)
+
+	items := make([]dto.BatchItem, 0, 3)
+	for _, id := range []string{"a", "b", "c"} {
+		chat := dto.NewChat()
+		chat.AddMessage(dto.RoleUser, "Translate hello to French")
+		items = append(items, dto.BatchItem{ID: id, Chat: chat})
+	}
+	items[1].Chat.Model = "missing-model"
+
+	job, err := client.CreateBatch(ctx, items)
+	assert.NoError(t, err)
+	assert.Equal(t, "batch-1", job.ID)
+	assert.Equal(t, dto.BatchValidating, job.Status)
+
+	lines := strings.Split(server.files["file-in"], "\n")
+	assert.Len(t, lines, 3)
+
+	var line struct {
+		CustomID string `json:"custom_id"`
+		URL      string `json:"url"`
+		Body     struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		} `json:"body"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	assert.Equal(t, "b", line.CustomID)
+	assert.Equal(t, "/v1/chat/completions", line.URL)
+	assert.Equal(t, "missing-model", line.Body.Model)
+	assert.Equal(t, "Translate hello to French", line.Body.Messages[0].Content)
+
+	_, err = client.BatchResults(ctx, job)
+	assert.ErrorContains(t, err, "has no results")
+
+	jobs, err := client.Batches(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	job, err = client.CancelBatch(ctx, "batch-1")
+	assert.NoError(t, err)
+	assert.Equal(t, dto.BatchCancelling, job.Status)
+
+	server.status = "completed"
+
+	job, err = client.Batch(ctx, "batch-1")
+	assert.NoError(t, err)
+	assert.True(t, job.Status.Done())
+	assert.Equal(t, 3, job.Total)
+	assert.False(t, job.DoneAt.IsZero())
+
+	results, err := client.BatchResults(ctx, job)
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.BatchResult{
+		{ID: "a", Model: "gpt-4o-mini", Content: "Bonjour", Usage: &dto.Usage{PromptTokens: 10, CompletionTokens: 2}},
+		{ID: "b", Error: "status 400: Invalid model"},
+		{ID: "c", Error: "no answer, the batch is completed"},
+	}, results)
+}
+
+func TestClient_BatchLong(t *testing.T) {
+	server := &batchServer{files: make(map[string]string)}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	client := openai.New(config.Config{OpenaiApiKey: "test"}, logger.New(nil, logger.WithEnabled(false)),
+		openai.WithBaseURL(srv.URL+"/v1"),
+		openai.WithModel("gpt-4o-mini"),
+	)
+
+	messages := make([]string, 0, 25)
+	for i := range 25 {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, fmt.Sprintf(`{"role": %q, "content": "%d"}`, role, i))
+	}
+
+	requests, err := batch.ReadRequests(strings.NewReader(`{"system": "Be brief.", "messages": [` + strings.Join(messages, ", ") + `]}`))
+	assert.NoError(t, err)
+
+	_, err = batch.NewRemote(client).Submit(context.Background(), requests)
+	assert.NoError(t, err)
+
+	var line struct {
+		Body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		} `json:"body"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(server.files["file-in"]), &line))
+
+	// A line is sent whole, the system prompt and all of its messages.
+	assert.Len(t, line.Body.Messages, 26)
+	assert.Equal(t, "Be brief.", line.Body.Messages[0].Content)
+	assert.Equal(t, "0", line.Body.Messages[1].Content)
+	assert.Equal(t, "24", line.Body.Messages[25].Content)
+}
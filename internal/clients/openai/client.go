@@ -3,10 +3,13 @@ package openai
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sort"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 
+	"github.com/andrian0vv/chatgpt-cli/internal/cache"
 	"github.com/andrian0vv/chatgpt-cli/internal/config"
 	"github.com/andrian0vv/chatgpt-cli/internal/dto"
 	"github.com/andrian0vv/chatgpt-cli/internal/logger"
@@ -14,23 +17,48 @@ import (
 
 const defaultModel = openai.GPT3Dot5Turbo
 
+type responseCache interface {
+	Get(key string, value any) (bool, error)
+	Put(key string, value any) error
+}
+
 type Client struct {
-	client *openai.Client
-	model  string
-	log    *logger.Logger
+	client  *openai.Client
+	model   string
+	log     *logger.Logger
+	baseURL string
+	params  dto.Params
+
+	cache    responseCache
+	cacheAll bool
+	refresh  bool
 }
 
 func New(cfg config.Config, log *logger.Logger, opts ...Option) *Client {
 	c := &Client{
-		client: openai.NewClient(cfg.OpenaiApiKey),
-		model:  defaultModel,
-		log:    log,
+		model:   defaultModel,
+		log:     log,
+		baseURL: cfg.OpenaiBaseURL,
 	}
 
 	for _, opt := range opts {
 		opt(c)
 	}
 
+	if c.params.Temperature == nil {
+		temperature := float32(defaultTemperature)
+		c.params.Temperature = &temperature
+	}
+
+	clientConfig := openai.DefaultConfig(cfg.OpenaiApiKey)
+	if c.baseURL != "" {
+		clientConfig.BaseURL = c.baseURL
+	}
+
+	clientConfig.HTTPClient = &http.Client{Transport: transport{base: http.DefaultTransport, log: log}}
+
+	c.client = openai.NewClientWithConfig(clientConfig)
+
 	return c
 }
 
@@ -38,41 +66,125 @@ func (c *Client) Model() string {
 	return c.model
 }
 
-func (c *Client) CreateChatCompletion(ctx context.Context, chat *dto.Chat) (string, error) {
+func (c *Client) SetModel(model string) {
+	c.model = model
+}
+
+// Params returns the default sampling parameters.
+func (c *Client) Params() dto.Params {
+	return c.params.Clone()
+}
+
+func (c *Client) CreateChatCompletion(ctx context.Context, chat *dto.Chat) (dto.Completion, error) {
 	in := c.toCreateChatCompletionIn(chat)
 
-	c.log.Debug("openai in CreateChatCompletion", logger.WithField("in", in))
+	// The messages may hold secrets, they are logged only on request.
+	if c.log.Prompts() {
+		c.log.Debug("openai in CreateChatCompletion", logger.WithField("in", in))
+	}
+
+	key := c.cacheKey(in)
+	if completion, found := c.cached(key); found {
+		return completion, nil
+	}
 
 	out, err := c.client.CreateChatCompletion(ctx, in)
 	if err != nil {
-		return "", fmt.Errorf("create chat completion: %w", err)
+		return dto.Completion{}, fmt.Errorf("create chat completion: %w", err)
 	}
 
-	c.log.Debug("openai out CreateChatCompletion", logger.WithField("out", out))
+	if c.log.Prompts() {
+		c.log.Debug("openai out CreateChatCompletion", logger.WithField("out", out))
+	}
+	c.log.Debug("chat completion",
+		logger.WithField("model", out.Model),
+		logger.WithField("messages", len(in.Messages)),
+		logger.WithField("choices", len(out.Choices)),
+		logger.WithField("prompt_tokens", out.Usage.PromptTokens),
+		logger.WithField("completion_tokens", out.Usage.CompletionTokens),
+		logger.WithField("request_id", out.Header().Get("X-Request-Id")),
+	)
 
 	if len(out.Choices) == 0 {
-		return "", fmt.Errorf("empty answer")
+		return dto.Completion{}, fmt.Errorf("empty answer")
 	}
 
-	return out.Choices[0].Message.Content, nil
+	completion := toCompletion(out)
+
+	if key != "" {
+		if err = c.cache.Put(key, completion); err != nil {
+			c.log.Warn("write response cache", logger.WithError(err))
+		}
+	}
+
+	return completion, nil
+}
+
+// Cached returns the answer to the chat from the response cache without sending it,
+// e.g. to skip the budget check of a free answer.
+func (c *Client) Cached(_ context.Context, chat *dto.Chat) (dto.Completion, bool) {
+	return c.cached(c.cacheKey(c.toCreateChatCompletionIn(chat)))
 }
 
-func (c *Client) ModelExists(ctx context.Context) (bool, error) {
-	if c.model == defaultModel {
-		return true, nil
+func (c *Client) cached(key string) (dto.Completion, bool) {
+	if key == "" || c.refresh {
+		return dto.Completion{}, false
 	}
 
-	out, err := c.client.GetModel(ctx, c.model)
+	var completion dto.Completion
+
+	found, err := c.cache.Get(key, &completion)
 	if err != nil {
-		return false, fmt.Errorf("get model: %w", err)
+		c.log.Warn("read response cache", logger.WithError(err))
+	}
+	if !found {
+		return dto.Completion{}, false
 	}
 
-	c.log.Debug("openai out GetModel", logger.WithField("out", out))
+	c.log.Debug("response cache hit", logger.WithField("key", key))
+	completion.Cached = true
 
-	return out.ID == c.model, nil
+	return completion, true
+}
+
+// cacheKey returns the key of the request in the response cache, or an empty key if it should not be cached.
+// Only the requests expected to get the same answer are cached unless the cache takes all of them.
+func (c *Client) cacheKey(in openai.ChatCompletionRequest) string {
+	if c.cache == nil {
+		return ""
+	}
+
+	if !c.cacheAll && in.Temperature > zeroTemperature && in.Seed == nil {
+		return ""
+	}
+
+	key, err := cache.Key(in)
+	if err != nil {
+		c.log.Warn("response cache key", logger.WithError(err))
+		return ""
+	}
+
+	return key
 }
 
 func (c *Client) GetModels(ctx context.Context) ([]string, error) {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, 0, len(models))
+	for _, model := range models {
+		list = append(list, model.ID)
+	}
+
+	c.log.Debug("models", logger.WithField("models", list))
+
+	return list, nil
+}
+
+// ListModels returns the models available to the API key sorted by ID.
+func (c *Client) ListModels(ctx context.Context) ([]dto.Model, error) {
 	out, err := c.client.ListModels(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("list models: %w", err)
@@ -80,14 +192,18 @@ func (c *Client) GetModels(ctx context.Context) ([]string, error) {
 
 	c.log.Debug("openai out ListModels", logger.WithField("out", out))
 
-	list := make([]string, 0, len(out.Models))
+	list := make([]dto.Model, 0, len(out.Models))
 	for _, model := range out.Models {
-		list = append(list, model.ID)
+		list = append(list, dto.Model{
+			ID:      model.ID,
+			OwnedBy: model.OwnedBy,
+			Created: time.Unix(model.CreatedAt, 0).UTC(),
+		})
 	}
 
-	sort.Strings(list)
-
-	c.log.Debug("models", logger.WithField("models", list))
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
 
 	return list, nil
 }
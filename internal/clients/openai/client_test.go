@@ -0,0 +1,48 @@
+package openai_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/openai"
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+)
+
+func TestClient_Log(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Request-Id", "req_123")
+		writeJSON(w, map[string]any{
+			"model":   "gpt-4o-mini",
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": "Bonjour"}}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 2},
+		})
+	}))
+	defer srv.Close()
+
+	chat := dto.NewChat()
+	chat.AddMessage(dto.RoleUser, "Translate hello to French")
+
+	for _, prompts := range []bool{false, true} {
+		var buf bytes.Buffer
+
+		log := logger.New(&buf, logger.WithEnabled(true), logger.WithPrompts(prompts))
+		client := openai.New(config.Config{OpenaiApiKey: "test"}, log, openai.WithBaseURL(srv.URL+"/v1"))
+
+		completion, err := client.CreateChatCompletion(context.Background(), chat)
+		assert.NoError(t, err)
+		assert.Equal(t, "Bonjour", completion.Content)
+
+		out := buf.String()
+		assert.Contains(t, out, `msg="openai request" method=POST path=/v1/chat/completions`)
+		assert.Contains(t, out, "status=200 request_id=req_123")
+		assert.Contains(t, out, "prompt_tokens=10")
+		assert.Equal(t, prompts, bytes.Contains(buf.Bytes(), []byte("Translate hello to French")))
+	}
+}
@@ -1,18 +1,22 @@
 package openai
 
 import (
+	"math"
+
 	"github.com/sashabaranov/go-openai"
 
 	"github.com/andrian0vv/chatgpt-cli/internal/dto"
 )
 
-const maxMessages = 20
+const (
+	defaultTemperature = 0.7
+	// zeroTemperature is sent for the zero temperature, go-openai omits the zero value
+	// and the API would use its default instead.
+	zeroTemperature = math.SmallestNonzeroFloat32
+)
 
 func (c *Client) toCreateChatCompletionIn(chat *dto.Chat) openai.ChatCompletionRequest {
-	chatMessages := chat.Messages
-	if len(chatMessages) > maxMessages {
-		chatMessages = chatMessages[len(chatMessages)-maxMessages:]
-	}
+	chatMessages := chat.Sent()
 
 	messages := make([]openai.ChatCompletionMessage, 0, len(chatMessages))
 	for _, message := range chatMessages {
@@ -22,10 +26,96 @@ func (c *Client) toCreateChatCompletionIn(chat *dto.Chat) openai.ChatCompletionR
 		})
 	}
 
-	return openai.ChatCompletionRequest{
-		Model:       c.model,
-		Messages:    messages,
-		Temperature: 0.7,
-		N:           1,
+	model := c.model
+	if chat.Model != "" {
+		model = chat.Model
+	}
+
+	in := openai.ChatCompletionRequest{
+		Model:    model,
+		Messages: messages,
+		N:        1,
+	}
+
+	params := chat.Params
+	if !chat.OwnParams {
+		params = c.params.Merge(chat.Params)
+	}
+	applyParams(&in, params)
+
+	return in
+}
+
+func applyParams(in *openai.ChatCompletionRequest, params dto.Params) {
+	if params.Temperature != nil {
+		in.Temperature = max(*params.Temperature, zeroTemperature)
+	}
+	if params.TopP != nil {
+		in.TopP = *params.TopP
+	}
+	if params.MaxTokens > 0 {
+		in.MaxTokens = params.MaxTokens
+	}
+	if params.PresencePenalty != nil {
+		in.PresencePenalty = *params.PresencePenalty
+	}
+	if params.FrequencyPenalty != nil {
+		in.FrequencyPenalty = *params.FrequencyPenalty
+	}
+	if params.Seed != nil {
+		in.Seed = params.Seed
+	}
+	if len(params.Stop) > 0 {
+		in.Stop = params.Stop
+	}
+	if len(params.LogitBias) > 0 {
+		in.LogitBias = params.LogitBias
+	}
+	if params.User != "" {
+		in.User = params.User
+	}
+	if params.N > 1 {
+		in.N = params.N
+	}
+}
+
+func toCompletion(out openai.ChatCompletionResponse) dto.Completion {
+	completion := dto.Completion{
+		Content:      out.Choices[0].Message.Content,
+		FinishReason: string(out.Choices[0].FinishReason),
+		Model:        out.Model,
+		Usage:        toUsage(out.Usage),
+	}
+
+	if len(out.Choices) > 1 {
+		completion.Choices = make([]dto.Choice, len(out.Choices))
+		for _, choice := range out.Choices {
+			if choice.Index < 0 || choice.Index >= len(out.Choices) {
+				continue
+			}
+			completion.Choices[choice.Index] = dto.Choice{
+				Content:      choice.Message.Content,
+				FinishReason: string(choice.FinishReason),
+			}
+		}
 	}
+
+	return completion
+}
+
+func toUsage(usage openai.Usage) dto.Usage {
+	out := dto.Usage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+	}
+
+	if usage.PromptTokensDetails != nil {
+		out.CachedTokens = usage.PromptTokensDetails.CachedTokens
+	}
+
+	if usage.CompletionTokensDetails != nil {
+		out.ReasoningTokens = usage.CompletionTokensDetails.ReasoningTokens
+	}
+
+	return out
 }
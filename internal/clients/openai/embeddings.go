@@ -0,0 +1,52 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+)
+
+const embeddingModel = openai.SmallEmbedding3
+
+// EmbeddingModel returns the model of the embeddings.
+func (c *Client) EmbeddingModel() string {
+	return string(embeddingModel)
+}
+
+// CreateEmbeddings returns the vectors of the texts made by the small embedding model.
+func (c *Client) CreateEmbeddings(ctx context.Context, texts []string) (dto.Embeddings, error) {
+	out, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: embeddingModel,
+	})
+	if err != nil {
+		return dto.Embeddings{}, fmt.Errorf("create embeddings: %w", err)
+	}
+
+	c.log.Debug("openai out CreateEmbeddings",
+		logger.WithField("texts", len(texts)),
+		logger.WithField("prompt_tokens", out.Usage.PromptTokens),
+	)
+
+	embeddings := dto.Embeddings{
+		Model:   string(out.Model),
+		Vectors: make([][]float32, len(texts)),
+		Usage:   dto.Usage{PromptTokens: out.Usage.PromptTokens},
+	}
+	if embeddings.Model == "" {
+		embeddings.Model = string(embeddingModel)
+	}
+
+	for _, e := range out.Data {
+		if e.Index < 0 || e.Index >= len(texts) {
+			return dto.Embeddings{}, fmt.Errorf("create embeddings: unexpected index %d", e.Index)
+		}
+		embeddings.Vectors[e.Index] = e.Embedding
+	}
+
+	return embeddings, nil
+}
@@ -1,5 +1,7 @@
 package openai
 
+import "github.com/andrian0vv/chatgpt-cli/internal/dto"
+
 type Option func(*Client)
 
 func WithModel(model string) Option {
@@ -9,3 +11,40 @@ func WithModel(model string) Option {
 		}
 	}
 }
+
+// WithBaseURL sends the requests to another OpenAI compatible API, e.g. a proxy.
+func WithBaseURL(url string) Option {
+	return func(a *Client) {
+		if url != "" {
+			a.baseURL = url
+		}
+	}
+}
+
+// WithParams sets the default sampling parameters, the parameters of a chat override them.
+func WithParams(params dto.Params) Option {
+	return func(a *Client) {
+		a.params = params
+	}
+}
+
+// WithCache reuses the answers to repeated requests from the cache.
+func WithCache(cache responseCache) Option {
+	return func(a *Client) {
+		a.cache = cache
+	}
+}
+
+// WithCacheAll caches the requests with a non-zero temperature and no seed too.
+func WithCacheAll(all bool) Option {
+	return func(a *Client) {
+		a.cacheAll = all
+	}
+}
+
+// WithRefresh sends the requests even if they are cached and replaces the cached answers.
+func WithRefresh(refresh bool) Option {
+	return func(a *Client) {
+		a.refresh = refresh
+	}
+}
@@ -0,0 +1,47 @@
+package openai
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+)
+
+// transport logs the metadata of every request to the API, the bodies are left to the client.
+type transport struct {
+	base http.RoundTripper
+	log  *logger.Logger
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	resp, err := t.base.RoundTrip(req)
+
+	fields := []logger.Field{
+		logger.WithField("method", req.Method),
+		logger.WithField("path", req.URL.Path),
+		logger.WithField("latency", time.Since(start).Round(time.Millisecond).String()),
+	}
+
+	if err != nil {
+		t.log.Warn("openai request", append(fields, logger.WithError(err))...)
+		return nil, err
+	}
+
+	fields = append(fields,
+		logger.WithField("status", resp.StatusCode),
+		logger.WithField("request_id", resp.Header.Get("X-Request-Id")),
+	)
+	if processing := resp.Header.Get("Openai-Processing-Ms"); processing != "" {
+		fields = append(fields, logger.WithField("processing_ms", processing))
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		t.log.Warn("openai request", fields...)
+	} else {
+		t.log.Info("openai request", fields...)
+	}
+
+	return resp, nil
+}
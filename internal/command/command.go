@@ -1,92 +1,600 @@
 package command
 
 import (
-	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/fatih/color"
 	"github.com/muesli/termenv"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
+	"github.com/andrian0vv/chatgpt-cli/internal/budget"
+	"github.com/andrian0vv/chatgpt-cli/internal/cache"
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/mock"
 	"github.com/andrian0vv/chatgpt-cli/internal/clients/openai"
 	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/credentials"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
 	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/redact"
+	"github.com/andrian0vv/chatgpt-cli/internal/search"
 	"github.com/andrian0vv/chatgpt-cli/internal/services/assistant"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/catalog"
+	"github.com/andrian0vv/chatgpt-cli/internal/sessions"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
 )
 
 const (
 	colorSystem = color.FgHiBlue
 	colorAI     = color.FgYellow
 	colorError  = color.FgRed
+	colorHint   = color.FgHiBlack
 )
 
+const (
+	responsesDir = "responses"
+	modelsDir    = "models"
+)
+
+const messageOnRedact = "Redacted before sending: %s. --no-redact sends them as they are."
+
+// provider answers the requests of the assistant and lists the models.
+type provider interface {
+	Model() string
+	SetModel(model string)
+	Params() dto.Params
+	CreateChatCompletion(ctx context.Context, chat *dto.Chat) (dto.Completion, error)
+	GetModels(ctx context.Context) ([]string, error)
+	ListModels(ctx context.Context) ([]dto.Model, error)
+	EmbeddingModel() string
+	CreateEmbeddings(ctx context.Context, texts []string) (dto.Embeddings, error)
+}
+
+// shared keeps the provider and the assistant of the process, they are created once
+// and shared by the commands.
+var shared struct {
+	providerOnce sync.Once
+	provider     provider
+	providerErr  error
+
+	logOnce sync.Once
+	log     *logger.Logger
+	logErr  error
+
+	assistantOnce sync.Once
+	assistant     *assistant.Assistant
+	assistantErr  error
+
+	redactorOnce sync.Once
+	redactor     *redact.Redactor
+	redactorErr  error
+
+	budgetOnce sync.Once
+	budget     *budget.Guard
+	budgetErr  error
+}
+
 // Command is a wrapper around cobra.Command with additional printing methods.
 type Command struct {
 	*cobra.Command
+	Config    config.Config
 	Assistant *assistant.Assistant
 }
 
-func New(c *cobra.Command) Command {
-	cmd := Command{
+func New(c *cobra.Command) (Command, error) {
+	cmd, err := NewOffline(c)
+	if err != nil {
+		return Command{}, err
+	}
+
+	return cmd.Connect()
+}
+
+// NewOffline creates a command for local operations that do not need the assistant.
+func NewOffline(c *cobra.Command) (Command, error) {
+	cfg, err := config.New()
+	if err != nil {
+		return Command{}, err
+	}
+
+	if profile, _ := c.Flags().GetString("profile"); profile != "" {
+		cfg.Profile = profile
+	}
+	if provider, _ := c.Flags().GetString("provider"); provider != "" {
+		cfg.Provider = provider
+	}
+
+	return Command{
 		Command: c,
+		Config:  cfg,
+	}, nil
+}
+
+// Connect creates the assistant, e.g. after the flags have been adjusted to the loaded resources.
+// No request is made, the model is only checked when a request fails.
+func (c Command) Connect() (Command, error) {
+	shared.assistantOnce.Do(func() {
+		shared.assistant, shared.assistantErr = c.createAssistant()
+	})
+
+	c.Assistant = shared.assistant
+
+	return c, shared.assistantErr
+}
+
+// Client returns the OpenAI client for the operations the assistant does not cover, such as batches.
+func (c Command) Client() (*openai.Client, error) {
+	p, err := c.provider()
+	if err != nil {
+		return nil, err
+	}
+
+	client, ok := p.(*openai.Client)
+	if !ok {
+		return nil, failure.New(failure.KindUsage, fmt.Errorf("the %s provider does not support this command", c.Config.Provider))
+	}
+
+	return client, nil
+}
+
+func (c Command) provider() (provider, error) {
+	shared.providerOnce.Do(func() {
+		shared.provider, shared.providerErr = c.createProvider()
+	})
+
+	return shared.provider, shared.providerErr
+}
+
+func (c Command) createProvider() (provider, error) {
+	log, err := c.Logger()
+	if err != nil {
+		return nil, err
+	}
+
+	// compare repeats --model for several models and sets them per request.
+	var model string
+	if flag := c.Flags().Lookup("model"); flag != nil && flag.Value.Type() == "string" {
+		model = flag.Value.String()
+	}
+
+	params, err := c.Params()
+	if err != nil {
+		return nil, failure.New(failure.KindUsage, err)
+	}
+	params = c.Config.DefaultParams().Merge(params)
+
+	switch c.Config.Provider {
+	case config.ProviderOpenAI:
+		return c.createClient(log, model, params)
+	case config.ProviderMock:
+		return c.createMock(log, model, params)
+	default:
+		return nil, failure.New(failure.KindUsage,
+			fmt.Errorf("unknown provider %q, use %s or %s", c.Config.Provider, config.ProviderOpenAI, config.ProviderMock))
+	}
+}
+
+// Logger returns the log of the process, it is written to stderr or the log file
+// as the log flags and the config ask.
+func (c Command) Logger() (*logger.Logger, error) {
+	shared.logOnce.Do(func() {
+		shared.log, shared.logErr = c.createLogger()
+	})
+
+	return shared.log, shared.logErr
+}
+
+func (c Command) createLogger() (*logger.Logger, error) {
+	cfg := c.Config.Log
+
+	if verbose, _ := c.Flags().GetBool("verbose"); verbose {
+		cfg.Level = "debug"
+	}
+	if flag := c.Flags().Lookup("log-level"); flag != nil && flag.Changed {
+		cfg.Level = flag.Value.String()
+	}
+	if flag := c.Flags().Lookup("log-format"); flag != nil && flag.Changed {
+		cfg.Format = flag.Value.String()
+	}
+	if flag := c.Flags().Lookup("log-file"); flag != nil && flag.Changed {
+		cfg.File = flag.Value.String()
+	}
+	if prompts, _ := c.Flags().GetBool("log-prompts"); prompts {
+		cfg.Prompts = true
+	}
+
+	// A log file alone is enough to turn the log on.
+	if cfg.Level == "" && cfg.File != "" {
+		cfg.Level = "info"
+	}
+	if cfg.Level == "" {
+		return logger.New(nil), nil
+	}
+
+	level, err := logger.ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, failure.New(failure.KindUsage, err)
+	}
+
+	format, err := logger.ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, failure.New(failure.KindUsage, err)
+	}
+
+	opts := []logger.Option{
+		logger.WithEnabled(true),
+		logger.WithLevel(level),
+		logger.WithFormat(format),
+		logger.WithPrompts(cfg.Prompts),
+	}
+
+	// The log never mixes with the answers on stdout.
+	if cfg.File == "" {
+		out := c.ErrOrStderr()
+		return logger.New(out, append(opts, logger.WithColor(isTerminal(out)))...), nil
+	}
+
+	path := cfg.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.Config.CacheDir, path)
+	}
+
+	file, err := logger.OpenFile(path, cfg.MaxSizeMB<<20, cfg.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return logger.New(file, opts...), nil
+}
+
+// isTerminal tells whether the output is a terminal that shows colors.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && !color.NoColor && term.IsTerminal(int(f.Fd()))
+}
+
+func (c Command) createClient(log *logger.Logger, model string, params dto.Params) (*openai.Client, error) {
+	cfg := c.Config
+
+	key, err := c.APIKey()
+	switch {
+	case err == nil:
+		cfg.OpenaiApiKey = key.Value
+	// Other OpenAI compatible APIs may work without a key.
+	case errors.Is(err, credentials.ErrNoKey) && cfg.OpenaiBaseURL != "":
+	default:
+		return nil, err
+	}
+
+	clientOpts, err := c.cacheOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	clientOpts = append(clientOpts, openai.WithParams(params), openai.WithModel(model))
+
+	return openai.New(cfg, log, clientOpts...), nil
+}
+
+// Credentials returns the resolver of the API key configured by the auth section.
+func (c Command) Credentials() (*credentials.Resolver, error) {
+	resolver, err := credentials.New(c.Config.Auth, c.Config.ConfigDir)
+	if err != nil {
+		return nil, failure.New(failure.KindUsage, err)
+	}
+
+	return resolver, nil
+}
+
+// APIKey returns the key of the first source that has one.
+func (c Command) APIKey() (credentials.Key, error) {
+	resolver, err := c.Credentials()
+	if err != nil {
+		return credentials.Key{}, err
+	}
+
+	key, err := resolver.Resolve()
+	if err != nil {
+		return credentials.Key{}, failure.New(failure.KindAuth, err)
+	}
+
+	return key, nil
+}
+
+// createMock creates the offline provider, its settings are checked before any request.
+func (c Command) createMock(log *logger.Logger, model string, params dto.Params) (*mock.Client, error) {
+	opts := []mock.Option{
+		mock.WithModel(model),
+		mock.WithParams(params),
+		mock.WithLatency(c.Config.Mock.Latency),
+	}
+
+	if c.Config.Mock.Error != "" {
+		if err := mock.CheckError(c.Config.Mock.Error); err != nil {
+			return nil, failure.New(failure.KindUsage, err)
+		}
+		opts = append(opts, mock.WithError(c.Config.Mock.Error))
 	}
 
-	cmd.Assistant = cmd.createAssistant()
+	if c.Config.Mock.Fixtures != "" {
+		fixtures, err := mock.LoadFixtures(c.Config.Mock.Fixtures)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, mock.WithFixtures(fixtures))
+	}
 
-	return cmd
+	return mock.New(log, opts...), nil
 }
 
-func (c Command) createAssistant() *assistant.Assistant {
-	cfg := config.New()
+func (c Command) createAssistant() (*assistant.Assistant, error) {
+	client, err := c.provider()
+	if err != nil {
+		return nil, err
+	}
+
+	models, err := c.Catalog()
+	if err != nil {
+		return nil, err
+	}
 
-	verbose, err := c.Flags().GetBool("verbose")
-	c.Fail(err)
+	guard, err := c.Budget()
+	if err != nil {
+		return nil, err
+	}
 
-	model, err := c.Flags().GetString("model")
-	c.Fail(err)
+	opts := []assistant.Option{
+		assistant.WithRecorder(c.Ledger()),
+		assistant.WithBudget(guard),
+		assistant.WithProfile(c.Config.Profile),
+		assistant.WithCatalog(models),
+	}
 
-	log := logger.New(c.OutOrStdout(), logger.WithEnabled(verbose))
+	// The answers of the mock provider are never cached.
+	if openaiClient, ok := client.(*openai.Client); ok {
+		opts = append(opts, assistant.WithCache(openaiClient))
+	}
 
-	a, err := assistant.New(
-		c.Context(),
-		openai.New(cfg, log, openai.WithModel(model)),
-		log,
+	redactor, err := c.Redactor()
+	if err != nil {
+		return nil, err
+	}
+	if redactor != nil {
+		opts = append(opts, assistant.WithRedactor(redactor))
+	}
+
+	return assistant.New(client, shared.log, opts...), nil
+}
+
+// Ledger returns the ledger of the usage of the requests.
+func (c Command) Ledger() *usage.Ledger {
+	return usage.NewLedger(c.Config.DataDir)
+}
+
+// Budget returns the guard of the spending limits of the profile shared by the commands,
+// it warns on the standard output.
+func (c Command) Budget() (*budget.Guard, error) {
+	shared.budgetOnce.Do(func() {
+		shared.budget, shared.budgetErr = c.createBudget()
+	})
+
+	return shared.budget, shared.budgetErr
+}
+
+func (c Command) createBudget() (*budget.Guard, error) {
+	ignoreBudget, err := c.Flags().GetBool("ignore-budget")
+	if err != nil {
+		return nil, err
+	}
+
+	guard := budget.New(
+		c.Config.Profile,
+		c.Config.ActiveProfile().Budget,
+		c.Ledger(),
+		pricing.New(c.Config.Prices),
+		budget.WithOverride(ignoreBudget),
+		// The warnings go to stderr, the answers and the results on stdout may be piped.
+		budget.WithWarner(func(message string) {
+			c.Clear()
+			_, _ = color.New(colorSystem).Fprintf(c.ErrOrStderr(), "[System] %s\n", message)
+		}),
 	)
-	c.Fail(err)
 
-	return a
+	return guard, nil
 }
 
-func (c Command) Clear() {
-	c.Print("\r\033[K")
+// Redactor returns the redactor of the outgoing messages shared by the commands,
+// nil if the redaction is disabled in the config or with --no-redact.
+func (c Command) Redactor() (*redact.Redactor, error) {
+	shared.redactorOnce.Do(func() {
+		shared.redactor, shared.redactorErr = c.createRedactor()
+	})
+
+	return shared.redactor, shared.redactorErr
 }
 
-func (c Command) Fail(err error) {
-	if err == nil {
-		return
+func (c Command) createRedactor() (*redact.Redactor, error) {
+	noRedact, err := c.Flags().GetBool("no-redact")
+	if err != nil {
+		return nil, err
+	}
+
+	if noRedact || c.Config.Redaction.Disabled {
+		return nil, nil
 	}
 
-	c.print(colorError, "[Error] %v\n", err)
+	redactor, err := redact.New(c.Config.Redaction, redact.WithReporter(func(summary redact.Summary) {
+		c.Clear()
+		_, _ = color.New(colorHint).Fprintf(c.ErrOrStderr(), "[System] "+messageOnRedact+"\n", summary)
+	}))
+	if err != nil {
+		return nil, failure.New(failure.KindUsage, err)
+	}
 
-	os.Exit(1)
+	return redactor, nil
 }
 
-func (c Command) AI(message string) {
-	if strings.Contains(message, "\n") {
-		r, err := glamour.NewTermRenderer(
-			glamour.WithColorProfile(termenv.ANSI256),
-			glamour.WithAutoStyle(),
-			glamour.WithWordWrap(100),
-		)
-		c.Fail(err)
+// Catalog lists the models with a local cache of the list.
+func (c Command) Catalog() (*catalog.Catalog, error) {
+	refresh, err := c.Flags().GetBool("refresh")
+	if err != nil {
+		return nil, err
+	}
+
+	// The models of the mock provider are not worth a cache.
+	if c.Config.Provider == config.ProviderMock {
+		client, err := c.provider()
+		if err != nil {
+			return nil, err
+		}
+
+		return catalog.New(client), nil
+	}
+
+	modelCache := cache.New(filepath.Join(c.Config.CacheDir, modelsDir), cache.WithTTL(c.Config.Models.CacheTTL))
+
+	return catalog.New(lister{c}, catalog.WithCache(modelCache, c.Config.OpenaiBaseURL), catalog.WithRefresh(refresh)), nil
+}
+
+// lister creates the provider on the first request of the models, so the cached list,
+// e.g. for a shell completion, never resolves the API key.
+type lister struct {
+	c Command
+}
+
+func (l lister) ListModels(ctx context.Context) ([]dto.Model, error) {
+	p, err := l.c.provider()
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ListModels(ctx)
+}
 
-		message, err = r.Render(message)
-		c.Fail(err)
+// CreateEmbeddings returns the vectors of the texts made by the provider, e.g. for the semantic search.
+// The request is checked against the budget and its usage is written to the ledger.
+func (c Command) CreateEmbeddings(ctx context.Context, texts []string) (dto.Embeddings, error) {
+	p, err := c.provider()
+	if err != nil {
+		return dto.Embeddings{}, err
+	}
+
+	redactor, err := c.Redactor()
+	if err != nil {
+		return dto.Embeddings{}, err
+	}
+
+	if redactor != nil {
+		redacted := make([]string, len(texts))
+		for i, text := range texts {
+			redacted[i], _ = redactor.Text(text)
+		}
+		texts = redacted
+	}
+
+	guard, err := c.Budget()
+	if err != nil {
+		return dto.Embeddings{}, err
+	}
+
+	promptTokens := 0
+	for _, text := range texts {
+		promptTokens += tokens.Estimate(text)
+	}
+
+	release, err := guard.ReserveEmbeddings(p.EmbeddingModel(), promptTokens)
+	if err != nil {
+		return dto.Embeddings{}, fmt.Errorf("check budget: %w", err)
+	}
 
-		message = strings.Trim(message, "\n")
+	embeddings, err := p.CreateEmbeddings(ctx, texts)
+	release(embeddings.Usage)
+	if err != nil {
+		return dto.Embeddings{}, err
+	}
+
+	if !embeddings.Usage.IsZero() {
+		err = c.Ledger().Record(dto.UsageRecord{
+			Time:    time.Now(),
+			Model:   embeddings.Model,
+			Session: usage.SessionFromContext(ctx),
+			Profile: c.Config.Profile,
+			Usage:   embeddings.Usage,
+		})
+		// The provider is created after the log.
+		if err != nil {
+			shared.log.Warn("record usage", logger.WithError(err))
+		}
+	}
+
+	return embeddings, nil
+}
+
+// Sessions returns the store of the saved chats keeping the search index up to date.
+func (c Command) Sessions() *sessions.Store {
+	return sessions.New(c.Config.DataDir, sessions.WithIndex(search.New(c.Config.DataDir)))
+}
+
+// ResponseCache returns the on-disk cache of the answers.
+func (c Command) ResponseCache() *cache.Cache {
+	return cache.New(
+		filepath.Join(c.Config.CacheDir, responsesDir),
+		cache.WithTTL(c.Config.Cache.TTL),
+		cache.WithMaxSize(c.Config.Cache.MaxSizeMB<<20),
+	)
+}
+
+// cacheOptions enables the response cache if the config opts in and --no-cache is not set.
+func (c Command) cacheOptions() ([]openai.Option, error) {
+	noCache, err := c.Flags().GetBool("no-cache")
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := c.Flags().GetBool("refresh")
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.Config.Cache.Enabled || noCache {
+		return nil, nil
+	}
+
+	return []openai.Option{
+		openai.WithCache(c.ResponseCache()),
+		openai.WithCacheAll(c.Config.Cache.All),
+		openai.WithRefresh(refresh),
+	}, nil
+}
+
+func (c Command) Clear() {
+	c.Print("\r\033[K")
+}
+
+// Error prints the error with a hint of what to do about it to stderr.
+func (c Command) Error(err error) {
+	_, _ = color.New(colorError).Fprintf(c.ErrOrStderr(), "[Error] %v\n", err)
+
+	if hint := failure.Hint(err); hint != "" {
+		_, _ = color.New(colorHint).Fprintf(c.ErrOrStderr(), "[Hint] %s\n", hint)
+	}
+}
+
+func (c Command) AI(message string) {
+	if strings.Contains(message, "\n") {
+		message = render(message)
 	}
 
 	c.print(colorAI, "[AI] %s\n", message)
@@ -132,12 +640,23 @@ func (c Command) Loading(message string) func() {
 	}
 }
 
-func (c Command) Read() string {
-	c.Print("[You] ")
+// render formats the markdown of the message, it is printed as is if it cannot be rendered.
+func render(message string) string {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithColorProfile(termenv.ANSI256),
+		glamour.WithAutoStyle(),
+		glamour.WithWordWrap(100),
+	)
+	if err != nil {
+		return message
+	}
 
-	question, _ := bufio.NewReader(c.InOrStdin()).ReadString('\n')
+	rendered, err := r.Render(message)
+	if err != nil {
+		return message
+	}
 
-	return strings.TrimSpace(question)
+	return strings.Trim(rendered, "\n")
 }
 
 func (c Command) print(attribute color.Attribute, message string, args ...any) {
@@ -0,0 +1,16 @@
+package command
+
+import (
+	"github.com/andrian0vv/chatgpt-cli/internal/budget"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/assistant"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/catalog"
+)
+
+// The errors of the services are classified for the exit codes and the hints of the commands.
+func init() {
+	failure.Register(budget.ErrExceeded, failure.KindQuota, "see the usage command, the limits are in the profiles of the config")
+	failure.Register(budget.ErrUnknownPrice, failure.KindQuota, "set the price of the model in the prices of the config")
+	failure.Register(assistant.ErrUnknownModel, failure.KindModelNotFound, "")
+	failure.Register(catalog.ErrNotFound, failure.KindModelNotFound, "")
+}
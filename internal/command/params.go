@@ -0,0 +1,50 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+// AddParamFlags adds the flags of the sampling parameters, they override the parameters from the config.
+func AddParamFlags(flags *pflag.FlagSet) {
+	flags.Float32("temperature", 0, "Sampling temperature from 0 to 2, 0.7 unless set in the config")
+	flags.Float32("top-p", 0, "Nucleus sampling probability mass from 0 to 1")
+	flags.Int("max-tokens", 0, "Maximum number of tokens in the answer")
+	flags.Float32("presence-penalty", 0, "Penalty from -2 to 2 for tokens that already appeared")
+	flags.Float32("frequency-penalty", 0, "Penalty from -2 to 2 for frequently repeated tokens")
+	flags.Int("seed", 0, "Seed for reproducible answers")
+	flags.StringArray("stop", nil, "Sequence that ends the answer, can be repeated")
+	flags.String("logit-bias", "", "Comma separated token=bias pairs, the bias is from -100 to 100")
+	flags.String("user", "", "End user ID sent for abuse monitoring")
+}
+
+// Params returns the sampling parameters set with the flags.
+func (c Command) Params() (dto.Params, error) {
+	var params dto.Params
+
+	for _, name := range dto.ParamNames {
+		flag := c.Flags().Lookup(strings.ReplaceAll(name, "_", "-"))
+		if flag == nil || !flag.Changed {
+			continue
+		}
+
+		if name == "stop" {
+			stop, err := c.Flags().GetStringArray(flag.Name)
+			if err != nil {
+				return dto.Params{}, err
+			}
+			params.Stop = stop
+
+			continue
+		}
+
+		if err := params.Set(name, flag.Value.String()); err != nil {
+			return dto.Params{}, err
+		}
+	}
+
+	return params, nil
+}
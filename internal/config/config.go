@@ -1,13 +1,264 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	fileName = "config.yaml"
+
+	DefaultProfile = "default"
+
+	ProviderOpenAI = "openai"
+	ProviderMock   = "mock"
+)
 
 type Config struct {
-	OpenaiApiKey string
+	// Provider answers the requests, openai or mock. CHATGPT_CLI_PROVIDER and --provider override it.
+	Provider string `yaml:"provider"`
+	// Mock configures the offline provider.
+	Mock Mock `yaml:"mock"`
+
+	// OpenaiApiKey is set by the command from the first source of Auth that has the key.
+	OpenaiApiKey string `yaml:"-"`
+	// Auth configures where the API key comes from.
+	Auth Auth `yaml:"auth"`
+	// OpenaiBaseURL points to another OpenAI compatible API, OPENAI_BASE_URL overrides it.
+	OpenaiBaseURL string `yaml:"openai_base_url"`
+
+	// DataDir keeps persistent user data such as the chat history.
+	DataDir string `yaml:"-"`
+	// ConfigDir keeps the config file and the user defined resources.
+	ConfigDir string `yaml:"-"`
+	// CacheDir keeps the data that can be recreated, such as the response cache.
+	CacheDir string `yaml:"-"`
+
+	// Prices override the built-in price table, in USD per 1M tokens.
+	Prices map[string]Price `yaml:"prices"`
+
+	// Profile is the name of the active profile, CHATGPT_CLI_PROFILE and --profile override it.
+	Profile  string             `yaml:"profile"`
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	Cache  Cache  `yaml:"cache"`
+	Models Models `yaml:"models"`
+	Titles Titles `yaml:"titles"`
+
+	Redaction Redaction `yaml:"redaction"`
+	Log       Log       `yaml:"log"`
+
+	// Params are the default sampling parameters, the profile parameters and the flags override them.
+	Params dto.Params `yaml:"params"`
+}
+
+// Cache configures the on-disk cache of the answers to repeated requests.
+type Cache struct {
+	Enabled bool `yaml:"enabled"`
+	// TTL is how long an answer is reused, 24 hours by default.
+	TTL time.Duration `yaml:"ttl"`
+	// MaxSizeMB bounds the size of the cache, the least recently used answers are evicted first. 100 by default.
+	MaxSizeMB int64 `yaml:"max_size_mb"`
+	// All caches every request, by default only the requests with zero temperature or a seed are cached.
+	All bool `yaml:"all"`
+}
+
+// Models configures the local list of the models used by models and the completion.
+type Models struct {
+	// CacheTTL is how long the list is reused, 24 hours by default. --refresh fetches it again.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+}
+
+// Auth configures the sources of the API key, so it does not have to live in a shell rc file.
+type Auth struct {
+	// Sources are tried in order until one has the key: env, keyring, command and file, all of them by default.
+	Sources []string `yaml:"sources"`
+	// APIKeyCommand prints the key, e.g. pass show openai. It runs once per process.
+	APIKeyCommand string `yaml:"api_key_command"`
+	// APIKeyFile holds the key and must not be readable by other users, api_key in the config directory by default.
+	APIKeyFile string `yaml:"api_key_file"`
+}
+
+// Log configures the log of the requests, --log-level, --log-format, --log-file and --log-prompts override it.
+type Log struct {
+	// Level is debug, info, warn or error, nothing is logged unless it or File is set.
+	Level string `yaml:"level"`
+	// Format is text or json, text by default.
+	Format string `yaml:"format"`
+	// File receives the log instead of stderr, relative to the cache directory.
+	// It is rotated when it grows over MaxSizeMB, 10 by default, and MaxBackups are kept, 3 by default.
+	File       string `yaml:"file"`
+	MaxSizeMB  int64  `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+	// Prompts logs the whole requests and answers, they are left out by default since they may hold secrets.
+	Prompts bool `yaml:"prompts"`
+}
+
+// Titles configures the titles and tags generated for the chats after their first answer.
+type Titles struct {
+	// Disabled stops the automatic titles, /title still generates one on demand.
+	Disabled bool `yaml:"disabled"`
+	// Model generates the titles, gpt-4o-mini by default.
+	Model string `yaml:"model"`
+}
+
+// Redaction configures the secrets and the personal data replaced with placeholders
+// before the requests leave the machine.
+type Redaction struct {
+	// Disabled sends the messages as they are, --no-redact does it for one command.
+	Disabled bool `yaml:"disabled"`
+	// Restore puts the values back in place of the placeholders in the answers, locally.
+	Restore bool `yaml:"restore"`
+	// Skip turns built-in detectors off by name, e.g. email.
+	Skip []string `yaml:"skip"`
+	// Patterns are custom detectors, the first group of a regex is replaced if it has one.
+	Patterns []RedactionPattern `yaml:"patterns"`
+}
+
+type RedactionPattern struct {
+	Name  string `yaml:"name"`
+	Regex string `yaml:"regex"`
+}
+
+// Mock configures the offline provider with deterministic answers, e.g. to test scripts without an API key.
+type Mock struct {
+	// Fixtures is a YAML file with the canned answers, the questions are echoed without it.
+	// CHATGPT_CLI_MOCK_FIXTURES overrides it.
+	Fixtures string `yaml:"fixtures"`
+	// Latency delays every answer, CHATGPT_CLI_MOCK_LATENCY overrides it.
+	Latency time.Duration `yaml:"latency"`
+	// Error fails every request with the error, e.g. rate_limit. CHATGPT_CLI_MOCK_ERROR overrides it.
+	Error string `yaml:"error"`
+}
+
+// Profile is a named set of settings, e.g. to keep separate budgets for work and personal use.
+type Profile struct {
+	Budget Budget     `yaml:"budget"`
+	Params dto.Params `yaml:"params"`
+}
+
+// Budget limits the spending of a profile, zero values mean no limit.
+type Budget struct {
+	// DailySpend and MonthlySpend are in USD.
+	DailySpend    float64 `yaml:"daily_spend"`
+	MonthlySpend  float64 `yaml:"monthly_spend"`
+	DailyTokens   int     `yaml:"daily_tokens"`
+	MonthlyTokens int     `yaml:"monthly_tokens"`
+	// WarnAt is the share of a limit after which every request is warned about, 0.8 by default.
+	WarnAt float64 `yaml:"warn_at"`
+	// CompletionEstimate is the number of answer tokens expected when checking a request, 1000 by default.
+	CompletionEstimate int `yaml:"completion_estimate"`
+}
+
+type Price struct {
+	Input       float64 `yaml:"input"`
+	CachedInput float64 `yaml:"cached_input"`
+	Output      float64 `yaml:"output"`
 }
 
-func New() Config {
-	return Config{
-		OpenaiApiKey: os.Getenv("OPENAI_API_KEY"),
+// New reads the config file if it exists and applies the environment.
+func New() (Config, error) {
+	cfg := Config{
+		ConfigDir: configDir(),
 	}
+
+	data, err := os.ReadFile(cfg.Path())
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return Config{}, fmt.Errorf("read config: %w", err)
+	default:
+		if err = yaml.Unmarshal(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parse config %s: %w", cfg.Path(), err)
+		}
+	}
+
+	if baseURL := os.Getenv("OPENAI_BASE_URL"); baseURL != "" {
+		cfg.OpenaiBaseURL = baseURL
+	}
+	if err = cfg.applyMockEnv(); err != nil {
+		return Config{}, err
+	}
+
+	if provider := os.Getenv("CHATGPT_CLI_PROVIDER"); provider != "" {
+		cfg.Provider = provider
+	}
+	if cfg.Provider == "" {
+		cfg.Provider = ProviderOpenAI
+	}
+
+	cfg.DataDir = dataDir()
+	cfg.CacheDir = cacheDir()
+
+	if profile := os.Getenv("CHATGPT_CLI_PROFILE"); profile != "" {
+		cfg.Profile = profile
+	}
+	if cfg.Profile == "" {
+		cfg.Profile = DefaultProfile
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) applyMockEnv() error {
+	if fixtures := os.Getenv("CHATGPT_CLI_MOCK_FIXTURES"); fixtures != "" {
+		c.Mock.Fixtures = fixtures
+	}
+	if latency := os.Getenv("CHATGPT_CLI_MOCK_LATENCY"); latency != "" {
+		d, err := time.ParseDuration(latency)
+		if err != nil {
+			return fmt.Errorf("parse CHATGPT_CLI_MOCK_LATENCY: %w", err)
+		}
+		c.Mock.Latency = d
+	}
+	if name := os.Getenv("CHATGPT_CLI_MOCK_ERROR"); name != "" {
+		c.Mock.Error = name
+	}
+
+	return nil
+}
+
+// ActiveProfile returns the settings of the active profile.
+func (c Config) ActiveProfile() Profile {
+	return c.Profiles[c.Profile]
+}
+
+// DefaultParams returns the sampling parameters with the ones of the active profile applied.
+func (c Config) DefaultParams() dto.Params {
+	return c.Params.Merge(c.ActiveProfile().Params)
+}
+
+// Path returns the location of the config file.
+func (c Config) Path() string {
+	return filepath.Join(c.ConfigDir, fileName)
+}
+
+// Init writes the config file template unless the file exists.
+func (c Config) Init() error {
+	if err := os.MkdirAll(c.ConfigDir, 0o700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+
+	f, err := os.OpenFile(c.Path(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("config %s already exists", c.Path())
+	}
+	if err != nil {
+		return fmt.Errorf("create config: %w", err)
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(template); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+
+	return nil
 }
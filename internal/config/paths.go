@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const appName = "chatgpt-cli"
+
+// dataDir returns the directory for persistent data following the XDG base directory spec.
+// CHATGPT_CLI_DATA_DIR overrides it.
+func dataDir() string {
+	if dir := os.Getenv("CHATGPT_CLI_DATA_DIR"); dir != "" {
+		return dir
+	}
+
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, appName)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), appName)
+	}
+
+	return filepath.Join(home, ".local", "share", appName)
+}
+
+// configDir returns the directory for the config file. CHATGPT_CLI_CONFIG_DIR overrides it.
+func configDir() string {
+	if dir := os.Getenv("CHATGPT_CLI_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), appName)
+	}
+
+	return filepath.Join(dir, appName)
+}
+
+// cacheDir returns the directory for the data that can be recreated, such as the response cache.
+// CHATGPT_CLI_CACHE_DIR overrides it.
+func cacheDir() string {
+	if dir := os.Getenv("CHATGPT_CLI_CACHE_DIR"); dir != "" {
+		return dir
+	}
+
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, appName)
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), appName)
+	}
+
+	return filepath.Join(dir, appName)
+}
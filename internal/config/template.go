@@ -0,0 +1,107 @@
+package config
+
+// template is written by "config init", every setting is commented out with its default.
+const template = `# chatgpt-cli configuration.
+
+# The provider of the answers, CHATGPT_CLI_PROVIDER and --provider override it.
+# mock answers offline without an API key, e.g. to test scripts in CI.
+# provider: openai
+
+# The mock provider echoes the questions or answers them from the fixtures,
+# which may call tools and stream the answers in chunks through serve.
+# The CHATGPT_CLI_MOCK_FIXTURES, _LATENCY and _ERROR variables override the settings.
+# mock:
+#   fixtures: /path/to/mock.yaml
+#   latency: 0s
+#   error: rate_limit    # fail every request, e.g. auth, quota, rate_limit, network, timeout
+
+# The API key is looked up in the sources in order: the OPENAI_API_KEY variable,
+# the keyring of the OS where auth login stores it, the output of a command and a file.
+# auth status shows which source has the key.
+# auth:
+#   sources: [env, keyring, command, file]
+#   api_key_command: pass show openai    # runs once per process
+#   api_key_file: ~/.config/chatgpt-cli/api_key   # must not be readable by other users
+
+# The log of the requests: the latency, the status and the request id of every API call.
+# --verbose is --log-level debug. The prompts and the answers are left out unless prompts is set.
+# log:
+#   level: info          # debug, info, warn or error, off by default
+#   format: text         # text or json
+#   file: chatgpt-cli.log   # relative to the cache directory, stderr by default, info unless level is set
+#   max_size_mb: 10      # rotate the file when it grows over the size
+#   max_backups: 3
+#   prompts: false
+
+# Another OpenAI compatible API, e.g. a proxy. OPENAI_BASE_URL overrides it.
+# openai_base_url: https://api.openai.com/v1
+
+# Prices in USD per 1M tokens, they override the built-in price table.
+# A model matches the longest price key it starts with, e.g. gpt-4o-2024-08-06 uses gpt-4o.
+# prices:
+#   gpt-4o:
+#     input: 2.5
+#     cached_input: 1.25
+#     output: 10
+
+# The active profile, CHATGPT_CLI_PROFILE and --profile override it.
+# profile: default
+
+# Profiles keep separate settings. Budgets are checked before every request
+# against the usage ledger, --ignore-budget turns the hard stop into a warning.
+# profiles:
+#   default:
+#     budget:
+#       daily_spend: 1       # USD
+#       monthly_spend: 20    # USD
+#       daily_tokens: 0      # 0 means no limit
+#       monthly_tokens: 0
+#       warn_at: 0.8         # warn after 80% of a limit
+#       completion_estimate: 1000
+#     params:            # override the default parameters below
+#       temperature: 0.2
+
+# Answers to repeated requests can be reused from the cache directory,
+# --no-cache skips the cache and --refresh replaces the cached answer.
+# cache:
+#   enabled: false
+#   ttl: 24h
+#   max_size_mb: 100
+#   all: false           # also cache requests with a non-zero temperature and no seed
+
+# The list of models is kept for the models command and the completion of --model,
+# --refresh fetches it again.
+# models:
+#   cache_ttl: 24h
+
+# A short title and a few tags are generated for a chat after its first answer
+# and shown by sessions list, /title sets or regenerates them.
+# titles:
+#   disabled: false
+#   model: gpt-4o-mini
+
+# Secrets and personal data are replaced with placeholders such as [REDACTED_API_KEY_1]
+# before the messages are sent, --no-redact sends them as they are.
+# Built-in detectors: private_key, jwt, aws_access_key, aws_secret_key, gcp_api_key,
+# gcp_token, api_key, url_password, password, email.
+# redaction:
+#   disabled: false
+#   restore: false       # put the values back into the answers, locally
+#   skip: [email]
+#   patterns:            # the first group of a regex is replaced if it has one
+#     - name: employee_id
+#       regex: EMP-[0-9]{6}
+
+# Default sampling parameters, the flags of ask and chat and /set override them.
+# params:
+#   temperature: 0.7
+#   top_p: 1
+#   max_tokens: 0        # 0 means the model limit
+#   presence_penalty: 0
+#   frequency_penalty: 0
+#   seed: 42
+#   stop: ["###"]
+#   logit_bias:          # token ID: bias from -100 to 100
+#     "50256": -100
+#   user: alice          # end user ID for abuse monitoring
+`
@@ -0,0 +1,328 @@
+// Package credentials finds the API key in the environment, the keyring of the OS,
+// the output of a command or a file, in the configured order.
+package credentials
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+)
+
+const (
+	SourceEnv     = "env"
+	SourceKeyring = "keyring"
+	SourceCommand = "command"
+	SourceFile    = "file"
+
+	// EnvKey is the variable of the env source.
+	EnvKey = "OPENAI_API_KEY"
+
+	// Service and Account name the key in the keyring.
+	Service = "chatgpt-cli"
+	Account = "openai"
+
+	// defaultFile keeps the key in the config directory unless the config names another file.
+	defaultFile    = "api_key"
+	commandTimeout = time.Minute
+)
+
+// ErrNoKey is returned when none of the sources has the key.
+var ErrNoKey = errors.New("the OpenAI API key is not set")
+
+// Sources returns the names of the sources in the default order.
+func Sources() []string {
+	return []string{SourceEnv, SourceKeyring, SourceCommand, SourceFile}
+}
+
+// Key is the API key with the name of the source it comes from.
+type Key struct {
+	Value  string
+	Source string
+}
+
+// Masked returns the key with only its beginning and end shown, e.g. sk-p…wxyz.
+func (k Key) Masked() string {
+	if len(k.Value) < 12 {
+		return strings.Repeat("*", len(k.Value))
+	}
+
+	return k.Value[:4] + "…" + k.Value[len(k.Value)-4:]
+}
+
+// Status tells whether a source has the key, Detail explains the state for a person.
+type Status struct {
+	Source string
+	Found  bool
+	Detail string
+}
+
+// Resolver finds the API key in the sources.
+type Resolver struct {
+	cfg       config.Auth
+	configDir string
+	keyring   Keyring
+	getenv    func(string) string
+}
+
+// New creates the resolver with the system keyring, configDir keeps the default key file.
+func New(cfg config.Auth, configDir string, opts ...Option) (*Resolver, error) {
+	for _, source := range cfg.Sources {
+		if !slices.Contains(Sources(), source) {
+			return nil, fmt.Errorf("unknown API key source %q, use %s", source, strings.Join(Sources(), ", "))
+		}
+	}
+
+	r := &Resolver{
+		cfg:       cfg,
+		configDir: configDir,
+		keyring:   SystemKeyring(),
+		getenv:    os.Getenv,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+// Resolve returns the key of the first source that has one. A source that is set up
+// but fails, e.g. a failing command or a file readable by others, stops the search.
+func (r *Resolver) Resolve() (Key, error) {
+	for _, source := range r.sources() {
+		value, err := r.lookup(source)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnavailable) {
+			continue
+		}
+		if err != nil {
+			return Key{}, err
+		}
+
+		return Key{Value: value, Source: source}, nil
+	}
+
+	return Key{}, ErrNoKey
+}
+
+// Inspect checks every source, e.g. to show where the key could come from.
+func (r *Resolver) Inspect() []Status {
+	statuses := make([]Status, 0, len(r.sources()))
+
+	for _, source := range r.sources() {
+		value, err := r.lookup(source)
+
+		status := Status{Source: source, Found: err == nil}
+		switch {
+		case err == nil:
+			status.Detail = Key{Value: value}.Masked()
+		case errors.Is(err, ErrNotFound):
+			status.Detail = strings.TrimPrefix(err.Error(), ErrNotFound.Error()+": ")
+		default:
+			status.Detail = err.Error()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// Save stores the key in the keyring or the key file and returns where it has been stored.
+func (r *Resolver) Save(store, key string) (string, error) {
+	switch store {
+	case SourceKeyring:
+		if err := r.keyring.Set(Service, Account, key); err != nil {
+			return "", fmt.Errorf("store the key in the keyring: %w", err)
+		}
+		return "the keyring", nil
+	case SourceFile:
+		path := r.filePath()
+		if err := writeFile(path, key); err != nil {
+			return "", err
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("the key can be stored in the %s or a %s, not %q", SourceKeyring, SourceFile, store)
+	}
+}
+
+// Delete removes the key from the keyring or the key file, ErrNotFound means there was none.
+func (r *Resolver) Delete(store string) (string, error) {
+	switch store {
+	case SourceKeyring:
+		if _, err := r.keyring.Get(Service, Account); err != nil {
+			return "", err
+		}
+		if err := r.keyring.Delete(Service, Account); err != nil {
+			return "", fmt.Errorf("delete the key from the keyring: %w", err)
+		}
+		return "the keyring", nil
+	case SourceFile:
+		path := r.filePath()
+		err := os.Remove(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", ErrNotFound
+		}
+		if err != nil {
+			return "", fmt.Errorf("delete the key file: %w", err)
+		}
+		return path, nil
+	default:
+		return "", fmt.Errorf("the key can be stored in the %s or a %s, not %q", SourceKeyring, SourceFile, store)
+	}
+}
+
+func (r *Resolver) sources() []string {
+	if len(r.cfg.Sources) == 0 {
+		return Sources()
+	}
+
+	return r.cfg.Sources
+}
+
+// lookup returns the key of the source, ErrNotFound if the source is not set up or has no key.
+func (r *Resolver) lookup(source string) (string, error) {
+	switch source {
+	case SourceEnv:
+		if value := strings.TrimSpace(r.getenv(EnvKey)); value != "" {
+			return value, nil
+		}
+		return "", fmt.Errorf("%w: %s is not set", ErrNotFound, EnvKey)
+	case SourceKeyring:
+		value, err := r.keyring.Get(Service, Account)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return "", fmt.Errorf("%w: run auth login to store the key", ErrNotFound)
+		case err != nil && !errors.Is(err, ErrUnavailable):
+			// A keyring that is installed but not running, e.g. over SSH, is skipped as a missing one.
+			return "", fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return value, err
+	case SourceCommand:
+		if r.cfg.APIKeyCommand == "" {
+			return "", fmt.Errorf("%w: api_key_command is not set", ErrNotFound)
+		}
+		return runCommand(r.cfg.APIKeyCommand)
+	case SourceFile:
+		return readFile(r.filePath())
+	default:
+		return "", fmt.Errorf("unknown API key source %q", source)
+	}
+}
+
+func (r *Resolver) filePath() string {
+	if r.cfg.APIKeyFile == "" {
+		return filepath.Join(r.configDir, defaultFile)
+	}
+
+	return expandHome(r.cfg.APIKeyFile)
+}
+
+// commands keeps the output of the key commands for the life of the process,
+// e.g. a password manager asks for the passphrase once.
+var commands struct {
+	sync.Mutex
+	keys map[string]string
+}
+
+func runCommand(command string) (string, error) {
+	commands.Lock()
+	defer commands.Unlock()
+
+	if key, ok := commands.keys[command]; ok {
+		return key, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	cmd := exec.CommandContext(ctx, shell, flag, command) //nolint:gosec // the command is configured by the user
+	// The terminal stays available for a passphrase prompt.
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("run api_key_command: %w", err)
+	}
+
+	// pass and similar tools print the secret on the first line and the notes after it.
+	key, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("run api_key_command: the command printed no key")
+	}
+
+	if commands.keys == nil {
+		commands.keys = make(map[string]string)
+	}
+	commands.keys[command] = key
+
+	return key, nil
+}
+
+func readFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("%w: %s does not exist", ErrNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read the key file: %w", err)
+	}
+
+	// Windows has no permission bits to check.
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("the key file %s can be read by other users, run chmod 600 %s", path, path)
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // the path is configured by the user
+	if err != nil {
+		return "", fmt.Errorf("read the key file: %w", err)
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("%w: %s is empty", ErrNotFound, path)
+	}
+
+	return key, nil
+}
+
+func writeFile(path, key string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create the key directory: %w", err)
+	}
+
+	if err := atomicfile.Write(path, []byte(key+"\n"), 0o600); err != nil {
+		return fmt.Errorf("write the key file: %w", err)
+	}
+
+	return nil
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+
+	return path
+}
@@ -0,0 +1,214 @@
+package credentials_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/credentials"
+)
+
+// keyring keeps the secrets in memory.
+type keyring map[string]string
+
+func (k keyring) Get(service, account string) (string, error) {
+	secret, ok := k[service+"/"+account]
+	if !ok {
+		return "", credentials.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k keyring) Set(service, account, secret string) error {
+	k[service+"/"+account] = secret
+	return nil
+}
+
+func (k keyring) Delete(service, account string) error {
+	delete(k, service+"/"+account)
+	return nil
+}
+
+func env(values map[string]string) credentials.Option {
+	return credentials.WithGetenv(func(key string) string { return values[key] })
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api_key"), []byte("sk-file\n"), 0o600))
+
+	tests := []struct {
+		name    string
+		cfg     config.Auth
+		env     map[string]string
+		keyring keyring
+		want    credentials.Key
+	}{
+		{
+			name:    "env first",
+			env:     map[string]string{credentials.EnvKey: "sk-env"},
+			keyring: keyring{"chatgpt-cli/openai": "sk-keyring"},
+			want:    credentials.Key{Value: "sk-env", Source: credentials.SourceEnv},
+		},
+		{
+			name:    "keyring",
+			keyring: keyring{"chatgpt-cli/openai": "sk-keyring"},
+			want:    credentials.Key{Value: "sk-keyring", Source: credentials.SourceKeyring},
+		},
+		{
+			name:    "file",
+			keyring: keyring{},
+			want:    credentials.Key{Value: "sk-file", Source: credentials.SourceFile},
+		},
+		{
+			name:    "configured order",
+			cfg:     config.Auth{Sources: []string{credentials.SourceFile, credentials.SourceEnv}},
+			env:     map[string]string{credentials.EnvKey: "sk-env"},
+			keyring: keyring{},
+			want:    credentials.Key{Value: "sk-file", Source: credentials.SourceFile},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := credentials.New(tc.cfg, dir, credentials.WithKeyring(tc.keyring), env(tc.env))
+			require.NoError(t, err)
+
+			got, err := r.Resolve()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	r, err := credentials.New(config.Auth{Sources: []string{credentials.SourceEnv}}, dir, env(nil))
+	require.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.ErrorIs(t, err, credentials.ErrNoKey)
+}
+
+func TestResolver_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command uses sh")
+	}
+
+	counter := filepath.Join(t.TempDir(), "runs")
+	cfg := config.Auth{
+		Sources:       []string{credentials.SourceCommand},
+		APIKeyCommand: "echo run >> " + counter + "; printf 'sk-command\\nnotes\\n'",
+	}
+
+	r, err := credentials.New(cfg, t.TempDir(), env(nil))
+	require.NoError(t, err)
+
+	for range 2 {
+		got, err := r.Resolve()
+		assert.NoError(t, err)
+		assert.Equal(t, credentials.Key{Value: "sk-command", Source: credentials.SourceCommand}, got)
+	}
+
+	// The command runs once per process.
+	runs, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(runs), "run"))
+
+	r, err = credentials.New(config.Auth{APIKeyCommand: "exit 1"}, t.TempDir(), credentials.WithKeyring(keyring{}), env(nil))
+	require.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.ErrorContains(t, err, "run api_key_command")
+}
+
+func TestResolver_File(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("windows has no permission bits")
+	}
+
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte("sk-file"), 0o644))
+
+	cfg := config.Auth{Sources: []string{credentials.SourceFile}, APIKeyFile: path}
+	r, err := credentials.New(cfg, t.TempDir(), env(nil))
+	require.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.ErrorContains(t, err, "can be read by other users")
+
+	require.NoError(t, os.Chmod(path, 0o600))
+
+	got, err := r.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-file", got.Value)
+}
+
+func TestResolver_SaveDelete(t *testing.T) {
+	dir := t.TempDir()
+	k := keyring{}
+
+	r, err := credentials.New(config.Auth{}, dir, credentials.WithKeyring(k), env(nil))
+	require.NoError(t, err)
+
+	// A key file left readable by others is replaced with a private one.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api_key"), []byte("sk-old\n"), 0o644))
+
+	where, err := r.Save(credentials.SourceFile, "sk-file")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "api_key"), where)
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(where)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	_, err = r.Save(credentials.SourceKeyring, "sk-keyring")
+	assert.NoError(t, err)
+	assert.Equal(t, "sk-keyring", k["chatgpt-cli/openai"])
+
+	got, err := r.Resolve()
+	assert.NoError(t, err)
+	assert.Equal(t, credentials.SourceKeyring, got.Source)
+
+	_, err = r.Delete(credentials.SourceKeyring)
+	assert.NoError(t, err)
+	_, err = r.Delete(credentials.SourceKeyring)
+	assert.ErrorIs(t, err, credentials.ErrNotFound)
+
+	_, err = r.Delete(credentials.SourceFile)
+	assert.NoError(t, err)
+	_, err = r.Delete(credentials.SourceFile)
+	assert.ErrorIs(t, err, credentials.ErrNotFound)
+
+	_, err = r.Save(credentials.SourceEnv, "sk-env")
+	assert.Error(t, err)
+}
+
+func TestResolver_Inspect(t *testing.T) {
+	r, err := credentials.New(config.Auth{}, t.TempDir(), credentials.WithKeyring(keyring{}),
+		env(map[string]string{credentials.EnvKey: "sk-proj-abcdefghijklmnop"}))
+	require.NoError(t, err)
+
+	statuses := r.Inspect()
+	require.Len(t, statuses, 4)
+
+	assert.Equal(t, credentials.Status{Source: credentials.SourceEnv, Found: true, Detail: "sk-p…mnop"}, statuses[0])
+	assert.Equal(t, credentials.Status{Source: credentials.SourceKeyring, Detail: "run auth login to store the key"}, statuses[1])
+	assert.Equal(t, credentials.Status{Source: credentials.SourceCommand, Detail: "api_key_command is not set"}, statuses[2])
+	assert.False(t, statuses[3].Found)
+}
+
+func TestNew(t *testing.T) {
+	_, err := credentials.New(config.Auth{Sources: []string{"vault"}}, t.TempDir())
+	assert.ErrorContains(t, err, `unknown API key source "vault"`)
+}
+
+func TestKey_Masked(t *testing.T) {
+	assert.Equal(t, "sk-p…mnop", credentials.Key{Value: "sk-proj-abcdefghijklmnop"}.Masked())
+	assert.Equal(t, "*****", credentials.Key{Value: "short"}.Masked())
+}
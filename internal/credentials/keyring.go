@@ -0,0 +1,171 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const keyringTimeout = 10 * time.Second
+
+var (
+	// ErrNotFound is returned by a keyring without the secret.
+	ErrNotFound = errors.New("the key is not found")
+	// ErrUnavailable is returned when the OS has no secret service the keyring can use.
+	ErrUnavailable = errors.New("no keyring available")
+)
+
+// Keyring keeps the secrets in the secret service of the OS.
+type Keyring interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+}
+
+// SystemKeyring returns the keyring of the OS: the login keychain on macOS
+// and the Secret Service with secret-tool on Linux.
+func SystemKeyring() Keyring {
+	switch runtime.GOOS {
+	case "darwin":
+		return keychain{}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return secretService{}
+	default:
+		return unavailable{}
+	}
+}
+
+// keychain uses the security tool of macOS.
+type keychain struct{}
+
+func (keychain) Get(service, account string) (string, error) {
+	out, err := callKeyring("security", "", "find-generic-password", "-s", service, "-a", account, "-w")
+	if err != nil {
+		// security exits with 44 for a missing item.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+func (k keychain) Set(service, account, secret string) error {
+	// The command is read from stdin so the secret never shows in the arguments of the process,
+	// -U updates the item if it exists.
+	command := strings.Join([]string{
+		"add-generic-password", "-U", "-s", quote(service), "-a", quote(account), "-w", quote(secret),
+	}, " ")
+
+	if _, err := callKeyring("security", command+"\n", "-i"); err != nil {
+		return err
+	}
+
+	// security -i does not always exit with the status of the command, the item is read back.
+	stored, err := k.Get(service, account)
+	if err != nil {
+		return err
+	}
+	if stored != secret {
+		return errors.New("security: the key has not been stored")
+	}
+
+	return nil
+}
+
+func (keychain) Delete(service, account string) error {
+	_, err := callKeyring("security", "", "delete-generic-password", "-s", service, "-a", account)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+// quote makes the argument a single word of a command of security -i.
+func quote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// secretService uses secret-tool of libsecret, e.g. with GNOME Keyring or KWallet.
+type secretService struct{}
+
+func (secretService) Get(service, account string) (string, error) {
+	out, err := callKeyring("secret-tool", "", "lookup", "service", service, "account", account)
+
+	// secret-tool exits with 1 and prints nothing for a missing secret.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && strings.TrimSpace(out) == "" {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secret := strings.TrimSpace(out)
+	if secret == "" {
+		return "", ErrNotFound
+	}
+
+	return secret, nil
+}
+
+func (secretService) Set(service, account, secret string) error {
+	_, err := callKeyring("secret-tool", secret, "store", "--label", service+" "+account, "service", service, "account", account)
+	return err
+}
+
+func (secretService) Delete(service, account string) error {
+	_, err := callKeyring("secret-tool", "", "clear", "service", service, "account", account)
+	return err
+}
+
+type unavailable struct{}
+
+func (unavailable) Get(string, string) (string, error) {
+	return "", fmt.Errorf("%w on %s", ErrUnavailable, runtime.GOOS)
+}
+
+func (unavailable) Set(string, string, string) error {
+	return fmt.Errorf("%w on %s", ErrUnavailable, runtime.GOOS)
+}
+
+func (unavailable) Delete(string, string) error {
+	return fmt.Errorf("%w on %s", ErrUnavailable, runtime.GOOS)
+}
+
+// callKeyring runs the tool of the keyring with the input, a missing tool means there is no keyring.
+func callKeyring(name, stdin string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringTimeout)
+	defer cancel()
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s is not installed", ErrUnavailable, name)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, path, args...) //nolint:gosec // the path is one of the keyring tools
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err = cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return stdout.String(), fmt.Errorf("%s: %w", name, err)
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,17 @@
+package credentials
+
+type Option func(*Resolver)
+
+// WithKeyring replaces the keyring of the OS, e.g. with one of a password manager.
+func WithKeyring(keyring Keyring) Option {
+	return func(r *Resolver) {
+		r.keyring = keyring
+	}
+}
+
+// WithGetenv replaces the lookup of the environment variables.
+func WithGetenv(getenv func(string) string) Option {
+	return func(r *Resolver) {
+		r.getenv = getenv
+	}
+}
@@ -0,0 +1,58 @@
+package dto
+
+import "time"
+
+// BatchStatus is the state of a batch job of the provider.
+type BatchStatus string
+
+const (
+	BatchValidating BatchStatus = "validating"
+	BatchFailed     BatchStatus = "failed"
+	BatchInProgress BatchStatus = "in_progress"
+	BatchFinalizing BatchStatus = "finalizing"
+	BatchCompleted  BatchStatus = "completed"
+	BatchExpired    BatchStatus = "expired"
+	BatchCancelling BatchStatus = "cancelling"
+	BatchCancelled  BatchStatus = "cancelled"
+)
+
+// Done reports whether the batch will not change anymore.
+func (s BatchStatus) Done() bool {
+	switch s {
+	case BatchFailed, BatchCompleted, BatchExpired, BatchCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// BatchJob is a batch submitted to the provider to be answered asynchronously.
+type BatchJob struct {
+	ID           string
+	Status       BatchStatus
+	InputFileID  string
+	OutputFileID string
+	ErrorFileID  string
+	CreatedAt    time.Time
+	DoneAt       time.Time
+	Total        int
+	Completed    int
+	Failed       int
+	// Errors explain why the batch failed, e.g. an invalid input line.
+	Errors []string
+}
+
+// BatchItem is a request of a batch, the chat ends with the question.
+type BatchItem struct {
+	ID   string
+	Chat *Chat
+}
+
+// BatchResult is the answer to a request of a batch, Error is set instead of Content if the request failed.
+type BatchResult struct {
+	ID      string `json:"id"`
+	Model   string `json:"model,omitempty"`
+	Content string `json:"content,omitempty"`
+	Usage   *Usage `json:"usage,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
@@ -1,25 +1,247 @@
 package dto
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SentMessages is the number of the last messages of the chat sent with a question.
+const SentMessages = 20
+
+// Chat is a tree of messages. Editing a past message or regenerating an answer
+// starts a new branch, Head points to the last message of the active branch.
 type Chat struct {
-	Messages []Message
+	Nodes []Node `json:"nodes"`
+	Head  int    `json:"head"`
+
+	// Model overrides the model of the assistant for this chat, e.g. for a line of a batch.
+	Model string `json:"model,omitempty"`
+	// Preset is the name of the preset the system prompt, examples and parameters come from.
+	Preset string `json:"preset,omitempty"`
+	// System is the system prompt and Examples are the exchanges sent before the messages.
+	System   string    `json:"system,omitempty"`
+	Examples []Message `json:"examples,omitempty"`
+	Params   Params    `json:"params"`
+	// PresetParams are the parameters the preset set, the other ones of Params were set by the user.
+	PresetParams Params `json:"preset_params"`
+
+	// Full sends all the messages, by default only the last ones are sent to keep the requests small.
+	// It is set for the conversations of other tools relayed by the proxy.
+	Full bool `json:"-"`
+	// OwnParams sends only the parameters of the chat, the unset ones keep the defaults of the API
+	// instead of the ones of the config. It is set for the requests relayed by the proxy.
+	OwnParams bool `json:"-"`
+}
+
+// Node is a message in the chat tree. IDs start from one, zero ParentID means a root message.
+type Node struct {
+	ID       int `json:"id"`
+	ParentID int `json:"parent_id,omitempty"`
+	// Time is when the message was added, it is zero for the chats saved before it was kept.
+	Time time.Time `json:"time"`
+	Message
 }
 
 type Message struct {
-	Role    Role
-	Content string
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+	// Model and Usage are set for the answers of the assistant.
+	Model string `json:"model,omitempty"`
+	Usage *Usage `json:"usage,omitempty"`
+	// ToolCalls are the calls an answer asks for, ToolCallID is the call a tool message is the result of.
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
 }
 
 func NewChat() *Chat {
 	return &Chat{}
 }
 
+// AddMessage appends a message to the active branch.
 func (c *Chat) AddMessage(role Role, content string) {
-	c.Messages = append(c.Messages, Message{
+	c.Add(Message{
 		Role:    role,
 		Content: content,
 	})
 }
 
+// Add appends a message with its metadata to the active branch.
+func (c *Chat) Add(message Message) {
+	node := Node{
+		ID:       len(c.Nodes) + 1,
+		ParentID: c.Head,
+		Time:     time.Now(),
+		Message:  message,
+	}
+
+	c.Nodes = append(c.Nodes, node)
+	c.Head = node.ID
+}
+
+// Messages returns the messages of the active branch from the first one.
+func (c *Chat) Messages() []Message {
+	path := c.Path()
+
+	messages := make([]Message, 0, len(path))
+	for _, node := range path {
+		messages = append(messages, node.Message)
+	}
+
+	return messages
+}
+
+// Preamble returns the system prompt and the examples sent before the messages.
+func (c *Chat) Preamble() []Message {
+	var preamble []Message
+	if c.System != "" {
+		preamble = append(preamble, Message{Role: RoleSystem, Content: c.System})
+	}
+
+	return append(preamble, c.Examples...)
+}
+
+// Prompt returns the preamble followed by the messages of the active branch.
+func (c *Chat) Prompt() []Message {
+	return append(c.Preamble(), c.Messages()...)
+}
+
+// Sent returns the part of the prompt sent with the next question: the preamble and, unless the chat is Full,
+// the last SentMessages messages.
+func (c *Chat) Sent() []Message {
+	messages := c.Messages()
+	if !c.Full && len(messages) > SentMessages {
+		messages = messages[len(messages)-SentMessages:]
+	}
+
+	// The preamble is always sent, only the conversation is cut.
+	return append(c.Preamble(), messages...)
+}
+
+// Path returns the nodes of the active branch from the first one.
+func (c *Chat) Path() []Node {
+	return c.PathTo(c.Head)
+}
+
+// PathTo returns the nodes of the branch ending with the node from the first one.
+func (c *Chat) PathTo(id int) []Node {
+	var path []Node
+	for id != 0 {
+		node, ok := c.Node(id)
+		if !ok {
+			break
+		}
+
+		path = append(path, node)
+		id = node.ParentID
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
+
+// Node returns the node by its ID.
+func (c *Chat) Node(id int) (Node, bool) {
+	if id < 1 || id > len(c.Nodes) {
+		return Node{}, false
+	}
+
+	return c.Nodes[id-1], true
+}
+
+// Leaves returns the last nodes of all branches in the order they were created.
+func (c *Chat) Leaves() []Node {
+	parents := make(map[int]bool, len(c.Nodes))
+	for _, node := range c.Nodes {
+		parents[node.ParentID] = true
+	}
+
+	var leaves []Node
+	for _, node := range c.Nodes {
+		if !parents[node.ID] {
+			leaves = append(leaves, node)
+		}
+	}
+
+	return leaves
+}
+
+// LastMessage returns the last message of the active branch if there is any.
+func (c *Chat) LastMessage() (Message, bool) {
+	node, ok := c.Node(c.Head)
+
+	return node.Message, ok
+}
+
+// Checkout makes the branch ending with the node active. Adding a message after
+// checking out a node in the middle of a branch starts a new branch.
+func (c *Chat) Checkout(id int) error {
+	if _, ok := c.Node(id); !ok && id != 0 {
+		return fmt.Errorf("message %d does not exist", id)
+	}
+
+	c.Head = id
+
+	return nil
+}
+
+// Rewind moves the head n messages back, the skipped messages stay in their branch.
+func (c *Chat) Rewind(n int) {
+	for ; n > 0 && c.Head != 0; n-- {
+		node, _ := c.Node(c.Head)
+		c.Head = node.ParentID
+	}
+}
+
+// Rollback removes the last added message if it is the head, e.g. after a failed request.
+func (c *Chat) Rollback() {
+	if n := len(c.Nodes); n > 0 && c.Nodes[n-1].ID == c.Head {
+		c.Head = c.Nodes[n-1].ParentID
+		c.Nodes = c.Nodes[:n-1]
+	}
+}
+
+// Usage sums up the tokens spent on all answers in all branches, grouped by model.
+func (c *Chat) Usage() map[string]Usage {
+	usage := make(map[string]Usage)
+	for _, node := range c.Nodes {
+		if node.Usage != nil {
+			usage[node.Model] = usage[node.Model].Add(*node.Usage)
+		}
+	}
+
+	return usage
+}
+
+// Reset removes the messages, the preamble and the parameters stay.
 func (c *Chat) Reset() {
-	c.Messages = nil
+	c.Nodes = nil
+	c.Head = 0
+}
+
+// UnmarshalJSON also reads chats saved as a flat list of messages.
+func (c *Chat) UnmarshalJSON(data []byte) error {
+	type chat Chat
+
+	var in struct {
+		chat
+		Messages []Message `json:"messages"`
+	}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*c = Chat(in.chat)
+
+	if len(c.Nodes) == 0 {
+		for _, message := range in.Messages {
+			c.AddMessage(message.Role, message.Content)
+		}
+	}
+
+	return nil
 }
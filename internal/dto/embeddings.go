@@ -0,0 +1,8 @@
+package dto
+
+// Embeddings are the vectors of the texts in their order, made by the model.
+type Embeddings struct {
+	Model   string
+	Vectors [][]float32
+	Usage   Usage
+}
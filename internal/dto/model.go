@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"slices"
+	"time"
+)
+
+// Capability is a kind of input or output a model supports.
+type Capability string
+
+const (
+	CapabilityChat       Capability = "chat"
+	CapabilityVision     Capability = "vision"
+	CapabilityAudio      Capability = "audio"
+	CapabilityEmbeddings Capability = "embeddings"
+	CapabilityReasoning  Capability = "reasoning"
+	CapabilityImage      Capability = "image"
+)
+
+// Capabilities lists the known capabilities.
+var Capabilities = []Capability{
+	CapabilityChat, CapabilityVision, CapabilityAudio, CapabilityEmbeddings, CapabilityReasoning, CapabilityImage,
+}
+
+// Model describes a model available to the API key.
+type Model struct {
+	ID      string    `json:"id"`
+	OwnedBy string    `json:"owned_by,omitempty"`
+	Created time.Time `json:"created"`
+	// Capabilities and ContextWindow come from the built-in table of known models, they are empty for unknown ones.
+	Capabilities  []Capability `json:"capabilities,omitempty"`
+	ContextWindow int          `json:"context_window,omitempty"`
+}
+
+// Can reports whether the model has the capability.
+func (m Model) Can(capability Capability) bool {
+	return slices.Contains(m.Capabilities, capability)
+}
@@ -0,0 +1,277 @@
+package dto
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParamNames lists the parameters in the order they are shown.
+var ParamNames = []string{
+	"temperature", "top_p", "max_tokens", "presence_penalty", "frequency_penalty", "seed", "stop", "logit_bias", "user",
+}
+
+// Params are the sampling parameters of a request, unset ones keep the defaults.
+type Params struct {
+	Temperature      *float32 `json:"temperature,omitempty" yaml:"temperature"`
+	TopP             *float32 `json:"top_p,omitempty" yaml:"top_p"`
+	MaxTokens        int      `json:"max_tokens,omitempty" yaml:"max_tokens"`
+	PresencePenalty  *float32 `json:"presence_penalty,omitempty" yaml:"presence_penalty"`
+	FrequencyPenalty *float32 `json:"frequency_penalty,omitempty" yaml:"frequency_penalty"`
+	Seed             *int     `json:"seed,omitempty" yaml:"seed"`
+	Stop             []string `json:"stop,omitempty" yaml:"stop"`
+	// LogitBias maps token IDs to a bias from -100 to 100.
+	LogitBias map[string]int `json:"logit_bias,omitempty" yaml:"logit_bias"`
+	// User identifies the end user to the provider for abuse monitoring.
+	User string `json:"user,omitempty" yaml:"user"`
+	// N is the number of answers to request, it is set per request and not configured.
+	N int `json:"n,omitempty" yaml:"-"`
+}
+
+// Merge returns the parameters with the ones set in over replacing them.
+func (p Params) Merge(over Params) Params {
+	if over.Temperature != nil {
+		p.Temperature = over.Temperature
+	}
+	if over.TopP != nil {
+		p.TopP = over.TopP
+	}
+	if over.MaxTokens > 0 {
+		p.MaxTokens = over.MaxTokens
+	}
+	if over.PresencePenalty != nil {
+		p.PresencePenalty = over.PresencePenalty
+	}
+	if over.FrequencyPenalty != nil {
+		p.FrequencyPenalty = over.FrequencyPenalty
+	}
+	if over.Seed != nil {
+		p.Seed = over.Seed
+	}
+	if len(over.Stop) > 0 {
+		p.Stop = over.Stop
+	}
+	if len(over.LogitBias) > 0 {
+		p.LogitBias = over.LogitBias
+	}
+	if over.User != "" {
+		p.User = over.User
+	}
+	if over.N > 0 {
+		p.N = over.N
+	}
+
+	return p
+}
+
+// Without returns the parameters that are not set to the same value in other,
+// e.g. the ones the user set over the parameters of a preset.
+func (p Params) Without(other Params) Params {
+	if equalPtr(p.Temperature, other.Temperature) {
+		p.Temperature = nil
+	}
+	if equalPtr(p.TopP, other.TopP) {
+		p.TopP = nil
+	}
+	if p.MaxTokens == other.MaxTokens {
+		p.MaxTokens = 0
+	}
+	if equalPtr(p.PresencePenalty, other.PresencePenalty) {
+		p.PresencePenalty = nil
+	}
+	if equalPtr(p.FrequencyPenalty, other.FrequencyPenalty) {
+		p.FrequencyPenalty = nil
+	}
+	if equalPtr(p.Seed, other.Seed) {
+		p.Seed = nil
+	}
+	if slices.Equal(p.Stop, other.Stop) {
+		p.Stop = nil
+	}
+	if maps.Equal(p.LogitBias, other.LogitBias) {
+		p.LogitBias = nil
+	}
+	if p.User == other.User {
+		p.User = ""
+	}
+	if p.N == other.N {
+		p.N = 0
+	}
+
+	return p
+}
+
+func equalPtr[T comparable](a, b *T) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// Set parses the value of the parameter, an empty value unsets it. The name may use dashes, e.g. top-p.
+// Stop takes a single sequence and logit_bias takes comma separated token=bias pairs.
+func (p *Params) Set(name, value string) error {
+	name = strings.ReplaceAll(name, "-", "_")
+
+	if value == "" {
+		return p.unset(name)
+	}
+
+	var err error
+
+	switch name {
+	case "temperature":
+		p.Temperature, err = parseFloat(value, 0, 2)
+	case "top_p":
+		p.TopP, err = parseFloat(value, 0, 1)
+	case "presence_penalty":
+		p.PresencePenalty, err = parseFloat(value, -2, 2)
+	case "frequency_penalty":
+		p.FrequencyPenalty, err = parseFloat(value, -2, 2)
+	case "max_tokens":
+		p.MaxTokens, err = strconv.Atoi(value)
+		if err == nil && p.MaxTokens < 1 {
+			err = fmt.Errorf("%d is not positive", p.MaxTokens)
+		}
+	case "seed":
+		var seed int
+		seed, err = strconv.Atoi(value)
+		p.Seed = &seed
+	case "stop":
+		p.Stop = []string{value}
+	case "logit_bias":
+		p.LogitBias, err = parseLogitBias(value)
+	case "user":
+		p.User = value
+	default:
+		return fmt.Errorf("unknown parameter %s, use one of %s", name, strings.Join(ParamNames, ", "))
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+
+	return nil
+}
+
+// Get formats the value of the parameter, it is empty if the parameter is not set.
+func (p Params) Get(name string) string {
+	switch strings.ReplaceAll(name, "-", "_") {
+	case "temperature":
+		return formatFloat(p.Temperature)
+	case "top_p":
+		return formatFloat(p.TopP)
+	case "presence_penalty":
+		return formatFloat(p.PresencePenalty)
+	case "frequency_penalty":
+		return formatFloat(p.FrequencyPenalty)
+	case "max_tokens":
+		if p.MaxTokens > 0 {
+			return strconv.Itoa(p.MaxTokens)
+		}
+	case "seed":
+		if p.Seed != nil {
+			return strconv.Itoa(*p.Seed)
+		}
+	case "stop":
+		if len(p.Stop) > 0 {
+			return strconv.Quote(strings.Join(p.Stop, `", "`))
+		}
+	case "logit_bias":
+		tokens := make([]string, 0, len(p.LogitBias))
+		for token, bias := range p.LogitBias {
+			tokens = append(tokens, fmt.Sprintf("%s=%d", token, bias))
+		}
+		sort.Strings(tokens)
+		return strings.Join(tokens, ",")
+	case "user":
+		return p.User
+	}
+
+	return ""
+}
+
+func (p *Params) unset(name string) error {
+	switch name {
+	case "temperature":
+		p.Temperature = nil
+	case "top_p":
+		p.TopP = nil
+	case "presence_penalty":
+		p.PresencePenalty = nil
+	case "frequency_penalty":
+		p.FrequencyPenalty = nil
+	case "max_tokens":
+		p.MaxTokens = 0
+	case "seed":
+		p.Seed = nil
+	case "stop":
+		p.Stop = nil
+	case "logit_bias":
+		p.LogitBias = nil
+	case "user":
+		p.User = ""
+	default:
+		return fmt.Errorf("unknown parameter %s, use one of %s", name, strings.Join(ParamNames, ", "))
+	}
+
+	return nil
+}
+
+func parseFloat(value string, low, high float64) (*float32, error) {
+	f, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	if f < low || f > high {
+		return nil, fmt.Errorf("out of range from %g to %g", low, high)
+	}
+
+	f32 := float32(f)
+
+	return &f32, nil
+}
+
+func parseLogitBias(value string) (map[string]int, error) {
+	bias := make(map[string]int)
+
+	for _, pair := range strings.Split(value, ",") {
+		token, b, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not token=bias", pair)
+		}
+
+		if _, err := strconv.Atoi(token); err != nil {
+			return nil, fmt.Errorf("token %q is not a token ID", token)
+		}
+
+		n, err := strconv.Atoi(b)
+		if err != nil {
+			return nil, err
+		}
+		if n < -100 || n > 100 {
+			return nil, fmt.Errorf("bias %d is out of range from -100 to 100", n)
+		}
+
+		bias[token] = n
+	}
+
+	return bias, nil
+}
+
+func formatFloat(f *float32) string {
+	if f == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(float64(*f), 'g', -1, 32)
+}
+
+// Clone copies the parameters so changing the copy keeps the original.
+func (p Params) Clone() Params {
+	p.Stop = append([]string(nil), p.Stop...)
+	p.LogitBias = maps.Clone(p.LogitBias)
+
+	return p
+}
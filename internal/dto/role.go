@@ -3,6 +3,9 @@ package dto
 type Role string
 
 const (
+	RoleSystem    Role = "system"
 	RoleUser      Role = "user"
 	RoleAssistant Role = "assistant"
+	// RoleTool is the role of the results of the tool calls of an answer.
+	RoleTool Role = "tool"
 )
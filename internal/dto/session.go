@@ -0,0 +1,17 @@
+package dto
+
+import "time"
+
+// Session is a chat saved on disk.
+type Session struct {
+	Name      string    `json:"name"`
+	Model     string    `json:"model"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Source identifies the conversation an imported session comes from, it is updated by the next import.
+	Source string `json:"source,omitempty"`
+	// Title and Tags describe the chat in the lists, they are generated after the first answer or set with /title.
+	Title string   `json:"title,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Chat  *Chat    `json:"chat"`
+}
@@ -0,0 +1,70 @@
+package dto
+
+import "time"
+
+// Usage is the number of tokens spent on a request.
+// CachedTokens are a part of PromptTokens, ReasoningTokens are a part of CompletionTokens.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	CachedTokens     int `json:"cached_tokens,omitempty"`
+	ReasoningTokens  int `json:"reasoning_tokens,omitempty"`
+}
+
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		CachedTokens:     u.CachedTokens + other.CachedTokens,
+		ReasoningTokens:  u.ReasoningTokens + other.ReasoningTokens,
+	}
+}
+
+// UsageRecord is a line of the usage ledger.
+type UsageRecord struct {
+	Time    time.Time `json:"time"`
+	Model   string    `json:"model"`
+	Session string    `json:"session,omitempty"`
+	Profile string    `json:"profile,omitempty"`
+	// Batch is the id of the batch of the Batch API the usage was spent in, it is billed at a discount.
+	Batch string `json:"batch,omitempty"`
+	Usage
+}
+
+// Completion is an answer of the model with its metadata.
+type Completion struct {
+	// Content, FinishReason and ToolCalls are the ones of the first choice.
+	Content      string     `json:"content"`
+	FinishReason string     `json:"finish_reason,omitempty"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	// Choices are all the answers when several were requested.
+	Choices []Choice `json:"choices,omitempty"`
+	Model   string   `json:"model"`
+	Usage   Usage    `json:"usage"`
+	// Cached is set for the answers reused from the response cache, nothing was spent on them.
+	Cached bool `json:"-"`
+}
+
+// Choice is one of the answers to a request.
+type Choice struct {
+	Content string `json:"content"`
+	// FinishReason tells why the answer ended, e.g. stop or length when it was cut by max_tokens.
+	FinishReason string `json:"finish_reason,omitempty"`
+	// ToolCalls are the calls of the tools the answer asks for, its FinishReason is tool_calls then.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+}
+
+// ToolCall is a call of a function tool, the arguments are a JSON object.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
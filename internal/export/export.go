@@ -0,0 +1,268 @@
+// Package export writes chats as Markdown, HTML, JSON or plain text documents to share them.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatJSON     = "json"
+	FormatText     = "txt"
+
+	timeLayout = "2006-01-02 15:04"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Formats returns the names of the supported formats.
+func Formats() []string {
+	return []string{FormatMarkdown, FormatHTML, FormatJSON, FormatText}
+}
+
+// FormatOf returns the format of the file by its extension, Markdown for a file without one.
+func FormatOf(path string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case "", ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".html", ".htm":
+		return FormatHTML, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".txt":
+		return FormatText, nil
+	default:
+		return "", fmt.Errorf("%w of the extension %s, use one of %s", ErrUnknownFormat, ext, strings.Join(Formats(), ", "))
+	}
+}
+
+// Write writes the active branch of the session chat in the format.
+func Write(w io.Writer, session *dto.Session, format string) error {
+	switch format {
+	case FormatMarkdown:
+		return writeMarkdown(w, session)
+	case FormatHTML:
+		return writeHTML(w, session)
+	case FormatJSON:
+		return writeJSON(w, session)
+	case FormatText:
+		return writeText(w, session)
+	default:
+		return fmt.Errorf("%w %q, use one of %s", ErrUnknownFormat, format, strings.Join(Formats(), ", "))
+	}
+}
+
+// entry is a message of the document with its heading.
+type entry struct {
+	Author  string
+	Time    string
+	Model   string
+	Content string
+	Role    dto.Role
+}
+
+// entriesOf returns the preamble and the messages of the active branch.
+func entriesOf(chat *dto.Chat) []entry {
+	var entries []entry
+
+	if chat.System != "" {
+		entries = append(entries, entry{Author: "System", Content: chat.System, Role: dto.RoleSystem})
+	}
+
+	for _, example := range chat.Examples {
+		entries = append(entries, entry{Author: authorOf(example.Role) + " (example)", Content: example.Content, Role: example.Role})
+	}
+
+	for _, node := range chat.Path() {
+		entries = append(entries, entry{
+			Author:  authorOf(node.Role),
+			Time:    formatTime(node.Time),
+			Model:   node.Model,
+			Content: node.Content,
+			Role:    node.Role,
+		})
+	}
+
+	return entries
+}
+
+func authorOf(role dto.Role) string {
+	switch role {
+	case dto.RoleUser:
+		return "You"
+	case dto.RoleAssistant:
+		return "AI"
+	case dto.RoleSystem:
+		return "System"
+	default:
+		return string(role)
+	}
+}
+
+// titleOf returns the title or the name of the session, or a generic title for an unsaved chat.
+func titleOf(session *dto.Session) string {
+	if session.Title != "" {
+		return session.Title
+	}
+
+	if session.Name != "" {
+		return session.Name
+	}
+
+	return "Chat"
+}
+
+// details returns the metadata lines shown under the title.
+func details(session *dto.Session) [][2]string {
+	var lines [][2]string
+
+	add := func(name, value string) {
+		if value != "" {
+			lines = append(lines, [2]string{name, value})
+		}
+	}
+
+	add("Tags", strings.Join(session.Tags, ", "))
+	add("Model", session.Model)
+	add("Preset", session.Chat.Preset)
+	add("Created", formatTime(session.CreatedAt))
+	add("Updated", formatTime(session.UpdatedAt))
+
+	return lines
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(timeLayout)
+}
+
+// heading joins the author, the time and the model of the message.
+func heading(e entry) string {
+	parts := []string{e.Author}
+	if e.Time != "" {
+		parts = append(parts, e.Time)
+	}
+	if e.Model != "" {
+		parts = append(parts, e.Model)
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+func writeMarkdown(w io.Writer, session *dto.Session) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", titleOf(session))
+	for _, line := range details(session) {
+		fmt.Fprintf(&b, "- **%s:** %s\n", line[0], line[1])
+	}
+
+	for _, e := range entriesOf(session.Chat) {
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n", heading(e), strings.TrimSpace(e.Content))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write markdown: %w", err)
+	}
+
+	return nil
+}
+
+func writeText(w io.Writer, session *dto.Session) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n", titleOf(session))
+	for _, line := range details(session) {
+		fmt.Fprintf(&b, "%s: %s\n", line[0], line[1])
+	}
+
+	for _, e := range entriesOf(session.Chat) {
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", heading(e), strings.TrimSpace(e.Content))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write text: %w", err)
+	}
+
+	return nil
+}
+
+type document struct {
+	Name      string        `json:"name,omitempty"`
+	Title     string        `json:"title,omitempty"`
+	Tags      []string      `json:"tags,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	Preset    string        `json:"preset,omitempty"`
+	CreatedAt *time.Time    `json:"created_at,omitempty"`
+	UpdatedAt *time.Time    `json:"updated_at,omitempty"`
+	System    string        `json:"system,omitempty"`
+	Examples  []dto.Message `json:"examples,omitempty"`
+	Params    dto.Params    `json:"params"`
+	Messages  []message     `json:"messages"`
+}
+
+type message struct {
+	Role    dto.Role   `json:"role"`
+	Content string     `json:"content"`
+	Model   string     `json:"model,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+	Usage   *dto.Usage `json:"usage,omitempty"`
+}
+
+// writeJSON writes the active branch as a flat list of messages, unlike the tree kept in the session file.
+func writeJSON(w io.Writer, session *dto.Session) error {
+	chat := session.Chat
+
+	doc := document{
+		Name:      session.Name,
+		Title:     session.Title,
+		Tags:      session.Tags,
+		Model:     session.Model,
+		Preset:    chat.Preset,
+		CreatedAt: timeOrNil(session.CreatedAt),
+		UpdatedAt: timeOrNil(session.UpdatedAt),
+		System:    chat.System,
+		Examples:  chat.Examples,
+		Params:    chat.Params,
+		Messages:  []message{},
+	}
+
+	for _, node := range chat.Path() {
+		doc.Messages = append(doc.Messages, message{
+			Role:    node.Role,
+			Content: node.Content,
+			Model:   node.Model,
+			Time:    timeOrNil(node.Time),
+			Usage:   node.Usage,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+
+	return nil
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,135 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/export"
+)
+
+func newSession() *dto.Session {
+	at := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+
+	chat := dto.NewChat()
+	chat.System = "Be brief"
+	chat.AddMessage(dto.RoleUser, "How to print in Go?")
+	chat.Add(dto.Message{
+		Role:    dto.RoleAssistant,
+		Content: "Use fmt:\n\n```go\nfmt.Println(\"<hi>\")\n```",
+		Model:   "gpt-4o",
+		Usage:   &dto.Usage{PromptTokens: 10, CompletionTokens: 20},
+	})
+	for i := range chat.Nodes {
+		chat.Nodes[i].Time = at
+	}
+
+	return &dto.Session{Name: "golang", Model: "gpt-4o", CreatedAt: at, UpdatedAt: at, Chat: chat}
+}
+
+func TestFormatOf(t *testing.T) {
+	testCases := []struct {
+		path    string
+		format  string
+		wantErr bool
+	}{
+		{path: "chat", format: export.FormatMarkdown},
+		{path: "chat.MD", format: export.FormatMarkdown},
+		{path: "out/chat.html", format: export.FormatHTML},
+		{path: "chat.json", format: export.FormatJSON},
+		{path: "chat.txt", format: export.FormatText},
+		{path: "chat.pdf", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			format, err := export.FormatOf(tc.path)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, export.ErrUnknownFormat)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.format, format)
+		})
+	}
+}
+
+func TestWrite_Markdown(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, export.Write(&out, newSession(), export.FormatMarkdown))
+
+	assert.Equal(t, "# golang\n\n"+
+		"- **Model:** gpt-4o\n"+
+		"- **Created:** 2024-05-01 10:30\n"+
+		"- **Updated:** 2024-05-01 10:30\n"+
+		"\n## System\n\nBe brief\n"+
+		"\n## You · 2024-05-01 10:30\n\nHow to print in Go?\n"+
+		"\n## AI · 2024-05-01 10:30 · gpt-4o\n\nUse fmt:\n\n```go\nfmt.Println(\"<hi>\")\n```\n", out.String())
+}
+
+func TestWrite_Text(t *testing.T) {
+	session := newSession()
+	session.Name = ""
+	session.Chat.Nodes[0].Time = time.Time{}
+
+	var out bytes.Buffer
+	assert.NoError(t, export.Write(&out, session, export.FormatText))
+
+	assert.Contains(t, out.String(), "Chat\nModel: gpt-4o\n")
+	assert.Contains(t, out.String(), "\n[You]\nHow to print in Go?\n")
+	assert.Contains(t, out.String(), "\n[AI · 2024-05-01 10:30 · gpt-4o]\nUse fmt:")
+}
+
+func TestWrite_HTML(t *testing.T) {
+	session := newSession()
+	session.Chat.AddMessage(dto.RoleUser, "<script>alert(1)</script>")
+
+	var out bytes.Buffer
+	assert.NoError(t, export.Write(&out, session, export.FormatHTML))
+
+	page := out.String()
+	assert.Contains(t, page, "<title>golang</title>")
+	assert.Contains(t, page, "<style>")
+	assert.Contains(t, page, `<article class="message assistant">`)
+	assert.Contains(t, page, "AI · 2024-05-01 10:30 · gpt-4o")
+	// The code is highlighted with the classes of the inlined style.
+	assert.Contains(t, page, `<pre class="chroma">`)
+	assert.Contains(t, page, ".chroma {")
+	assert.Contains(t, page, "&lt;hi&gt;")
+	assert.NotContains(t, page, "<script>")
+	assert.NotContains(t, page, "<link")
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, export.Write(&out, newSession(), export.FormatJSON))
+
+	var doc struct {
+		Name     string `json:"name"`
+		System   string `json:"system"`
+		Messages []struct {
+			Role  string     `json:"role"`
+			Model string     `json:"model"`
+			Time  time.Time  `json:"time"`
+			Usage *dto.Usage `json:"usage"`
+		} `json:"messages"`
+	}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+
+	assert.Equal(t, "golang", doc.Name)
+	assert.Equal(t, "Be brief", doc.System)
+	assert.Len(t, doc.Messages, 2)
+	assert.Equal(t, "assistant", doc.Messages[1].Role)
+	assert.Equal(t, "gpt-4o", doc.Messages[1].Model)
+	assert.Equal(t, 20, doc.Messages[1].Usage.CompletionTokens)
+	assert.True(t, doc.Messages[0].Time.Equal(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)))
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	assert.ErrorIs(t, export.Write(&bytes.Buffer{}, newSession(), "pdf"), export.ErrUnknownFormat)
+}
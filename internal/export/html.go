@@ -0,0 +1,171 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const codeStyle = "github"
+
+const pageCSS = `
+body { margin: 0; background: #f6f8fa; color: #1f2328; font: 16px/1.5 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; }
+main { max-width: 860px; margin: 0 auto; padding: 32px 16px; }
+h1 { margin: 0 0 8px; }
+.details { margin: 0 0 24px; padding: 0; list-style: none; color: #59636e; font-size: 14px; }
+.message { margin: 16px 0; padding: 12px 16px; border: 1px solid #d1d9e0; border-radius: 8px; background: #fff; }
+.message.user { background: #ddf4ff; }
+.message.system { background: #fff8c5; }
+.message header { margin-bottom: 8px; color: #59636e; font-size: 13px; font-weight: 600; }
+.message pre { overflow-x: auto; padding: 12px; border-radius: 6px; font-size: 13px; }
+.message code { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+.message table { border-collapse: collapse; }
+.message th, .message td { padding: 4px 8px; border: 1px solid #d1d9e0; }
+`
+
+var page = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<ul class="details">
+{{- range .Details}}
+<li><strong>{{index . 0}}:</strong> {{index . 1}}</li>
+{{- end}}
+</ul>
+{{- range .Messages}}
+<article class="message {{.Role}}">
+<header>{{.Heading}}</header>
+{{.Body}}
+</article>
+{{- end}}
+</main>
+</body>
+</html>
+`))
+
+type htmlMessage struct {
+	Role    dto.Role
+	Heading string
+	Body    template.HTML
+}
+
+// writeHTML writes a self-contained page with the messages rendered from Markdown,
+// the code blocks are highlighted with CSS classes defined in the page.
+func writeHTML(w io.Writer, session *dto.Session) error {
+	style := styles.Get(codeStyle)
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+
+	var css strings.Builder
+	css.WriteString(pageCSS)
+	if err := formatter.WriteCSS(&css, style); err != nil {
+		return fmt.Errorf("write code css: %w", err)
+	}
+
+	// The raw HTML of the messages is not rendered, the page is safe to open whatever the chat contains.
+	markdown := goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(
+			html.WithHardWraps(),
+			renderer.WithNodeRenderers(util.Prioritized(&codeRenderer{formatter: formatter, style: style}, 100)),
+		),
+	)
+
+	entries := entriesOf(session.Chat)
+	messages := make([]htmlMessage, 0, len(entries))
+
+	for _, e := range entries {
+		var body bytes.Buffer
+		if err := markdown.Convert([]byte(e.Content), &body); err != nil {
+			return fmt.Errorf("render message: %w", err)
+		}
+
+		messages = append(messages, htmlMessage{
+			Role:    e.Role,
+			Heading: heading(e),
+			Body:    template.HTML(body.String()), //nolint:gosec // goldmark escapes the content and drops raw HTML
+		})
+	}
+
+	err := page.Execute(w, struct {
+		Title    string
+		CSS      template.CSS
+		Details  [][2]string
+		Messages []htmlMessage
+	}{
+		Title:    titleOf(session),
+		CSS:      template.CSS(css.String()), //nolint:gosec // the CSS is built here
+		Details:  details(session),
+		Messages: messages,
+	})
+	if err != nil {
+		return fmt.Errorf("write html: %w", err)
+	}
+
+	return nil
+}
+
+// codeRenderer highlights the fenced code blocks by their language or, without one, by the guessed one.
+type codeRenderer struct {
+	formatter *chromahtml.Formatter
+	style     *chroma.Style
+}
+
+func (r *codeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.render)
+}
+
+func (r *codeRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	block := node.(*ast.FencedCodeBlock)
+
+	var code strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		line := lines.At(i)
+		code.Write(line.Value(source))
+	}
+
+	lexer := lexers.Get(string(block.Language(source)))
+	if lexer == nil {
+		lexer = lexers.Analyse(code.String())
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code.String())
+	if err == nil {
+		err = r.formatter.Format(w, r.style, iterator)
+	}
+	if err != nil {
+		// The code is still worth showing without the colors.
+		_, _ = fmt.Fprintf(w, "<pre><code>%s</code></pre>\n", template.HTMLEscapeString(code.String()))
+	}
+
+	return ast.WalkSkipChildren, nil
+}
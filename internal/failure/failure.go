@@ -0,0 +1,262 @@
+// Package failure classifies the errors shown to the user, every kind has a hint and an exit code
+// listed by Codes. The errors of the other packages get their kind with Register, so this package
+// depends on none of them.
+package failure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type Kind int
+
+const (
+	KindUnknown Kind = iota
+	KindUsage
+	KindAuth
+	KindQuota
+	KindRateLimit
+	KindModelNotFound
+	KindContextLength
+	KindContentFilter
+	KindNetwork
+	KindTimeout
+	KindCanceled
+)
+
+var kinds = map[Kind]struct {
+	name string
+	code int
+	// about tells what the exit code stands for.
+	about string
+	hint  string
+}{
+	KindUnknown:       {"error", 1, "other errors", ""},
+	KindUsage:         {"usage", 2, "invalid flags or arguments", "see --help for the flags and arguments"},
+	KindAuth:          {"auth", 3, "authentication, e.g. a missing or revoked API key", "run auth login or set OPENAI_API_KEY"},
+	KindQuota:         {"quota", 4, "exhausted quota, billing or the budget of the profile", "check the plan and billing of the API key"},
+	KindRateLimit:     {"rate limit", 5, "rate limit", "wait a moment and try again, batch --rpm and --tpm slow the requests down"},
+	KindModelNotFound: {"model not found", 6, "unknown model", "run models to list the available models"},
+	KindContextLength: {"context length", 7, "context length exceeded", "shorten the conversation with /undo or /reset, or pick a model with a larger context"},
+	KindContentFilter: {"content filter", 8, "content filter", "the request was refused by the content filter, rephrase it"},
+	KindNetwork:       {"network", 9, "network", "check the connection and OPENAI_BASE_URL"},
+	KindTimeout:       {"timeout", 10, "timeout", "the API is slow to answer, try again"},
+	KindCanceled:      {"canceled", 130, "canceled with Ctrl+C", ""},
+}
+
+type sentinel struct {
+	err  error
+	kind Kind
+	hint string
+}
+
+var (
+	// sentinels are the errors with a known kind, a hint replaces the one of the kind.
+	sentinels = []*sentinel{
+		{context.Canceled, KindCanceled, ""},
+		{context.DeadlineExceeded, KindTimeout, ""},
+	}
+	sentinelsMu sync.RWMutex
+)
+
+// Register gives the errors matching err the kind and the hint, an empty hint keeps the one of the kind.
+// It is meant for the sentinel errors of the app, e.g. in an init. The returned function removes it again,
+// e.g. at the end of a test.
+func Register(err error, kind Kind, hint string) func() {
+	s := &sentinel{err: err, kind: kind, hint: hint}
+
+	sentinelsMu.Lock()
+	defer sentinelsMu.Unlock()
+
+	sentinels = append(sentinels, s)
+
+	return func() {
+		sentinelsMu.Lock()
+		defer sentinelsMu.Unlock()
+
+		sentinels = slices.DeleteFunc(sentinels, func(other *sentinel) bool { return other == s })
+	}
+}
+
+// Codes lists the exit codes with what they stand for, one per line, e.g. for the help.
+func Codes() string {
+	list := make([]Kind, 0, len(kinds))
+	for kind := range kinds {
+		list = append(list, kind)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return kinds[list[i]].code < kinds[list[j]].code
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %-4d %s", 0, "success")
+	for _, kind := range list {
+		fmt.Fprintf(&b, "\n  %-4d %s", kinds[kind].code, kinds[kind].about)
+	}
+
+	return b.String()
+}
+
+func (k Kind) String() string {
+	return kinds[k].name
+}
+
+// ExitCode returns the exit code of the process failed with the kind of error.
+func (k Kind) ExitCode() int {
+	return kinds[k].code
+}
+
+// Error marks an error with its kind, e.g. for the errors found before sending a request.
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+// New marks the error with the kind.
+func New(kind Kind, err error) error {
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf classifies the error.
+func KindOf(err error) Kind {
+	kind, _ := classify(err)
+
+	return kind
+}
+
+// Hint suggests what the user can do about the error, it is empty if there is nothing to suggest.
+func Hint(err error) string {
+	_, hint := classify(err)
+
+	return hint
+}
+
+// ExitCode returns the exit code of the process failed with the error.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	return KindOf(err).ExitCode()
+}
+
+func classify(err error) (Kind, string) {
+	var marked *Error
+	if errors.As(err, &marked) {
+		return marked.Kind, kinds[marked.Kind].hint
+	}
+
+	if s := registered(err); s != nil {
+		if s.hint != "" {
+			return s.kind, s.hint
+		}
+		return s.kind, kinds[s.kind].hint
+	}
+
+	kind := classifyAPI(err)
+	if kind == KindUnknown {
+		kind = classifyNetwork(err)
+	}
+
+	return kind, kinds[kind].hint
+}
+
+// registered returns the sentinel the error matches, nil if there is none.
+func registered(err error) *sentinel {
+	sentinelsMu.RLock()
+	defer sentinelsMu.RUnlock()
+
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// classifyAPI classifies the errors returned by the API from the status and the error code.
+func classifyAPI(err error) Kind {
+	var (
+		status int
+		code   string
+	)
+
+	var apiErr *openai.APIError
+	var requestErr *openai.RequestError
+
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+		code, _ = apiErr.Code.(string)
+		if code == "" {
+			code = apiErr.Type
+		}
+		if apiErr.InnerError != nil && apiErr.InnerError.Code == "ResponsibleAIPolicyViolation" {
+			code = "content_filter"
+		}
+	case errors.As(err, &requestErr):
+		status = requestErr.HTTPStatusCode
+	default:
+		return KindUnknown
+	}
+
+	switch code {
+	case "insufficient_quota", "billing_hard_limit_reached", "billing_not_active":
+		return KindQuota
+	case "model_not_found":
+		return KindModelNotFound
+	case "context_length_exceeded", "string_above_max_length":
+		return KindContextLength
+	case "content_filter", "content_policy_violation":
+		return KindContentFilter
+	case "invalid_api_key", "invalid_authentication":
+		return KindAuth
+	}
+
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return KindAuth
+	case http.StatusPaymentRequired:
+		return KindQuota
+	case http.StatusTooManyRequests:
+		return KindRateLimit
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return KindTimeout
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return KindNetwork
+	}
+
+	return KindUnknown
+}
+
+// classifyNetwork classifies the errors of the connection, e.g. a refused connection or a failed DNS lookup.
+func classifyNetwork(err error) Kind {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return KindTimeout
+		}
+		return KindNetwork
+	}
+
+	return KindUnknown
+}
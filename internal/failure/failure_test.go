@@ -0,0 +1,153 @@
+package failure_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+)
+
+var (
+	errOverBudget   = errors.New("over budget")
+	errUnknownModel = errors.New("unknown model")
+)
+
+// register gives the errors of the tests their kinds until the end of the test.
+func register(t *testing.T) {
+	t.Cleanup(failure.Register(errOverBudget, failure.KindQuota, "see the usage command"))
+	t.Cleanup(failure.Register(errUnknownModel, failure.KindModelNotFound, ""))
+}
+
+func apiError(status int, code string) error {
+	err := &openai.APIError{HTTPStatusCode: status, Message: "failed"}
+	if code != "" {
+		err.Code = code
+	}
+
+	return fmt.Errorf("create chat completion: %w", err)
+}
+
+func TestKindOf(t *testing.T) {
+	register(t)
+
+	tests := []struct {
+		name string
+		err  error
+		kind failure.Kind
+	}{
+		{"plain error", errors.New("boom"), failure.KindUnknown},
+		{"marked", failure.New(failure.KindUsage, errors.New("bad flag")), failure.KindUsage},
+		{"wrapped mark", fmt.Errorf("run: %w", failure.New(failure.KindAuth, errors.New("no key"))), failure.KindAuth},
+		{"budget", fmt.Errorf("%w: daily limit", errOverBudget), failure.KindQuota},
+		{"unknown model", fmt.Errorf("%w: gpt-9", errUnknownModel), failure.KindModelNotFound},
+		{"canceled", fmt.Errorf("send: %w", context.Canceled), failure.KindCanceled},
+		{"deadline", context.DeadlineExceeded, failure.KindTimeout},
+		{"quota code", apiError(http.StatusTooManyRequests, "insufficient_quota"), failure.KindQuota},
+		{"rate limit", apiError(http.StatusTooManyRequests, "rate_limit_exceeded"), failure.KindRateLimit},
+		{"invalid key", apiError(http.StatusUnauthorized, "invalid_api_key"), failure.KindAuth},
+		{"forbidden", apiError(http.StatusForbidden, ""), failure.KindAuth},
+		{"model code", apiError(http.StatusNotFound, "model_not_found"), failure.KindModelNotFound},
+		{"bare not found", apiError(http.StatusNotFound, ""), failure.KindUnknown},
+		{"context length", apiError(http.StatusBadRequest, "context_length_exceeded"), failure.KindContextLength},
+		{"content filter", apiError(http.StatusBadRequest, "content_filter"), failure.KindContentFilter},
+		{"bad gateway", apiError(http.StatusBadGateway, ""), failure.KindNetwork},
+		{"gateway timeout", apiError(http.StatusGatewayTimeout, ""), failure.KindTimeout},
+		{"request error", &openai.RequestError{HTTPStatusCode: http.StatusServiceUnavailable, Err: errors.New("down")}, failure.KindNetwork},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, failure.KindNetwork},
+		{"dns", &net.DNSError{Err: "no such host", Name: "api.openai.com"}, failure.KindNetwork},
+		{"net timeout", &net.DNSError{Err: "timeout", Name: "api.openai.com", IsTimeout: true}, failure.KindTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.kind, failure.KindOf(tt.err))
+		})
+	}
+}
+
+func TestKindOf_AzureContentFilter(t *testing.T) {
+	err := &openai.APIError{
+		HTTPStatusCode: http.StatusBadRequest,
+		InnerError:     &openai.InnerError{Code: "ResponsibleAIPolicyViolation"},
+	}
+
+	assert.Equal(t, failure.KindContentFilter, failure.KindOf(err))
+}
+
+func TestHint(t *testing.T) {
+	register(t)
+
+	assert.Equal(t, "", failure.Hint(errors.New("boom")))
+	assert.Equal(t, "", failure.Hint(context.Canceled))
+	assert.Equal(t, "run auth login or set OPENAI_API_KEY", failure.Hint(apiError(http.StatusUnauthorized, "")))
+
+	// A registered error has its own hint rather than the one of the kind.
+	assert.Contains(t, failure.Hint(errOverBudget), "usage")
+	assert.Contains(t, failure.Hint(errUnknownModel), "run models")
+	assert.Contains(t, failure.Hint(apiError(http.StatusTooManyRequests, "insufficient_quota")), "billing")
+}
+
+func TestExitCode(t *testing.T) {
+	register(t)
+
+	assert.Equal(t, 0, failure.ExitCode(nil))
+	assert.Equal(t, 1, failure.ExitCode(errors.New("boom")))
+	assert.Equal(t, 2, failure.ExitCode(failure.New(failure.KindUsage, errors.New("bad flag"))))
+	assert.Equal(t, 3, failure.ExitCode(apiError(http.StatusUnauthorized, "")))
+	assert.Equal(t, 4, failure.ExitCode(errOverBudget))
+	assert.Equal(t, 5, failure.ExitCode(apiError(http.StatusTooManyRequests, "")))
+	assert.Equal(t, 6, failure.ExitCode(errUnknownModel))
+	assert.Equal(t, 7, failure.ExitCode(apiError(http.StatusBadRequest, "context_length_exceeded")))
+	assert.Equal(t, 8, failure.ExitCode(apiError(http.StatusBadRequest, "content_policy_violation")))
+	assert.Equal(t, 9, failure.ExitCode(&net.OpError{Op: "dial", Err: errors.New("refused")}))
+	assert.Equal(t, 10, failure.ExitCode(context.DeadlineExceeded))
+	assert.Equal(t, 130, failure.ExitCode(context.Canceled))
+}
+
+func TestRegister(t *testing.T) {
+	errLocked := errors.New("locked")
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			failure.KindOf(errLocked)
+		}()
+	}
+
+	unregister := failure.Register(errLocked, failure.KindAuth, "")
+	wg.Wait()
+	assert.Equal(t, failure.KindAuth, failure.KindOf(errLocked))
+
+	unregister()
+	assert.Equal(t, failure.KindUnknown, failure.KindOf(errLocked))
+	assert.Equal(t, failure.KindUnknown, failure.KindOf(errOverBudget))
+}
+
+func TestCodes(t *testing.T) {
+	lines := strings.Split(failure.Codes(), "\n")
+
+	assert.Len(t, lines, 12)
+	assert.Equal(t, "  0    success", lines[0])
+	assert.Equal(t, "  4    exhausted quota, billing or the budget of the profile", lines[4])
+	assert.Equal(t, "  130  canceled with Ctrl+C", lines[11])
+}
+
+func TestError(t *testing.T) {
+	inner := errors.New("no key")
+	err := failure.New(failure.KindAuth, inner)
+
+	assert.Equal(t, "no key", err.Error())
+	assert.ErrorIs(t, err, inner)
+	assert.Equal(t, "auth", failure.KindAuth.String())
+}
@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	recipientAll    = "all"
+	placeholderFile = "[file]"
+)
+
+// chatgptConversation is a conversation of conversations.json, its messages are a tree of nodes
+// where current_node is the last message of the branch shown in the web app.
+type chatgptConversation struct {
+	ID               string                 `json:"id"`
+	ConversationID   string                 `json:"conversation_id"`
+	Title            string                 `json:"title"`
+	CreateTime       float64                `json:"create_time"`
+	UpdateTime       float64                `json:"update_time"`
+	Mapping          map[string]chatgptNode `json:"mapping"`
+	CurrentNode      string                 `json:"current_node"`
+	DefaultModelSlug string                 `json:"default_model_slug"`
+}
+
+type chatgptNode struct {
+	ID       string          `json:"id"`
+	Message  *chatgptMessage `json:"message"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatgptMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+	} `json:"content"`
+	Recipient string `json:"recipient"`
+	Metadata  struct {
+		ModelSlug string `json:"model_slug"`
+		Hidden    bool   `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+// ParseChatGPT reads conversations.json of a ChatGPT data export. The tool calls, their results
+// and the hidden messages are skipped, the images and the files are replaced with a placeholder.
+func ParseChatGPT(r io.Reader, opts Options) ([]Conversation, error) {
+	var in []chatgptConversation
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", conversationsFile, err)
+	}
+
+	conversations := make([]Conversation, 0, len(in))
+	for _, c := range in {
+		chat := toChat(c, opts)
+		if len(chat.Nodes) == 0 {
+			continue
+		}
+
+		id := c.ConversationID
+		if id == "" {
+			id = c.ID
+		}
+
+		conversations = append(conversations, Conversation{
+			Title: c.Title,
+			Session: &dto.Session{
+				Model:     c.DefaultModelSlug,
+				CreatedAt: toTime(c.CreateTime),
+				UpdatedAt: toTime(c.UpdateTime),
+				Source:    "chatgpt:" + id,
+				Title:     c.Title,
+				Chat:      chat,
+			},
+		})
+	}
+
+	if len(conversations) == 0 {
+		return nil, ErrNoConversations
+	}
+
+	return conversations, nil
+}
+
+// toChat converts the node tree to a chat tree. The skipped nodes are left out with their children
+// attached to the closest kept ancestor, so the branches stay as they were.
+func toChat(c chatgptConversation, opts Options) *dto.Chat {
+	chat := dto.NewChat()
+
+	// ids maps the node IDs of the export to the IDs of the chat nodes, or of their closest kept ancestor.
+	ids := make(map[string]int, len(c.Mapping))
+
+	add := func(node chatgptNode, parentID int) int {
+		message, ok := toMessage(node.Message, chat)
+		if !ok {
+			ids[node.ID] = parentID
+			return parentID
+		}
+
+		chat.Nodes = append(chat.Nodes, dto.Node{
+			ID:       len(chat.Nodes) + 1,
+			ParentID: parentID,
+			Time:     toTime(node.Message.CreateTime),
+			Message:  message,
+		})
+		ids[node.ID] = len(chat.Nodes)
+
+		return len(chat.Nodes)
+	}
+
+	if opts.Flatten {
+		parentID := 0
+		for _, node := range activePath(c) {
+			parentID = add(node, parentID)
+		}
+		chat.Head = parentID
+
+		return chat
+	}
+
+	// seen stops the walk at a node met before, a broken export may link the nodes in a cycle.
+	seen := make(map[string]bool, len(c.Mapping))
+
+	var walk func(id string, parentID int)
+	walk = func(id string, parentID int) {
+		node, ok := c.Mapping[id]
+		if !ok || seen[id] {
+			return
+		}
+		seen[id] = true
+
+		parentID = add(node, parentID)
+		for _, child := range node.Children {
+			walk(child, parentID)
+		}
+	}
+
+	for _, root := range roots(c) {
+		walk(root, 0)
+	}
+
+	chat.Head = len(chat.Nodes)
+	if head, ok := ids[c.CurrentNode]; ok && head > 0 {
+		chat.Head = head
+	}
+
+	return chat
+}
+
+// roots returns the IDs of the nodes without a parent in a stable order.
+func roots(c chatgptConversation) []string {
+	var ids []string
+	for id, node := range c.Mapping {
+		if _, ok := c.Mapping[node.Parent]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// activePath returns the nodes from the root to the current one.
+func activePath(c chatgptConversation) []chatgptNode {
+	var path []chatgptNode
+
+	seen := make(map[string]bool)
+	for id := c.CurrentNode; !seen[id]; {
+		node, ok := c.Mapping[id]
+		if !ok {
+			break
+		}
+
+		seen[id] = true
+		path = append(path, node)
+		id = node.Parent
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return path
+}
+
+// toMessage converts a visible text message, the first system prompt becomes the one of the chat.
+func toMessage(in *chatgptMessage, chat *dto.Chat) (dto.Message, bool) {
+	if in == nil || in.Metadata.Hidden || in.Recipient != "" && in.Recipient != recipientAll {
+		return dto.Message{}, false
+	}
+
+	switch in.Content.ContentType {
+	case "text", "multimodal_text":
+	default:
+		return dto.Message{}, false
+	}
+
+	content := strings.TrimSpace(joinParts(in.Content.Parts))
+	if content == "" {
+		return dto.Message{}, false
+	}
+
+	switch role := dto.Role(in.Author.Role); role {
+	case dto.RoleUser:
+		return dto.Message{Role: role, Content: content}, true
+	case dto.RoleAssistant:
+		return dto.Message{Role: role, Content: content, Model: in.Metadata.ModelSlug}, true
+	case dto.RoleSystem:
+		if chat.System == "" {
+			chat.System = content
+		}
+		return dto.Message{}, false
+	default:
+		return dto.Message{}, false
+	}
+}
+
+// joinParts joins the text parts of the content, the other parts are images or files.
+func joinParts(parts []json.RawMessage) string {
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		var text string
+		if err := json.Unmarshal(part, &text); err != nil {
+			texts = append(texts, placeholderFile)
+			continue
+		}
+		texts = append(texts, text)
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+// toTime converts the seconds since the epoch with a fraction.
+func toTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+
+	whole, frac := math.Modf(seconds)
+
+	return time.Unix(int64(whole), int64(frac*1e9)).Round(time.Millisecond)
+}
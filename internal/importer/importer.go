@@ -0,0 +1,125 @@
+// Package importer reads conversations kept elsewhere, the data exports of the ChatGPT web app
+// and Markdown transcripts, into sessions that can be continued in the chat.
+package importer
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	conversationsFile = "conversations.json"
+	maxNameLength     = 48
+	defaultName       = "imported"
+)
+
+var (
+	ErrUnknownFormat   = errors.New("unknown import format")
+	ErrNoConversations = errors.New("no conversations found")
+
+	notNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Conversation is an imported session without a name yet, with the title it had.
+type Conversation struct {
+	Title   string
+	Session *dto.Session
+}
+
+// Options control how the conversations are read.
+type Options struct {
+	// Flatten keeps only the branch that was active, by default all the branches are kept.
+	Flatten bool
+}
+
+// ReadFile reads the conversations of a ChatGPT data export, as the zip archive or its conversations.json,
+// or of a Markdown transcript.
+func ReadFile(name string, opts Options) ([]Conversation, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".zip":
+		return readArchive(name, opts)
+	case ".json":
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("open export: %w", err)
+		}
+		defer f.Close()
+
+		return ParseChatGPT(f, opts)
+	case ".md", ".markdown":
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("open transcript: %w", err)
+		}
+		defer f.Close()
+
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			abs = name
+		}
+
+		conversation, err := ParseMarkdown(f, strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)))
+		if err != nil {
+			return nil, err
+		}
+		conversation.Session.Source = "file:" + abs
+
+		// The transcript has no times of its own, the next import replaces it only after it is edited.
+		if info, err := f.Stat(); err == nil {
+			conversation.Session.CreatedAt = info.ModTime()
+			conversation.Session.UpdatedAt = info.ModTime()
+		}
+
+		return []Conversation{conversation}, nil
+	default:
+		return nil, fmt.Errorf("%w of %s, use a ChatGPT export (.zip or conversations.json) or a Markdown transcript (.md)",
+			ErrUnknownFormat, name)
+	}
+}
+
+// readArchive reads conversations.json from the zip archive of a ChatGPT data export.
+func readArchive(name string, opts Options) ([]Conversation, error) {
+	archive, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		if path.Base(f.Name) != conversationsFile {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", f.Name, err)
+		}
+		defer r.Close()
+
+		return ParseChatGPT(r, opts)
+	}
+
+	return nil, fmt.Errorf("%w: the archive has no %s", ErrNoConversations, conversationsFile)
+}
+
+// Name returns a session name made of the title, e.g. "Plan a trip!" gives plan-a-trip.
+func Name(title string) string {
+	name := strings.Trim(notNameChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(name) > maxNameLength {
+		name = strings.TrimRight(name[:maxNameLength], "-")
+	}
+
+	if name == "" {
+		return defaultName
+	}
+
+	return name
+}
@@ -0,0 +1,220 @@
+package importer_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/export"
+	"github.com/andrian0vv/chatgpt-cli/internal/importer"
+)
+
+// conversations is a conversation of a ChatGPT data export where the second question was edited,
+// with the hidden system message, a search tool call and an image the export keeps.
+const conversations = `[{
+  "title": "Trip to Lisbon",
+  "create_time": 1714557000.5,
+  "update_time": 1714557600.25,
+  "conversation_id": "c-1",
+  "current_node": "a3",
+  "default_model_slug": "gpt-4o",
+  "mapping": {
+    "root": {"id": "root", "message": null, "parent": null, "children": ["sys"]},
+    "sys": {"id": "sys", "parent": "root", "children": ["q1"], "message": {
+      "author": {"role": "system"}, "content": {"content_type": "text", "parts": [""]},
+      "metadata": {"is_visually_hidden_from_conversation": true}, "recipient": "all"}},
+    "q1": {"id": "q1", "parent": "sys", "children": ["a1"], "message": {
+      "author": {"role": "user"}, "create_time": 1714557001, "recipient": "all",
+      "content": {"content_type": "multimodal_text", "parts": [{"content_type": "image_asset_pointer"}, "Where is it?"]}}},
+    "a1": {"id": "a1", "parent": "q1", "children": ["q2", "q3"], "message": {
+      "author": {"role": "assistant"}, "create_time": 1714557002, "recipient": "all",
+      "content": {"content_type": "text", "parts": ["In Portugal."]}, "metadata": {"model_slug": "gpt-4o"}}},
+    "q2": {"id": "q2", "parent": "a1", "children": ["a2"], "message": {
+      "author": {"role": "user"}, "create_time": 1714557003, "recipient": "all",
+      "content": {"content_type": "text", "parts": ["Weather?"]}}},
+    "a2": {"id": "a2", "parent": "q2", "children": [], "message": {
+      "author": {"role": "assistant"}, "create_time": 1714557004, "recipient": "all",
+      "content": {"content_type": "text", "parts": ["Sunny."]}, "metadata": {"model_slug": "gpt-4o"}}},
+    "q3": {"id": "q3", "parent": "a1", "children": ["call"], "message": {
+      "author": {"role": "user"}, "create_time": 1714557005, "recipient": "all",
+      "content": {"content_type": "text", "parts": ["Weather today?"]}}},
+    "call": {"id": "call", "parent": "q3", "children": ["result"], "message": {
+      "author": {"role": "assistant"}, "recipient": "browser",
+      "content": {"content_type": "code", "text": "search('weather lisbon')"}}},
+    "result": {"id": "result", "parent": "call", "children": ["a3"], "message": {
+      "author": {"role": "tool"}, "recipient": "all",
+      "content": {"content_type": "tether_browsing_display", "result": "..."}}},
+    "a3": {"id": "a3", "parent": "result", "children": [], "message": {
+      "author": {"role": "assistant"}, "create_time": 1714557006, "recipient": "all",
+      "content": {"content_type": "text", "parts": ["Rainy."]}, "metadata": {"model_slug": "gpt-4o-mini"}}}
+  }
+}]`
+
+func TestParseChatGPT(t *testing.T) {
+	out, err := importer.ParseChatGPT(strings.NewReader(conversations), importer.Options{})
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+
+	assert.Equal(t, "Trip to Lisbon", out[0].Title)
+
+	session := out[0].Session
+	assert.Equal(t, "gpt-4o", session.Model)
+	assert.Equal(t, "chatgpt:c-1", session.Source)
+	assert.Equal(t, time.Unix(1714557600, 250e6), session.UpdatedAt)
+
+	chat := session.Chat
+	assert.Len(t, chat.Nodes, 6)
+	assert.Empty(t, chat.System)
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "[file]\nWhere is it?"},
+		{Role: dto.RoleAssistant, Content: "In Portugal.", Model: "gpt-4o"},
+		{Role: dto.RoleUser, Content: "Weather today?"},
+		{Role: dto.RoleAssistant, Content: "Rainy.", Model: "gpt-4o-mini"},
+	}, chat.Messages())
+	assert.Equal(t, time.Unix(1714557006, 0), chat.Path()[3].Time)
+
+	// The edited question stays in its branch.
+	assert.Len(t, chat.Leaves(), 2)
+	assert.Equal(t, "Sunny.", chat.Leaves()[0].Content)
+}
+
+func TestParseChatGPT_Flatten(t *testing.T) {
+	out, err := importer.ParseChatGPT(strings.NewReader(conversations), importer.Options{Flatten: true})
+	assert.NoError(t, err)
+
+	chat := out[0].Session.Chat
+	assert.Len(t, chat.Nodes, 4)
+	assert.Equal(t, 4, chat.Head)
+	assert.Equal(t, "Rainy.", chat.Messages()[3].Content)
+}
+
+func TestParseChatGPT_Cycle(t *testing.T) {
+	const cycle = `[{"title": "Loop", "conversation_id": "c-2", "current_node": "a", "mapping": {
+  "root": {"id": "root", "message": null, "parent": null, "children": ["q"]},
+  "q": {"id": "q", "parent": "root", "children": ["a"], "message": {
+    "author": {"role": "user"}, "recipient": "all", "content": {"content_type": "text", "parts": ["Hi"]}}},
+  "a": {"id": "a", "parent": "q", "children": ["q"], "message": {
+    "author": {"role": "assistant"}, "recipient": "all", "content": {"content_type": "text", "parts": ["Hello"]}}}
+}}]`
+
+	for _, flatten := range []bool{false, true} {
+		out, err := importer.ParseChatGPT(strings.NewReader(cycle), importer.Options{Flatten: flatten})
+		assert.NoError(t, err)
+		assert.Len(t, out[0].Session.Chat.Nodes, 2)
+	}
+}
+
+func TestParseChatGPT_Empty(t *testing.T) {
+	_, err := importer.ParseChatGPT(strings.NewReader(`[{"title": "New chat", "mapping": {}}]`), importer.Options{})
+	assert.ErrorIs(t, err, importer.ErrNoConversations)
+
+	_, err = importer.ParseChatGPT(strings.NewReader(`{}`), importer.Options{})
+	assert.Error(t, err)
+}
+
+func TestParseMarkdown(t *testing.T) {
+	transcript := `# Go questions
+
+Exported from somewhere.
+
+**System**
+Be brief.
+
+**User:** How to print?
+
+**Assistant**
+Like this:
+
+` + "```md\n**User**\nfmt.Println()\n```" + `
+
+**User**
+Thanks!
+`
+
+	out, err := importer.ParseMarkdown(strings.NewReader(transcript), "file")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Go questions", out.Title)
+	assert.Equal(t, "Be brief.", out.Session.Chat.System)
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "How to print?"},
+		{Role: dto.RoleAssistant, Content: "Like this:\n\n```md\n**User**\nfmt.Println()\n```"},
+		{Role: dto.RoleUser, Content: "Thanks!"},
+	}, out.Session.Chat.Messages())
+
+	_, err = importer.ParseMarkdown(strings.NewReader("# Notes\n\nNothing here."), "notes")
+	assert.ErrorIs(t, err, importer.ErrNoMessages)
+}
+
+func TestParseMarkdown_Export(t *testing.T) {
+	at := time.Date(2024, 5, 1, 10, 30, 0, 0, time.Local)
+
+	chat := dto.NewChat()
+	chat.System = "Be brief"
+	chat.Examples = []dto.Message{{Role: dto.RoleUser, Content: "Hi"}, {Role: dto.RoleAssistant, Content: "Hello"}}
+	chat.AddMessage(dto.RoleUser, "How to print in Go?")
+	chat.Add(dto.Message{Role: dto.RoleAssistant, Content: "Use `fmt`.", Model: "gpt-4o"})
+	for i := range chat.Nodes {
+		chat.Nodes[i].Time = at
+	}
+
+	var b bytes.Buffer
+	assert.NoError(t, export.Write(&b, &dto.Session{Name: "golang", Model: "gpt-4o", Chat: chat}, export.FormatMarkdown))
+
+	out, err := importer.ParseMarkdown(&b, "file")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "golang", out.Title)
+	assert.Equal(t, chat.System, out.Session.Chat.System)
+	assert.Equal(t, chat.Examples, out.Session.Chat.Examples)
+	assert.Equal(t, chat.Messages(), out.Session.Chat.Messages())
+	assert.True(t, at.Equal(out.Session.Chat.Nodes[1].Time))
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	archive := filepath.Join(dir, "export.zip")
+	f, err := os.Create(archive)
+	assert.NoError(t, err)
+
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("chat.html")
+	assert.NoError(t, err)
+	_, _ = w.Write([]byte("<html></html>"))
+	w, err = zw.Create("conversations.json")
+	assert.NoError(t, err)
+	_, _ = w.Write([]byte(conversations))
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, f.Close())
+
+	out, err := importer.ReadFile(archive, importer.Options{})
+	assert.NoError(t, err)
+	assert.Len(t, out, 1)
+
+	transcript := filepath.Join(dir, "notes.md")
+	assert.NoError(t, os.WriteFile(transcript, []byte("**User**\nHi\n\n**Assistant**\nHello"), 0o600))
+
+	out, err = importer.ReadFile(transcript, importer.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, "notes", out[0].Title)
+	assert.Equal(t, "file:"+transcript, out[0].Session.Source)
+	assert.False(t, out[0].Session.UpdatedAt.IsZero())
+
+	_, err = importer.ReadFile(filepath.Join(dir, "chat.pdf"), importer.Options{})
+	assert.ErrorIs(t, err, importer.ErrUnknownFormat)
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "plan-a-trip-to-lisbon", importer.Name("Plan a trip to Lisbon!"))
+	assert.Equal(t, "c-basics", importer.Name("  C++ basics"))
+	assert.Equal(t, "imported", importer.Name("Привет"))
+	assert.Len(t, importer.Name(strings.Repeat("long title ", 10)), 48)
+}
@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+// exportTimeLayout is the time in the headings of the Markdown written by the export package.
+const exportTimeLayout = "2006-01-02 15:04"
+
+var (
+	ErrNoMessages = errors.New("no messages found, start them with a **User** or **Assistant** line")
+
+	// boldLine is a line like **User**, **User:** or **Assistant**: followed by the message or nothing.
+	boldLine = regexp.MustCompile(`(?i)^\*\*(user|you|assistant|chatgpt|ai|system)(?::\*\*|\*\*:?)\s*(.*)$`)
+	// headingLine is a line like ## User, ## You (example) or ## AI · 2024-05-01 10:30 · gpt-4o as written by the export.
+	headingLine = regexp.MustCompile(`(?i)^#{1,6}\s+(user|you|assistant|chatgpt|ai|system)\s*(\(example\))?\s*((?:·.*)?)$`)
+	titleLine   = regexp.MustCompile(`^#\s+(.+)$`)
+	fenceLine   = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// header is the start of a message in a transcript.
+type header struct {
+	role    dto.Role
+	time    time.Time
+	model   string
+	example bool
+}
+
+// ParseMarkdown reads a transcript where every message starts with a line naming its author,
+// e.g. **User** or **Assistant**. The text before the first message is skipped except a # title,
+// the name is the title when there is none.
+func ParseMarkdown(r io.Reader, name string) (Conversation, error) {
+	chat := dto.NewChat()
+	conversation := Conversation{Title: name}
+
+	var (
+		current *header
+		lines   []string
+		system  []string
+		inFence bool
+		// title is only the one of the transcript, the file name does not describe the chat.
+		title string
+	)
+
+	flush := func() {
+		content := strings.TrimSpace(strings.Join(lines, "\n"))
+
+		switch {
+		case current == nil || content == "":
+		case current.role == dto.RoleSystem:
+			system = append(system, content)
+		case current.example:
+			chat.Examples = append(chat.Examples, dto.Message{Role: current.role, Content: content})
+		default:
+			chat.Add(dto.Message{Role: current.role, Content: content, Model: current.model})
+			chat.Nodes[len(chat.Nodes)-1].Time = current.time
+		}
+
+		current, lines = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if fenceLine.MatchString(line) {
+			inFence = !inFence
+		}
+
+		if !inFence {
+			if h, rest, ok := parseHeader(line); ok {
+				flush()
+				current, lines = &h, []string{rest}
+				continue
+			}
+
+			if m := titleLine.FindStringSubmatch(line); m != nil && current == nil && len(chat.Nodes) == 0 {
+				conversation.Title = strings.TrimSpace(m[1])
+				title = conversation.Title
+				continue
+			}
+		}
+
+		if current != nil {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Conversation{}, fmt.Errorf("read transcript: %w", err)
+	}
+
+	flush()
+
+	if len(chat.Nodes) == 0 {
+		return Conversation{}, ErrNoMessages
+	}
+
+	chat.System = strings.Join(system, "\n\n")
+	conversation.Session = &dto.Session{Title: title, Chat: chat}
+
+	return conversation, nil
+}
+
+// parseHeader returns the message started by the line and the text following the author on the same line.
+func parseHeader(line string) (header, string, bool) {
+	if m := boldLine.FindStringSubmatch(line); m != nil {
+		return header{role: roleOf(m[1])}, m[2], true
+	}
+
+	m := headingLine.FindStringSubmatch(line)
+	if m == nil {
+		return header{}, "", false
+	}
+
+	h := header{role: roleOf(m[1]), example: m[2] != ""}
+
+	// The details written by the export: the time and, for an answer, the model.
+	for _, detail := range strings.Split(m[3], "·") {
+		detail = strings.TrimSpace(detail)
+		if detail == "" {
+			continue
+		}
+
+		if t, err := time.ParseInLocation(exportTimeLayout, detail, time.Local); err == nil {
+			h.time = t
+		} else if h.role == dto.RoleAssistant {
+			h.model = detail
+		}
+	}
+
+	return h, "", true
+}
+
+func roleOf(author string) dto.Role {
+	switch strings.ToLower(author) {
+	case "user", "you":
+		return dto.RoleUser
+	case "system":
+		return dto.RoleSystem
+	default:
+		return dto.RoleAssistant
+	}
+}
@@ -1,20 +1,35 @@
 package logger
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"io"
 	"log/slog"
-	"time"
+	"sync"
 
 	"github.com/fatih/color"
 )
 
+// ColoredHandler colors the lines of another handler by the level of the records.
 type ColoredHandler struct {
 	handler slog.Handler
+	out     io.Writer
+
+	// The handlers derived with WithAttrs and WithGroup write to the same buffer.
+	mu  *sync.Mutex
+	buf *bytes.Buffer
 }
 
-func NewColoredHandler(handler slog.Handler) *ColoredHandler {
-	return &ColoredHandler{handler: handler}
+// NewColoredHandler creates the handler, newHandler creates the handler that formats the records into w.
+func NewColoredHandler(out io.Writer, newHandler func(w io.Writer) slog.Handler) *ColoredHandler {
+	buf := &bytes.Buffer{}
+
+	return &ColoredHandler{
+		handler: newHandler(buf),
+		out:     out,
+		mu:      &sync.Mutex{},
+		buf:     buf,
+	}
 }
 
 func (h *ColoredHandler) Enabled(ctx context.Context, level slog.Level) bool {
@@ -22,45 +37,45 @@ func (h *ColoredHandler) Enabled(ctx context.Context, level slog.Level) bool {
 }
 
 func (h *ColoredHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return h.handler.WithAttrs(attrs)
+	return h.with(h.handler.WithAttrs(attrs))
 }
 
 func (h *ColoredHandler) WithGroup(name string) slog.Handler {
-	return h.handler.WithGroup(name)
+	return h.with(h.handler.WithGroup(name))
 }
 
-func (h *ColoredHandler) Handle(_ context.Context, record slog.Record) error {
-	var outputColor *color.Color
-	switch record.Level {
-	case slog.LevelDebug:
-		outputColor = color.New(color.FgHiBlack)
-	case slog.LevelInfo:
-		outputColor = color.New(color.FgHiCyan)
-	case slog.LevelWarn:
-		outputColor = color.New(color.FgHiYellow)
-	case slog.LevelError:
-		outputColor = color.New(color.FgHiRed)
-	default:
-		outputColor = color.New(color.FgHiWhite)
-	}
-
-	logData := map[string]interface{}{
-		"time":    time.Now().Format(time.RFC3339),
-		"level":   record.Level.String(),
-		"message": record.Message,
-	}
-
-	record.Attrs(func(attr slog.Attr) bool {
-		logData[attr.Key] = attr.Value.Any()
-		return true
-	})
+func (h *ColoredHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	jsonLog, err := json.Marshal(logData)
-	if err != nil {
+	h.buf.Reset()
+	if err := h.handler.Handle(ctx, record); err != nil {
 		return err
 	}
 
-	_, err = outputColor.Println(string(jsonLog))
+	_, err := levelColor(record.Level).Fprint(h.out, h.buf.String())
 
 	return err
 }
+
+func (h *ColoredHandler) with(handler slog.Handler) *ColoredHandler {
+	return &ColoredHandler{
+		handler: handler,
+		out:     h.out,
+		mu:      h.mu,
+		buf:     h.buf,
+	}
+}
+
+func levelColor(level slog.Level) *color.Color {
+	switch {
+	case level >= slog.LevelError:
+		return color.New(color.FgHiRed)
+	case level >= slog.LevelWarn:
+		return color.New(color.FgHiYellow)
+	case level >= slog.LevelInfo:
+		return color.New(color.FgHiCyan)
+	default:
+		return color.New(color.FgHiBlack)
+	}
+}
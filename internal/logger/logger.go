@@ -1,38 +1,90 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"log/slog"
+	"strings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
 )
 
 type Logger struct {
 	logger  *slog.Logger
 	enabled bool
+	level   slog.Level
+	format  string
+	color   bool
+	prompts bool
 }
 
+// New creates the logger of debug records in text, the options change the level and the format.
 func New(w io.Writer, opts ...Option) *Logger {
-	l := slog.New(
-		NewColoredHandler(
-			slog.NewTextHandler(
-				w,
-				&slog.HandlerOptions{
-					Level: slog.LevelDebug,
-				},
-			),
-		),
-	)
-
 	log := &Logger{
-		logger: l,
+		level:  slog.LevelDebug,
+		format: FormatText,
 	}
 
 	for _, opt := range opts {
 		opt(log)
 	}
 
+	if w == nil {
+		w = io.Discard
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: log.level}
+	newHandler := func(w io.Writer) slog.Handler {
+		if log.format == FormatJSON {
+			return slog.NewJSONHandler(w, handlerOpts)
+		}
+		return slog.NewTextHandler(w, handlerOpts)
+	}
+
+	if log.color {
+		log.logger = slog.New(NewColoredHandler(w, newHandler))
+	} else {
+		log.logger = slog.New(newHandler(w))
+	}
+
 	return log
 }
 
+// ParseLevel returns the level of the name, e.g. debug or warn.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q, use debug, info, warn or error", name)
+	}
+
+	return level, nil
+}
+
+// ParseFormat checks the name of the format, an empty one is text.
+func ParseFormat(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q, use %s or %s", name, FormatText, FormatJSON)
+	}
+}
+
+// Enabled tells whether the records are written at all.
+func (l *Logger) Enabled() bool {
+	return l.enabled
+}
+
+// Prompts tells whether the whole requests and answers may be logged.
+func (l *Logger) Prompts() bool {
+	return l.enabled && l.prompts
+}
+
 func (l *Logger) Debug(message string, f ...Field) {
 	if l.enabled {
 		l.logger.Debug(message, fields(f).args()...)
@@ -0,0 +1,102 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	log := logger.New(&buf, logger.WithEnabled(true), logger.WithLevel(slog.LevelInfo), logger.WithFormat(logger.FormatJSON))
+	log.Debug("dropped")
+	log.Info("request", logger.WithField("status", 200))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "request", record["msg"])
+	assert.Equal(t, float64(200), record["status"])
+
+	buf.Reset()
+	logger.New(&buf).Error("disabled")
+	assert.Empty(t, buf.String())
+}
+
+func TestColoredHandler(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	var buf bytes.Buffer
+
+	handler := logger.NewColoredHandler(&buf, func(w io.Writer) slog.Handler {
+		return slog.NewTextHandler(w, &slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+	})
+
+	log := slog.New(handler).With("model", "gpt-4o").WithGroup("usage")
+	log.Warn("tokens", "prompt", 10)
+
+	line := buf.String()
+	assert.True(t, strings.HasPrefix(line, "\x1b[93m"), line)
+	assert.Contains(t, line, "level=WARN msg=tokens model=gpt-4o usage.prompt=10")
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := logger.ParseLevel("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = logger.ParseLevel("verbose")
+	assert.Error(t, err)
+
+	format, err := logger.ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, logger.FormatText, format)
+
+	_, err = logger.ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "cli.log")
+
+	f, err := logger.OpenFile(path, 10, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.Close())
+
+	read := func(path string) string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+}
@@ -1,5 +1,7 @@
 package logger
 
+import "log/slog"
+
 type Option func(*Logger)
 
 func WithEnabled(enabled bool) Option {
@@ -7,3 +9,31 @@ func WithEnabled(enabled bool) Option {
 		l.enabled = enabled
 	}
 }
+
+// WithLevel drops the records below the level, debug by default.
+func WithLevel(level slog.Level) Option {
+	return func(l *Logger) {
+		l.level = level
+	}
+}
+
+// WithFormat writes the records in text or json.
+func WithFormat(format string) Option {
+	return func(l *Logger) {
+		l.format = format
+	}
+}
+
+// WithColor colors the records by their level, e.g. on a terminal.
+func WithColor(color bool) Option {
+	return func(l *Logger) {
+		l.color = color
+	}
+}
+
+// WithPrompts logs the whole requests and answers, they may hold secrets.
+func WithPrompts(prompts bool) Option {
+	return func(l *Logger) {
+		l.prompts = prompts
+	}
+}
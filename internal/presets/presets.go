@@ -0,0 +1,233 @@
+// Package presets loads the named bundles of a system prompt, examples, model and parameters.
+package presets
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	dirName    = "presets"
+	projectDir = ".chatgpt-cli"
+)
+
+var (
+	ErrNotFound    = errors.New("preset not found")
+	ErrInvalidName = errors.New("invalid preset name")
+
+	validName  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	extensions = []string{".yaml", ".yml"}
+)
+
+// Preset is read from a YAML file named after it:
+//
+//	description: Answers with SQL only
+//	model: gpt-4o
+//	system: You are an expert in PostgreSQL. Answer with a single query.
+//	examples:
+//	  - user: Count the users
+//	    assistant: SELECT count(*) FROM users;
+//	params:
+//	  temperature: 0
+type Preset struct {
+	Name        string     `yaml:"-"`
+	Path        string     `yaml:"-"`
+	Description string     `yaml:"description"`
+	Model       string     `yaml:"model"`
+	System      string     `yaml:"system"`
+	Examples    []Example  `yaml:"examples"`
+	Params      dto.Params `yaml:"params"`
+}
+
+// Example is a user message with the expected answer.
+type Example struct {
+	User      string `yaml:"user"`
+	Assistant string `yaml:"assistant"`
+}
+
+// Apply replaces the preamble of the chat and the parameters of the previous preset with the ones of the preset,
+// the parameters set by the user stay over them.
+func (p *Preset) Apply(chat *dto.Chat) {
+	overrides := chat.Params.Without(chat.PresetParams)
+
+	chat.Preset = p.Name
+	chat.System = strings.TrimSpace(p.System)
+	chat.Params = p.Params.Merge(overrides)
+	chat.PresetParams = p.Params.Clone()
+	chat.Examples = nil
+
+	for _, example := range p.Examples {
+		chat.Examples = append(chat.Examples,
+			dto.Message{Role: dto.RoleUser, Content: strings.TrimSpace(example.User)},
+			dto.Message{Role: dto.RoleAssistant, Content: strings.TrimSpace(example.Assistant)},
+		)
+	}
+}
+
+// Remove takes the preamble and the parameters of the preset off the chat, the parameters set by the user stay.
+func Remove(chat *dto.Chat) {
+	chat.Preset = ""
+	chat.System = ""
+	chat.Examples = nil
+	chat.Params = chat.Params.Without(chat.PresetParams)
+	chat.PresetParams = dto.Params{}
+}
+
+// Store reads the presets from several folders, a preset in a later folder hides the one with the same name in an earlier folder.
+type Store struct {
+	dirs []string
+}
+
+// New creates a store with the presets of the config directory and of the project the working directory belongs to.
+func New(configDir string) *Store {
+	dirs := []string{UserDir(configDir)}
+
+	if wd, err := os.Getwd(); err == nil {
+		if dir := FindProjectDir(wd); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return NewWithDirs(dirs...)
+}
+
+// UserDir returns the folder of the presets in the config directory.
+func UserDir(configDir string) string {
+	return filepath.Join(configDir, dirName)
+}
+
+// NewWithDirs creates a store reading the presets from the folders.
+func NewWithDirs(dirs ...string) *Store {
+	return &Store{
+		dirs: dirs,
+	}
+}
+
+// FindProjectDir looks for the .chatgpt-cli/presets folder in the directory and its parents.
+func FindProjectDir(dir string) string {
+	for {
+		candidate := filepath.Join(dir, projectDir, dirName)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load reads the preset by its name.
+func (s *Store) Load(name string) (*Preset, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+
+	paths, err := s.paths()
+	if err != nil {
+		return nil, err
+	}
+
+	path, ok := paths[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read preset: %w", err)
+	}
+
+	p := &Preset{
+		Name: name,
+		Path: path,
+	}
+
+	if err = yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("parse preset %s: %w", path, err)
+	}
+
+	return p, nil
+}
+
+// List returns all presets in alphabetical order.
+func (s *Store) List() ([]*Preset, error) {
+	names, err := s.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Preset, 0, len(names))
+	for _, name := range names {
+		p, err := s.Load(name)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, p)
+	}
+
+	return list, nil
+}
+
+// Names returns the names of all presets in alphabetical order.
+func (s *Store) Names() ([]string, error) {
+	paths, err := s.paths()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(paths))
+	for name := range paths {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// paths maps the names of the presets to their files.
+func (s *Store) paths() (map[string]string, error) {
+	paths := make(map[string]string)
+
+	for _, dir := range s.dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read presets dir: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			ext := filepath.Ext(entry.Name())
+			name := strings.TrimSuffix(entry.Name(), ext)
+
+			if !slices.Contains(extensions, ext) || !validName.MatchString(name) {
+				continue
+			}
+
+			paths[name] = filepath.Join(dir, entry.Name())
+		}
+	}
+
+	return paths, nil
+}
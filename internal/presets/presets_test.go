@@ -0,0 +1,99 @@
+package presets_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/presets"
+)
+
+func TestStore(t *testing.T) {
+	user := t.TempDir()
+	project := filepath.Join(t.TempDir(), "repo")
+	projectPresets := filepath.Join(project, ".chatgpt-cli", "presets")
+	nested := filepath.Join(project, "internal", "pkg")
+
+	assert.NoError(t, os.MkdirAll(projectPresets, 0o700))
+	assert.NoError(t, os.MkdirAll(nested, 0o700))
+
+	write := func(dir, name, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	write(user, "translator.yaml", "description: Translates to English\nsystem: Translate to English.\n")
+	write(user, "sql.yaml", "description: User SQL\n")
+	write(user, "notes.txt", "not a preset")
+	write(projectPresets, "sql.yml", `description: Project SQL
+model: gpt-4o
+system: |
+  You write PostgreSQL.
+examples:
+  - user: Count users
+    assistant: SELECT count(*) FROM users;
+params:
+  temperature: 0
+  max_tokens: 200
+`)
+
+	assert.Equal(t, projectPresets, presets.FindProjectDir(nested))
+	assert.Empty(t, presets.FindProjectDir(user))
+
+	store := presets.NewWithDirs(user, presets.FindProjectDir(nested))
+
+	names, err := store.Names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sql", "translator"}, names)
+
+	p, err := store.Load("sql")
+	assert.NoError(t, err)
+	assert.Equal(t, "Project SQL", p.Description)
+	assert.Equal(t, filepath.Join(projectPresets, "sql.yml"), p.Path)
+
+	_, err = store.Load("missing")
+	assert.ErrorIs(t, err, presets.ErrNotFound)
+
+	_, err = store.Load("../sql")
+	assert.ErrorIs(t, err, presets.ErrInvalidName)
+
+	chat := dto.NewChat()
+	chat.AddMessage(dto.RoleUser, "Hi")
+	p.Apply(chat)
+
+	temperature := float32(0)
+	assert.Equal(t, "sql", chat.Preset)
+	assert.Equal(t, dto.Params{Temperature: &temperature, MaxTokens: 200}, chat.Params)
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleSystem, Content: "You write PostgreSQL."},
+		{Role: dto.RoleUser, Content: "Count users"},
+		{Role: dto.RoleAssistant, Content: "SELECT count(*) FROM users;"},
+		{Role: dto.RoleUser, Content: "Hi"},
+	}, chat.Prompt())
+}
+
+func TestPreset_Apply(t *testing.T) {
+	zero, one, half := float32(0), float32(1), float32(0.5)
+
+	chat := dto.NewChat()
+	chat.Params.TopP = &half
+
+	sql := &presets.Preset{Name: "sql", System: "You write SQL.", Params: dto.Params{Temperature: &zero, MaxTokens: 200}}
+	sql.Apply(chat)
+	assert.Equal(t, dto.Params{Temperature: &zero, TopP: &half, MaxTokens: 200}, chat.Params)
+
+	// The user sets the tokens over the preset, they stay when the preset is replaced.
+	chat.Params.MaxTokens = 100
+
+	poet := &presets.Preset{Name: "poet", System: "You write poems.", Params: dto.Params{Temperature: &one, MaxTokens: 50}}
+	poet.Apply(chat)
+	assert.Equal(t, "You write poems.", chat.System)
+	assert.Equal(t, dto.Params{Temperature: &one, TopP: &half, MaxTokens: 100}, chat.Params)
+
+	presets.Remove(chat)
+	assert.Empty(t, chat.Preset)
+	assert.Empty(t, chat.System)
+	assert.Equal(t, dto.Params{TopP: &half, MaxTokens: 100}, chat.Params)
+}
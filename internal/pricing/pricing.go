@@ -0,0 +1,97 @@
+// Package pricing estimates the cost of requests from the per-model price table.
+package pricing
+
+import (
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const perTokens = 1_000_000
+
+// BatchDiscount is the share of the price paid for the requests of the Batch API.
+const BatchDiscount = 0.5
+
+// defaultPrices are the public OpenAI prices in USD per 1M tokens.
+var defaultPrices = map[string]config.Price{
+	"gpt-3.5-turbo": {Input: 0.5, Output: 1.5},
+	"gpt-4":         {Input: 30, Output: 60},
+	"gpt-4-turbo":   {Input: 10, Output: 30},
+	"gpt-4o":        {Input: 2.5, CachedInput: 1.25, Output: 10},
+	"gpt-4o-mini":   {Input: 0.15, CachedInput: 0.075, Output: 0.6},
+	"gpt-4.1":       {Input: 2, CachedInput: 0.5, Output: 8},
+	"gpt-4.1-mini":  {Input: 0.4, CachedInput: 0.1, Output: 1.6},
+	"gpt-4.1-nano":  {Input: 0.1, CachedInput: 0.025, Output: 0.4},
+	"o1":            {Input: 15, CachedInput: 7.5, Output: 60},
+	"o1-mini":       {Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	"o3":            {Input: 2, CachedInput: 0.5, Output: 8},
+	"o3-mini":       {Input: 1.1, CachedInput: 0.55, Output: 4.4},
+	"o4-mini":       {Input: 1.1, CachedInput: 0.275, Output: 4.4},
+
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+	"text-embedding-ada-002": {Input: 0.1},
+}
+
+type Table struct {
+	prices map[string]config.Price
+}
+
+// New creates the price table, the overrides replace the built-in prices.
+func New(overrides map[string]config.Price) *Table {
+	prices := make(map[string]config.Price, len(defaultPrices)+len(overrides))
+	for model, price := range defaultPrices {
+		prices[model] = price
+	}
+	for model, price := range overrides {
+		prices[model] = price
+	}
+
+	return &Table{prices: prices}
+}
+
+// Lookup returns the price of the model, matching the longest known model name it starts with.
+func (t *Table) Lookup(model string) (config.Price, bool) {
+	var (
+		best  string
+		found bool
+	)
+
+	for name := range t.prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+			found = true
+		}
+	}
+
+	return t.prices[best], found
+}
+
+// Cost returns the cost of the usage in USD and whether the model price is known.
+func (t *Table) Cost(model string, usage dto.Usage) (float64, bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+
+	cachedPrice := price.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = price.Input
+	}
+
+	cost := float64(usage.PromptTokens-usage.CachedTokens)*price.Input +
+		float64(usage.CachedTokens)*cachedPrice +
+		float64(usage.CompletionTokens)*price.Output
+
+	return cost / perTokens, true
+}
+
+// Discount returns the share of the price paid for the usage of the record.
+func Discount(record dto.UsageRecord) float64 {
+	if record.Batch != "" {
+		return BatchDiscount
+	}
+
+	return 1
+}
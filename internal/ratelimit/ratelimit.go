@@ -0,0 +1,86 @@
+// Package ratelimit keeps the requests under the requests and tokens per minute limits.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const window = time.Minute
+
+// Limiter allows at most RPM requests and TPM tokens within any minute. Zero limits are not checked.
+type Limiter struct {
+	rpm int
+	tpm int
+
+	mu     sync.Mutex
+	events []event
+}
+
+type event struct {
+	at     time.Time
+	tokens int
+}
+
+func New(rpm, tpm int) *Limiter {
+	return &Limiter{
+		rpm: rpm,
+		tpm: tpm,
+	}
+}
+
+// Wait blocks until a request with the number of tokens fits the limits and takes its share.
+// A request bigger than the tokens limit waits for an empty window instead of blocking forever.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil || l.rpm <= 0 && l.tpm <= 0 {
+		return nil
+	}
+
+	for {
+		delay := l.reserve(tokens)
+		if delay == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes the share of the request if it fits, otherwise it returns how long to wait.
+func (l *Limiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	// Forget the events outside the window.
+	i := 0
+	for i < len(l.events) && now.Sub(l.events[i].at) >= window {
+		i++
+	}
+	l.events = l.events[i:]
+
+	used := 0
+	for _, e := range l.events {
+		used += e.tokens
+	}
+
+	fits := (l.rpm <= 0 || len(l.events) < l.rpm) &&
+		(l.tpm <= 0 || used+tokens <= l.tpm || len(l.events) == 0)
+
+	if fits {
+		l.events = append(l.events, event{at: now, tokens: tokens})
+		return 0
+	}
+
+	// Wait for the oldest event to leave the window, then check again.
+	return l.events[0].at.Add(window).Sub(now)
+}
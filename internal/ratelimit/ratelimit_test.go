@@ -0,0 +1,38 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/ratelimit"
+)
+
+func TestLimiter(t *testing.T) {
+	wait := func(l *ratelimit.Limiter, tokens int) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		return l.Wait(ctx, tokens)
+	}
+
+	unlimited := ratelimit.New(0, 0)
+	for range 100 {
+		assert.NoError(t, wait(unlimited, 1000))
+	}
+
+	requests := ratelimit.New(2, 0)
+	assert.NoError(t, wait(requests, 10))
+	assert.NoError(t, wait(requests, 10))
+	assert.ErrorIs(t, wait(requests, 10), context.DeadlineExceeded)
+
+	tokens := ratelimit.New(0, 100)
+	assert.NoError(t, wait(tokens, 60))
+	assert.ErrorIs(t, wait(tokens, 60), context.DeadlineExceeded)
+	assert.NoError(t, wait(tokens, 40))
+
+	// A request over the limit still goes through in an empty window.
+	assert.NoError(t, wait(ratelimit.New(0, 100), 500))
+}
@@ -0,0 +1,56 @@
+package readline
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const defaultEditor = "vi"
+
+// EditExternal opens $VISUAL or $EDITOR with the initial text and returns the saved content.
+func EditExternal(initial string) (string, error) {
+	f, err := os.CreateTemp("", "chatgpt-cli-*.md")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.WriteString(initial); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+
+	if err = f.Close(); err != nil {
+		return "", fmt.Errorf("close temp file: %w", err)
+	}
+
+	args := append(strings.Fields(editorCommand()), f.Name())
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec // the editor is chosen by the user
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("run editor %s: %w", args[0], err)
+	}
+
+	content, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", fmt.Errorf("read temp file: %w", err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+func editorCommand() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(os.Getenv(env)); editor != "" {
+			return editor
+		}
+	}
+
+	return defaultEditor
+}
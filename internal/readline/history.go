@@ -0,0 +1,118 @@
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+)
+
+const DefaultHistoryLimit = 1000
+
+// History keeps previously entered lines and persists them to a file.
+// Multi-line entries are stored on a single line with escaped newlines.
+type History struct {
+	path    string
+	limit   int
+	entries []string
+}
+
+// LoadHistory reads the history file. A missing file results in an empty history.
+func LoadHistory(path string, limit int) (*History, error) {
+	h := &History{
+		path:  path,
+		limit: limit,
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, unescapeEntry(line))
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+
+	h.trim()
+
+	return h, nil
+}
+
+// Add appends an entry, skipping blanks and immediate duplicates.
+func (h *History) Add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return
+	}
+
+	h.entries = append(h.entries, entry)
+	h.trim()
+}
+
+// Entries returns the entries from the oldest to the newest.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Save writes the history to its file.
+func (h *History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return fmt.Errorf("create history dir: %w", err)
+	}
+
+	var b strings.Builder
+	for _, entry := range h.entries {
+		b.WriteString(escapeEntry(entry))
+		b.WriteByte('\n')
+	}
+
+	if err := atomicfile.Write(h.path, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+
+	return nil
+}
+
+func (h *History) trim() {
+	if h.limit > 0 && len(h.entries) > h.limit {
+		h.entries = h.entries[len(h.entries)-h.limit:]
+	}
+}
+
+var (
+	entryEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	entryUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+func escapeEntry(entry string) string {
+	return entryEscaper.Replace(entry)
+}
+
+func unescapeEntry(line string) string {
+	return entryUnescaper.Replace(line)
+}
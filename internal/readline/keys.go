@@ -0,0 +1,206 @@
+package readline
+
+import (
+	"bufio"
+	"strings"
+)
+
+type keyCode int
+
+const (
+	keyUnknown keyCode = iota
+	keyRune
+	keyPaste
+	keyEnter
+	keyNewline
+	keyTab
+	keyBackspace
+	keyDelete
+	keyLeft
+	keyRight
+	keyWordLeft
+	keyWordRight
+	keyUp
+	keyDown
+	keyHome
+	keyEnd
+	keyKillToEnd
+	keyKillToStart
+	keyDeleteWord
+	keyClearScreen
+	keySearch
+	keyCancel
+	keyEOF
+	keyInterrupt
+)
+
+const (
+	escape     = 0x1b
+	pasteStart = "200~"
+	pasteEnd   = "\x1b[201~"
+)
+
+// controlKeys maps single control bytes to keys, emacs style.
+var controlKeys = map[byte]keyCode{
+	0x01: keyHome,        // Ctrl+A
+	0x02: keyLeft,        // Ctrl+B
+	0x03: keyInterrupt,   // Ctrl+C
+	0x04: keyEOF,         // Ctrl+D
+	0x05: keyEnd,         // Ctrl+E
+	0x06: keyRight,       // Ctrl+F
+	0x07: keyCancel,      // Ctrl+G
+	0x08: keyBackspace,   // Ctrl+H
+	0x09: keyTab,         // Tab
+	0x0a: keyNewline,     // Ctrl+J
+	0x0b: keyKillToEnd,   // Ctrl+K
+	0x0c: keyClearScreen, // Ctrl+L
+	0x0d: keyEnter,       // Enter
+	0x0e: keyDown,        // Ctrl+N
+	0x10: keyUp,          // Ctrl+P
+	0x12: keySearch,      // Ctrl+R
+	0x15: keyKillToStart, // Ctrl+U
+	0x17: keyDeleteWord,  // Ctrl+W
+	0x7f: keyBackspace,   // Backspace
+}
+
+type key struct {
+	code keyCode
+	r    rune
+	text string
+}
+
+// readKey reads a single key press, decoding escape sequences and bracketed paste.
+func readKey(r *bufio.Reader) (key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+
+	if b == escape {
+		return readEscape(r)
+	}
+
+	if code, ok := controlKeys[b]; ok {
+		return key{code: code}, nil
+	}
+
+	if b < 0x20 {
+		return key{code: keyUnknown}, nil
+	}
+
+	if err = r.UnreadByte(); err != nil {
+		return key{}, err
+	}
+
+	ru, _, err := r.ReadRune()
+	if err != nil {
+		return key{}, err
+	}
+
+	return key{code: keyRune, r: ru}, nil
+}
+
+func readEscape(r *bufio.Reader) (key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+
+	switch b {
+	case '\r', '\n':
+		return key{code: keyNewline}, nil // Alt+Enter
+	case 'b':
+		return key{code: keyWordLeft}, nil
+	case 'f':
+		return key{code: keyWordRight}, nil
+	case 'O':
+		b, err = r.ReadByte()
+		if err != nil {
+			return key{}, err
+		}
+
+		return key{code: csiKey("", b)}, nil
+	case '[':
+		return readCSI(r)
+	default:
+		return key{code: keyUnknown}, nil
+	}
+}
+
+func readCSI(r *bufio.Reader) (key, error) {
+	var params strings.Builder
+
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return key{}, err
+		}
+
+		if b >= 0x40 && b <= 0x7e {
+			if b == '~' && params.String()+"~" == pasteStart {
+				return readPaste(r)
+			}
+
+			return key{code: csiKey(params.String(), b)}, nil
+		}
+
+		params.WriteByte(b)
+	}
+}
+
+func csiKey(params string, final byte) keyCode {
+	// Ctrl and Alt modifiers turn arrows into word movements, e.g. "1;5C".
+	modified := strings.HasSuffix(params, ";5") || strings.HasSuffix(params, ";3")
+
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		if modified {
+			return keyWordRight
+		}
+		return keyRight
+	case 'D':
+		if modified {
+			return keyWordLeft
+		}
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDelete
+		}
+	}
+
+	return keyUnknown
+}
+
+// readPaste reads everything up to the end of a bracketed paste.
+func readPaste(r *bufio.Reader) (key, error) {
+	var text strings.Builder
+
+	for !strings.HasSuffix(text.String(), pasteEnd) {
+		b, err := r.ReadByte()
+		if err != nil {
+			return key{}, err
+		}
+
+		text.WriteByte(b)
+	}
+
+	pasted := strings.TrimSuffix(text.String(), pasteEnd)
+	pasted = strings.ReplaceAll(pasted, "\r\n", "\n")
+	pasted = strings.ReplaceAll(pasted, "\r", "\n")
+
+	return key{code: keyPaste, text: pasted}, nil
+}
@@ -0,0 +1,71 @@
+package readline
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want key
+	}{
+		{name: "rune", in: "a", want: key{code: keyRune, r: 'a'}},
+		{name: "multibyte rune", in: "ж", want: key{code: keyRune, r: 'ж'}},
+		{name: "enter", in: "\r", want: key{code: keyEnter}},
+		{name: "ctrl+j", in: "\n", want: key{code: keyNewline}},
+		{name: "ctrl+r", in: "\x12", want: key{code: keySearch}},
+		{name: "unknown control", in: "\x1f", want: key{code: keyUnknown}},
+		{name: "alt+enter", in: "\x1b\r", want: key{code: keyNewline}},
+		{name: "alt+b", in: "\x1bb", want: key{code: keyWordLeft}},
+		{name: "alt+f", in: "\x1bf", want: key{code: keyWordRight}},
+		{name: "unknown escape", in: "\x1bz", want: key{code: keyUnknown}},
+		{name: "up", in: "\x1b[A", want: key{code: keyUp}},
+		{name: "down", in: "\x1b[B", want: key{code: keyDown}},
+		{name: "right", in: "\x1b[C", want: key{code: keyRight}},
+		{name: "ctrl+left", in: "\x1b[1;5D", want: key{code: keyWordLeft}},
+		{name: "alt+right", in: "\x1b[1;3C", want: key{code: keyWordRight}},
+		{name: "ss3 home", in: "\x1bOH", want: key{code: keyHome}},
+		{name: "ss3 end", in: "\x1bOF", want: key{code: keyEnd}},
+		{name: "vt home", in: "\x1b[1~", want: key{code: keyHome}},
+		{name: "rxvt end", in: "\x1b[8~", want: key{code: keyEnd}},
+		{name: "delete", in: "\x1b[3~", want: key{code: keyDelete}},
+		{name: "unknown csi", in: "\x1b[15~", want: key{code: keyUnknown}},
+		{
+			name: "bracketed paste",
+			in:   "\x1b[200~line one\r\nline two\rthree\x1b[201~",
+			want: key{code: keyPaste, text: "line one\nline two\nthree"},
+		},
+		{
+			name: "paste with escapes",
+			in:   "\x1b[200~a\x1b[Ab\x1b[201~",
+			want: key{code: keyPaste, text: "a\x1b[Ab"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tc.in))
+
+			got, err := readKey(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+
+			// The whole sequence is consumed.
+			_, err = r.ReadByte()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestReadKey_Truncated(t *testing.T) {
+	for _, in := range []string{"", "\x1b", "\x1b[1;5", "\x1bO", "\x1b[200~unterminated"} {
+		_, err := readKey(bufio.NewReader(strings.NewReader(in)))
+		assert.ErrorIs(t, err, io.EOF, "%q", in)
+	}
+}
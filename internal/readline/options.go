@@ -0,0 +1,17 @@
+package readline
+
+type Option func(*Editor)
+
+func WithHistory(history *History) Option {
+	return func(e *Editor) {
+		if history != nil {
+			e.history = history
+		}
+	}
+}
+
+func WithCompleter(completer Completer) Option {
+	return func(e *Editor) {
+		e.completer = completer
+	}
+}
@@ -0,0 +1,167 @@
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupt is returned by ReadLine when the user presses Ctrl+C.
+var ErrInterrupt = errors.New("interrupted")
+
+const (
+	blockQuote = `"""`
+
+	bracketedPasteOn  = "\x1b[?2004h"
+	bracketedPasteOff = "\x1b[?2004l"
+)
+
+// Completer returns candidates that may replace the text before the cursor.
+type Completer func(line string) []string
+
+// Editor reads user input with line editing, history and multi-line support.
+// When the input is not a terminal it falls back to plain line reading.
+type Editor struct {
+	in        io.Reader
+	out       io.Writer
+	reader    *bufio.Reader
+	fd        int
+	terminal  bool
+	history   *History
+	completer Completer
+}
+
+func New(in io.Reader, out io.Writer, opts ...Option) *Editor {
+	e := &Editor{
+		in:      in,
+		out:     out,
+		reader:  bufio.NewReader(in),
+		history: &History{},
+	}
+
+	if IsTerminal(in) {
+		e.fd = int(in.(*os.File).Fd())
+		e.terminal = true
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// IsTerminal reports whether the input is an interactive terminal rather than a pipe or a file.
+func IsTerminal(in io.Reader) bool {
+	f, ok := in.(*os.File)
+
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// ReadLine shows the prompt and returns the entered text.
+// Text wrapped in triple quotes may span several lines, the quotes are stripped.
+// It returns io.EOF on Ctrl+D or the end of input and ErrInterrupt on Ctrl+C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	var (
+		line string
+		err  error
+	)
+
+	if e.terminal {
+		line, err = e.readTerminal(prompt)
+	} else {
+		line, err = e.readPlain(prompt)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	e.history.Add(line)
+
+	return line, nil
+}
+
+// AddHistory records text entered some other way, e.g. in an external editor.
+func (e *Editor) AddHistory(entry string) {
+	e.history.Add(entry)
+}
+
+// Close persists the history.
+func (e *Editor) Close() error {
+	return e.history.Save()
+}
+
+func (e *Editor) readTerminal(prompt string) (string, error) {
+	state, err := term.MakeRaw(e.fd)
+	if err != nil {
+		return "", fmt.Errorf("make raw: %w", err)
+	}
+
+	defer func() {
+		_, _ = io.WriteString(e.out, bracketedPasteOff)
+		_ = term.Restore(e.fd, state)
+	}()
+
+	_, _ = io.WriteString(e.out, bracketedPasteOn)
+
+	return newSession(e, prompt).run()
+}
+
+func (e *Editor) readPlain(prompt string) (string, error) {
+	_, _ = io.WriteString(e.out, prompt)
+
+	var text strings.Builder
+
+	for {
+		line, err := e.reader.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "" && text.Len() == 0) {
+			return "", err
+		}
+
+		text.WriteString(line)
+
+		if !blockPending(strings.TrimRight(text.String(), "\n")) || err != nil {
+			break
+		}
+	}
+
+	return unquoteBlock(strings.TrimRight(text.String(), "\r\n")), nil
+}
+
+func (e *Editor) width() int {
+	const defaultWidth = 80
+
+	if w, _, err := term.GetSize(e.fd); err == nil && w > 0 {
+		return w
+	}
+
+	return defaultWidth
+}
+
+// blockPending reports whether the text opens a triple-quoted block that is not closed yet.
+func blockPending(text string) bool {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, blockQuote) {
+		return false
+	}
+
+	return len(text) < 2*len(blockQuote) || !strings.HasSuffix(text, blockQuote)
+}
+
+func unquoteBlock(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, blockQuote) {
+		return text
+	}
+
+	trimmed = strings.TrimPrefix(trimmed, blockQuote)
+	trimmed = strings.TrimSuffix(trimmed, blockQuote)
+
+	return strings.Trim(trimmed, "\r\n")
+}
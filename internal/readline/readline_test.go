@@ -0,0 +1,96 @@
+package readline_test
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/readline"
+)
+
+func TestEditor_ReadLine(t *testing.T) {
+	testCases := []struct {
+		name     string
+		in       string
+		expected []string
+	}{
+		{
+			name:     "single lines",
+			in:       "hello\nworld\n",
+			expected: []string{"hello", "world"},
+		},
+		{
+			name:     "last line without newline",
+			in:       "hello",
+			expected: []string{"hello"},
+		},
+		{
+			name:     "multi-line block",
+			in:       "\"\"\"\nfunc main() {\n}\n\"\"\"\nnext\n",
+			expected: []string{"func main() {\n}", "next"},
+		},
+		{
+			name:     "block on a single line",
+			in:       "\"\"\"quoted\"\"\"\n",
+			expected: []string{"quoted"},
+		},
+		{
+			name:     "block started on the first line",
+			in:       "\"\"\"first\nsecond\"\"\"\n",
+			expected: []string{"first\nsecond"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := readline.New(strings.NewReader(tc.in), io.Discard)
+
+			var lines []string
+			for {
+				line, err := e.ReadLine("> ")
+				if err == io.EOF || !assert.NoError(t, err) {
+					break
+				}
+
+				lines = append(lines, line)
+			}
+
+			assert.Equal(t, tc.expected, lines)
+		})
+	}
+}
+
+func TestEditor_ReadLinePrompt(t *testing.T) {
+	var out bytes.Buffer
+
+	e := readline.New(strings.NewReader("hi\n"), &out)
+
+	_, err := e.ReadLine("[You] ")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "[You] ", out.String())
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "history")
+
+	h, err := readline.LoadHistory(path, 3)
+	assert.NoError(t, err)
+	assert.Empty(t, h.Entries())
+
+	e := readline.New(strings.NewReader("one\none\n\n\"\"\"\ntwo\nlines\n\"\"\"\nthree\nback\\slash\n"), io.Discard, readline.WithHistory(h))
+	for {
+		if _, err = e.ReadLine(""); err != nil {
+			break
+		}
+	}
+	assert.NoError(t, e.Close())
+
+	loaded, err := readline.LoadHistory(path, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"two\nlines", "three", "back\\slash"}, loaded.Entries())
+}
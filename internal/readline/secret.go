@@ -0,0 +1,34 @@
+package readline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ReadSecret reads a line without echoing it on a terminal, e.g. an API key.
+// The first line of a pipe is read as is.
+func ReadSecret(in io.Reader, out io.Writer, prompt string) (string, error) {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(out, prompt)
+		secret, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(out)
+		if err != nil {
+			return "", fmt.Errorf("read secret: %w", err)
+		}
+
+		return strings.TrimSpace(string(secret)), nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read secret: %w", err)
+	}
+
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,499 @@
+package readline
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const (
+	searchPrompt       = "(reverse-i-search)`%s': "
+	failedSearchPrompt = "(failed reverse-i-search)`%s': "
+	bell               = "\a"
+)
+
+// session edits a single input in raw terminal mode.
+type session struct {
+	editor *Editor
+	prompt string
+
+	buf []rune
+	pos int
+
+	// rows is the cursor row relative to the first prompt row after the last render.
+	rows int
+
+	// historyIndex counts entries back from the newest one, zero is the edited buffer.
+	historyIndex int
+	saved        []rune
+
+	search *search
+}
+
+type search struct {
+	query    []rune
+	match    int
+	failed   bool
+	original []rune
+}
+
+func newSession(e *Editor, prompt string) *session {
+	return &session{
+		editor: e,
+		prompt: prompt,
+	}
+}
+
+func (s *session) run() (string, error) {
+	s.render()
+
+	for {
+		k, err := readKey(s.editor.reader)
+		if err != nil {
+			return "", err
+		}
+
+		if s.search != nil {
+			done, line, submit := s.handleSearch(k)
+			if submit {
+				return s.submit(line), nil
+			}
+			if done {
+				s.render()
+				continue
+			}
+		}
+
+		switch k.code {
+		case keyEnter:
+			text := string(s.buf)
+			if blockPending(text) {
+				s.insert([]rune{'\n'})
+				break
+			}
+			return s.submit(unquoteBlock(text)), nil
+		case keyEOF:
+			if len(s.buf) == 0 {
+				s.finish("")
+				return "", io.EOF
+			}
+			s.deleteForward()
+		case keyInterrupt:
+			s.finish("^C")
+			return "", ErrInterrupt
+		default:
+			s.handleEdit(k)
+		}
+
+		s.render()
+	}
+}
+
+func (s *session) handleEdit(k key) {
+	switch k.code {
+	case keyRune:
+		s.insert([]rune{k.r})
+	case keyPaste:
+		s.insert([]rune(k.text))
+	case keyNewline:
+		s.insert([]rune{'\n'})
+	case keyTab:
+		s.complete()
+	case keyBackspace:
+		if s.pos > 0 {
+			s.buf = append(s.buf[:s.pos-1], s.buf[s.pos:]...)
+			s.pos--
+		}
+	case keyDelete:
+		s.deleteForward()
+	case keyLeft:
+		if s.pos > 0 {
+			s.pos--
+		}
+	case keyRight:
+		if s.pos < len(s.buf) {
+			s.pos++
+		}
+	case keyWordLeft:
+		s.pos = s.wordLeft()
+	case keyWordRight:
+		s.pos = s.wordRight()
+	case keyHome:
+		s.pos = s.lineStart(s.pos)
+	case keyEnd:
+		s.pos = s.lineEnd(s.pos)
+	case keyUp:
+		if s.lineStart(s.pos) > 0 {
+			s.moveLine(-1)
+		} else {
+			s.historyPrev()
+		}
+	case keyDown:
+		if s.lineEnd(s.pos) < len(s.buf) {
+			s.moveLine(1)
+		} else {
+			s.historyNext()
+		}
+	case keyKillToEnd:
+		s.buf = append(s.buf[:s.pos], s.buf[s.lineEnd(s.pos):]...)
+	case keyKillToStart:
+		start := s.lineStart(s.pos)
+		s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+		s.pos = start
+	case keyDeleteWord:
+		start := s.wordLeft()
+		s.buf = append(s.buf[:start], s.buf[s.pos:]...)
+		s.pos = start
+	case keyClearScreen:
+		s.write("\x1b[H\x1b[2J")
+		s.rows = 0
+	case keySearch:
+		s.search = &search{match: len(s.editor.history.Entries()), original: slices.Clone(s.buf)}
+		s.findOlder()
+	default:
+		s.write(bell)
+	}
+}
+
+// handleSearch processes a key in the reverse search mode.
+// done means the key was consumed, submit means the found entry must be returned.
+func (s *session) handleSearch(k key) (done bool, line string, submit bool) {
+	entries := s.editor.history.Entries()
+
+	switch k.code {
+	case keyRune:
+		s.search.query = append(s.search.query, k.r)
+		s.search.match = min(s.search.match+1, len(entries))
+		s.findOlder()
+		return true, "", false
+	case keyBackspace:
+		if len(s.search.query) > 0 {
+			s.search.query = s.search.query[:len(s.search.query)-1]
+		}
+		s.search.match = len(entries)
+		s.findOlder()
+		return true, "", false
+	case keySearch:
+		s.findOlder()
+		return true, "", false
+	case keyCancel:
+		s.setBuffer(s.search.original)
+		s.search = nil
+		return true, "", false
+	case keyEnter:
+		s.acceptSearch()
+		return true, string(s.buf), true
+	default:
+		s.acceptSearch()
+		return false, "", false
+	}
+}
+
+// findOlder moves the search match to the next older entry containing the query.
+func (s *session) findOlder() {
+	entries := s.editor.history.Entries()
+	query := string(s.search.query)
+
+	for i := s.search.match - 1; i >= 0; i-- {
+		if strings.Contains(entries[i], query) {
+			s.search.match = i
+			s.search.failed = false
+			return
+		}
+	}
+
+	s.search.failed = true
+}
+
+func (s *session) acceptSearch() {
+	entries := s.editor.history.Entries()
+	if s.search.match < len(entries) {
+		s.setBuffer([]rune(entries[s.search.match]))
+	}
+
+	s.search = nil
+}
+
+func (s *session) insert(runes []rune) {
+	tail := append([]rune{}, s.buf[s.pos:]...)
+	s.buf = append(append(s.buf[:s.pos], runes...), tail...)
+	s.pos += len(runes)
+}
+
+func (s *session) deleteForward() {
+	if s.pos < len(s.buf) {
+		s.buf = append(s.buf[:s.pos], s.buf[s.pos+1:]...)
+	}
+}
+
+func (s *session) setBuffer(buf []rune) {
+	s.buf = append([]rune{}, buf...)
+	s.pos = len(s.buf)
+}
+
+func (s *session) complete() {
+	if s.editor.completer == nil {
+		s.write(bell)
+		return
+	}
+
+	candidates := s.editor.completer(string(s.buf[:s.pos]))
+
+	switch len(candidates) {
+	case 0:
+		s.write(bell)
+	case 1:
+		s.replacePrefix(candidates[0])
+	default:
+		prefix := commonPrefix(candidates)
+		if len([]rune(prefix)) > s.pos {
+			s.replacePrefix(prefix)
+			return
+		}
+
+		s.moveToEnd()
+		s.write("\r\n" + strings.Join(candidates, "  ") + "\r\n")
+		s.rows = 0
+	}
+}
+
+func (s *session) replacePrefix(prefix string) {
+	tail := s.buf[s.pos:]
+	s.buf = append([]rune(prefix), tail...)
+	s.pos = len([]rune(prefix))
+}
+
+func (s *session) historyPrev() {
+	entries := s.editor.history.Entries()
+	if s.historyIndex >= len(entries) {
+		s.write(bell)
+		return
+	}
+
+	if s.historyIndex == 0 {
+		s.saved = slices.Clone(s.buf)
+	}
+
+	s.historyIndex++
+	s.setBuffer([]rune(entries[len(entries)-s.historyIndex]))
+}
+
+func (s *session) historyNext() {
+	entries := s.editor.history.Entries()
+
+	switch {
+	case s.historyIndex == 0:
+		s.write(bell)
+	case s.historyIndex == 1:
+		s.historyIndex = 0
+		s.setBuffer(s.saved)
+	default:
+		s.historyIndex--
+		s.setBuffer([]rune(entries[len(entries)-s.historyIndex]))
+	}
+}
+
+func (s *session) lineStart(pos int) int {
+	for pos > 0 && s.buf[pos-1] != '\n' {
+		pos--
+	}
+
+	return pos
+}
+
+func (s *session) lineEnd(pos int) int {
+	for pos < len(s.buf) && s.buf[pos] != '\n' {
+		pos++
+	}
+
+	return pos
+}
+
+// moveLine moves the cursor to the previous or the next line keeping the column.
+func (s *session) moveLine(direction int) {
+	start := s.lineStart(s.pos)
+	column := s.pos - start
+
+	var target int
+	if direction < 0 {
+		target = s.lineStart(start - 1)
+	} else {
+		target = s.lineEnd(s.pos) + 1
+	}
+
+	s.pos = min(target+column, s.lineEnd(target))
+}
+
+func (s *session) wordLeft() int {
+	pos := s.pos
+	for pos > 0 && !isWordRune(s.buf[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(s.buf[pos-1]) {
+		pos--
+	}
+
+	return pos
+}
+
+func (s *session) wordRight() int {
+	pos := s.pos
+	for pos < len(s.buf) && !isWordRune(s.buf[pos]) {
+		pos++
+	}
+	for pos < len(s.buf) && isWordRune(s.buf[pos]) {
+		pos++
+	}
+
+	return pos
+}
+
+func (s *session) submit(line string) string {
+	s.moveToEnd()
+	s.write("\r\n")
+
+	return line
+}
+
+func (s *session) finish(marker string) {
+	s.moveToEnd()
+	s.write(marker + "\r\n")
+}
+
+func (s *session) moveToEnd() {
+	s.pos = len(s.buf)
+	s.render()
+}
+
+// render redraws the prompt and the buffer and places the cursor.
+func (s *session) render() {
+	var out strings.Builder
+
+	if s.rows > 0 {
+		fmt.Fprintf(&out, "\x1b[%dA", s.rows)
+	}
+	out.WriteString("\r\x1b[J")
+
+	prompt, text, cursor := s.view()
+	width := s.editor.width()
+	continuation := strings.Repeat(" ", max(runewidth.StringWidth(prompt)-4, 0)) + "... "
+
+	var row, col, cursorRow, cursorCol int
+
+	put := func(r rune) {
+		if r == '\t' || unicode.IsControl(r) {
+			r = ' '
+		}
+
+		w := runewidth.RuneWidth(r)
+		if col+w > width {
+			row++
+			col = 0
+		}
+
+		out.WriteRune(r)
+		col += w
+	}
+
+	for _, r := range prompt {
+		put(r)
+	}
+
+	for i, r := range text {
+		if i == cursor {
+			cursorRow, cursorCol = s.position(row, col, r, width)
+		}
+
+		if r == '\n' {
+			out.WriteString("\r\n")
+			row++
+			col = 0
+
+			for _, c := range continuation {
+				put(c)
+			}
+
+			continue
+		}
+
+		put(r)
+	}
+
+	if col >= width {
+		out.WriteString("\r\n")
+		row++
+		col = 0
+	}
+
+	if cursor >= len(text) {
+		cursorRow, cursorCol = row, col
+	}
+
+	if up := row - cursorRow; up > 0 {
+		fmt.Fprintf(&out, "\x1b[%dA", up)
+	}
+	out.WriteString("\r")
+	if cursorCol > 0 {
+		fmt.Fprintf(&out, "\x1b[%dC", cursorCol)
+	}
+
+	s.rows = cursorRow
+	s.write(out.String())
+}
+
+// position returns where the rune r will be drawn when the next free cell is (row, col).
+func (s *session) position(row, col int, r rune, width int) (int, int) {
+	if r != '\n' && col+runewidth.RuneWidth(r) > width {
+		return row + 1, 0
+	}
+
+	return row, min(col, width-1)
+}
+
+// view returns what must be shown: the prompt, the text and the cursor position in the text.
+func (s *session) view() (string, []rune, int) {
+	if s.search == nil {
+		return s.prompt, s.buf, s.pos
+	}
+
+	format := searchPrompt
+	if s.search.failed {
+		format = failedSearchPrompt
+	}
+
+	var text []rune
+	if entries := s.editor.history.Entries(); s.search.match < len(entries) {
+		text = []rune(entries[s.search.match])
+	}
+
+	return fmt.Sprintf(format, string(s.search.query)), text, len(text)
+}
+
+func (s *session) write(text string) {
+	_, _ = io.WriteString(s.editor.out, text)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func commonPrefix(values []string) string {
+	prefix := []rune(values[0])
+
+	for _, value := range values[1:] {
+		runes := []rune(value)
+
+		n := 0
+		for n < len(prefix) && n < len(runes) && prefix[n] == runes[n] {
+			n++
+		}
+
+		prefix = prefix[:n]
+	}
+
+	return string(prefix)
+}
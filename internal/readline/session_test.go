@@ -0,0 +1,58 @@
+package readline
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []string
+		in      string
+		want    string
+	}{
+		{name: "typing", in: "hello\r", want: "hello"},
+		{name: "backspace", in: "helo\x7f\x7fllo\r", want: "hello"},
+		{name: "insert in the middle", in: "hllo\x1b[D\x1b[D\x1b[De\r", want: "hello"},
+		{name: "delete forward", in: "xhello\x01\x1b[3~\r", want: "hello"},
+		{name: "ctrl+d deletes forward", in: "xhello\x01\x04\r", want: "hello"},
+		{name: "kill to end", in: "hello world\x1bb\x0b\r", want: "hello "},
+		{name: "kill to start", in: "hello world\x1bb\x15\r", want: "world"},
+		{name: "delete word", in: "hello big world\x1bb\x17\r", want: "hello world"},
+		{name: "word right", in: "one two\x01\x1bf!\r", want: "one! two"},
+		{name: "paste", in: "say \x1b[200~a\r\nb\x1b[201~\r", want: "say a\nb"},
+		{name: "alt+enter adds a line", in: "a\x1b\rb\r", want: "a\nb"},
+		{name: "up moves between lines", in: "ab\ncd\x1b[A\x1b[DX\r", want: "aXb\ncd"},
+		{name: "open block", in: "\"\"\"\rx\r\"\"\"\r", want: "x"},
+		{name: "history", history: []string{"first", "second"}, in: "\x10\x10\x0e\r", want: "second"},
+		{name: "history keeps the edited buffer", history: []string{"first"}, in: "draft\x10\x0e!\r", want: "draft!"},
+		{name: "reverse search", history: []string{"git status", "go test", "git push"}, in: "\x12stat\r", want: "git status"},
+		{name: "search accepted by editing", history: []string{"go test"}, in: "\x12te\x05 ./...\r", want: "go test ./..."},
+		{name: "search cancelled", history: []string{"go test"}, in: "draft\x12go\x07!\r", want: "draft!"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := New(strings.NewReader(tc.in), io.Discard)
+			for _, entry := range tc.history {
+				e.AddHistory(entry)
+			}
+
+			got, err := newSession(e, "> ").run()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSession_EOF(t *testing.T) {
+	_, err := newSession(New(strings.NewReader("\x04"), io.Discard), "> ").run()
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = newSession(New(strings.NewReader("abc\x03"), io.Discard), "> ").run()
+	assert.ErrorIs(t, err, ErrInterrupt)
+}
@@ -0,0 +1,16 @@
+package redact
+
+import "context"
+
+type redactorKey struct{}
+
+// WithRedactor makes the requests made with the context redacted by the redactor, e.g. by the scope of a request.
+func WithRedactor(ctx context.Context, r *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, r)
+}
+
+// FromContext returns the redactor set by WithRedactor.
+func FromContext(ctx context.Context) (*Redactor, bool) {
+	r, ok := ctx.Value(redactorKey{}).(*Redactor)
+	return r, ok && r != nil
+}
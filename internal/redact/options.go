@@ -0,0 +1,10 @@
+package redact
+
+type Option func(*Redactor)
+
+// WithReporter is called with the values a chat redacts for the first time, e.g. to tell the user.
+func WithReporter(report func(Summary)) Option {
+	return func(r *Redactor) {
+		r.report = report
+	}
+}
@@ -0,0 +1,333 @@
+// Package redact replaces the secrets and the personal data in the messages with placeholders
+// before the requests leave the machine.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+var (
+	// placeholder matches the placeholders so they are never redacted again.
+	placeholder = regexp.MustCompile(`\[REDACTED_[A-Z0-9_]+_\d+\]`)
+	nonWord     = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// detector finds a kind of values, the first group of the regex is replaced if it has one.
+type detector struct {
+	name  string
+	label string
+	re    *regexp.Regexp
+}
+
+// detectors are the built-in ones. Overlapping matches are replaced together and named
+// after the earliest listed detector, the custom patterns come after the built-in ones.
+var detectors = []detector{
+	{
+		name:  "private_key",
+		label: "private key",
+		re:    regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY-----`),
+	},
+	{
+		name:  "jwt",
+		label: "JWT",
+		re:    regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]*`),
+	},
+	{
+		name:  "aws_access_key",
+		label: "AWS access key",
+		re:    regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`),
+	},
+	{
+		name:  "aws_secret_key",
+		label: "AWS secret key",
+		re:    regexp.MustCompile(`(?i)aws_?secret(?:_?access)?_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+	},
+	{
+		name:  "gcp_api_key",
+		label: "GCP API key",
+		re:    regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	},
+	{
+		name:  "gcp_token",
+		label: "GCP token",
+		re:    regexp.MustCompile(`\bya29\.[0-9A-Za-z_-]{20,}`),
+	},
+	{
+		name:  "api_key",
+		label: "API key",
+		re: regexp.MustCompile(`\b(?:sk-[A-Za-z0-9_-]{20,}|(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,}` +
+			`|xox[abprs]-[A-Za-z0-9-]{10,}|[sr]k_live_[A-Za-z0-9]{16,}|glpat-[A-Za-z0-9_-]{20})`),
+	},
+	{
+		name:  "url_password",
+		label: "password in a URL",
+		re:    regexp.MustCompile(`\b[A-Za-z][A-Za-z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`),
+	},
+	{
+		name:  "password",
+		label: "password",
+		re:    regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret)["']?\s*[:=]\s*["']?([^\s"',;]{4,})`),
+	},
+	{
+		name:  "email",
+		label: "email",
+		re:    regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	},
+}
+
+// Names returns the names of the built-in detectors.
+func Names() []string {
+	names := make([]string, 0, len(detectors))
+	for _, d := range detectors {
+		names = append(names, d.name)
+	}
+
+	return names
+}
+
+// Summary counts the redacted values by their kind.
+type Summary map[string]int
+
+// String lists the counts, e.g. "2 API keys, 1 email".
+func (s Summary) String() string {
+	labels := make([]string, 0, len(s))
+	for label := range s {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		n := s[label]
+		if n != 1 {
+			label = plural(label)
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, label))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func plural(label string) string {
+	// "password in a URL" becomes "passwords in URLs".
+	if head, tail, ok := strings.Cut(label, " in a "); ok {
+		return head + "s in " + tail + "s"
+	}
+
+	return label + "s"
+}
+
+// Redactor replaces every value with the same placeholder for the life of the process,
+// so a chat keeps referring to the same value and the answers can be restored.
+type Redactor struct {
+	detectors []detector
+	restore   bool
+	report    func(Summary)
+
+	mu sync.Mutex
+	// placeholders of the values and the values of the placeholders.
+	placeholders map[string]string
+	values       map[string]string
+	// counts numbers the placeholders of every detector.
+	counts map[string]int
+}
+
+// New creates the redactor with the built-in detectors except the skipped ones and the custom patterns.
+func New(cfg config.Redaction, opts ...Option) (*Redactor, error) {
+	r := &Redactor{
+		restore:      cfg.Restore,
+		placeholders: make(map[string]string),
+		values:       make(map[string]string),
+		counts:       make(map[string]int),
+	}
+
+	names := Names()
+	for _, name := range cfg.Skip {
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("unknown redaction detector %q, use one of %s", name, strings.Join(names, ", "))
+		}
+	}
+
+	for _, d := range detectors {
+		if !slices.Contains(cfg.Skip, d.name) {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+
+	for _, p := range cfg.Patterns {
+		if p.Name == "" {
+			return nil, fmt.Errorf("redaction pattern %q has no name", p.Regex)
+		}
+
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("parse redaction pattern %s: %w", p.Name, err)
+		}
+
+		r.detectors = append(r.detectors, detector{name: p.Name, label: p.Name, re: re})
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+// Scope returns a redactor with the same detectors and its own placeholders that reports nothing,
+// e.g. for a request of a client of the server, so no client gets the values of another one restored
+// and the placeholders are forgotten with the request.
+func (r *Redactor) Scope() *Redactor {
+	return &Redactor{
+		detectors:    r.detectors,
+		restore:      r.restore,
+		placeholders: make(map[string]string),
+		values:       make(map[string]string),
+		counts:       make(map[string]int),
+	}
+}
+
+// Chat returns a copy of the chat with all the messages redacted, the chat itself is kept as it is.
+// The values seen for the first time are reported.
+func (r *Redactor) Chat(chat *dto.Chat) *dto.Chat {
+	summary := make(Summary)
+
+	out := *chat
+	out.System = r.text(chat.System, summary)
+
+	out.Examples = slices.Clone(chat.Examples)
+	for i := range out.Examples {
+		out.Examples[i].Content = r.text(out.Examples[i].Content, summary)
+	}
+
+	out.Nodes = slices.Clone(chat.Nodes)
+	for i := range out.Nodes {
+		out.Nodes[i].Content = r.text(out.Nodes[i].Content, summary)
+	}
+
+	if len(summary) > 0 && r.report != nil {
+		r.report(summary)
+	}
+
+	return &out
+}
+
+// Text returns the text redacted and the values in it seen for the first time.
+func (r *Redactor) Text(text string) (string, Summary) {
+	summary := make(Summary)
+	return r.text(text, summary), summary
+}
+
+// Restore puts the values back in place of their placeholders if the config asks for it.
+func (r *Redactor) Restore(text string) string {
+	if !r.restore || !strings.Contains(text, "[REDACTED_") {
+		return text
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return placeholder.ReplaceAllStringFunc(text, func(p string) string {
+		if value, ok := r.values[p]; ok {
+			return value
+		}
+		return p
+	})
+}
+
+// span is a value found by a detector.
+type span struct {
+	start, end int
+	detector   int
+}
+
+func (r *Redactor) text(text string, summary Summary) string {
+	if text == "" {
+		return text
+	}
+
+	spans := r.find(text)
+	if len(spans) == 0 {
+		return text
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		b.WriteString(text[pos:s.start])
+		b.WriteString(r.placeholder(text[s.start:s.end], r.detectors[s.detector], summary))
+		pos = s.end
+	}
+	b.WriteString(text[pos:])
+
+	return b.String()
+}
+
+// find returns the values to replace in the order of the text. The overlapping matches are merged
+// into one value, so no part of them stays, of the detector listed first.
+func (r *Redactor) find(text string) []span {
+	var spans []span
+
+	for i, d := range r.detectors {
+		for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+
+			if start == end || placeholder.MatchString(text[start:end]) {
+				continue
+			}
+
+			spans = append(spans, span{start: start, end: end, detector: i})
+		}
+	}
+
+	sort.SliceStable(spans, func(a, b int) bool {
+		if spans[a].start != spans[b].start {
+			return spans[a].start < spans[b].start
+		}
+		return spans[a].detector < spans[b].detector
+	})
+
+	kept := spans[:0]
+	for _, s := range spans {
+		if len(kept) == 0 || s.start >= kept[len(kept)-1].end {
+			kept = append(kept, s)
+			continue
+		}
+
+		last := &kept[len(kept)-1]
+		last.end = max(last.end, s.end)
+		last.detector = min(last.detector, s.detector)
+	}
+
+	return kept
+}
+
+// placeholder returns the placeholder of the value, a new one is counted in the summary.
+func (r *Redactor) placeholder(value string, d detector, summary Summary) string {
+	if p, ok := r.placeholders[value]; ok {
+		return p
+	}
+
+	r.counts[d.name]++
+	p := fmt.Sprintf("[REDACTED_%s_%d]", strings.ToUpper(nonWord.ReplaceAllString(d.name, "_")), r.counts[d.name])
+
+	r.placeholders[value] = p
+	r.values[p] = value
+	summary[d.label]++
+
+	return p
+}
@@ -0,0 +1,432 @@
+// Package search finds saved sessions by their words with an inverted index kept next to them,
+// or by their meaning with the embeddings of their messages.
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	indexFile = "search_index.json"
+	// indexFormat is the version of the index layout, an index of another one is rebuilt.
+	indexFormat = 1
+	// maxMessages is the number of matching messages kept for a session.
+	maxMessages = 3
+)
+
+type sessionStore interface {
+	Versions() (map[string]time.Time, error)
+	Load(name string) (*dto.Session, error)
+}
+
+// Index is an inverted index of the words of the saved sessions with their positions,
+// it is loaded from the data directory on the first use.
+type Index struct {
+	path string
+
+	mu     sync.Mutex
+	loaded bool
+	data   indexData
+}
+
+type indexData struct {
+	Format int                  `json:"format"`
+	Docs   map[string]*document `json:"docs"`
+	Terms  map[string][]posting `json:"terms"`
+}
+
+// document is an indexed session, Version is the modification time of its file.
+type document struct {
+	Version   time.Time      `json:"version"`
+	Model     string         `json:"model,omitempty"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Messages  []indexMessage `json:"messages"`
+}
+
+// indexMessage is a message of all the branches, ID is the node ID or zero for the system prompt.
+type indexMessage struct {
+	ID    int       `json:"id"`
+	Role  dto.Role  `json:"role"`
+	Model string    `json:"model,omitempty"`
+	Time  time.Time `json:"time"`
+}
+
+// posting is the positions of a word in a message of a session.
+type posting struct {
+	Session   string `json:"s"`
+	Message   int    `json:"m"`
+	Positions []int  `json:"p"`
+}
+
+// Result is a session matching the query with the IDs of its best matching messages,
+// zero stands for the system prompt.
+type Result struct {
+	Session   string
+	Model     string
+	UpdatedAt time.Time
+	Score     float64
+	Messages  []int
+}
+
+// New creates the index kept in the data directory.
+func New(dataDir string) *Index {
+	return &Index{
+		path: filepath.Join(dataDir, indexFile),
+	}
+}
+
+// Update indexes the saved session, it replaces the previous version of the session.
+func (i *Index) Update(session *dto.Session, version time.Time) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.load(); err != nil {
+		return err
+	}
+
+	i.remove(session.Name)
+	i.add(session, version)
+
+	return i.save()
+}
+
+// Sync catches up with the sessions changed outside the store, e.g. saved by an older version or deleted.
+func (i *Index) Sync(store sessionStore) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.load(); err != nil {
+		return err
+	}
+
+	versions, err := store.Versions()
+	if err != nil {
+		return err
+	}
+
+	// The outdated sessions are removed in one pass over the terms, e.g. after an import of many chats.
+	stale := make(map[string]bool)
+	for name, doc := range i.data.Docs {
+		if version, ok := versions[name]; !ok || !doc.Version.Equal(version) {
+			stale[name] = true
+		}
+	}
+
+	var changed []*dto.Session
+	for name, version := range versions {
+		if doc, ok := i.data.Docs[name]; ok && doc.Version.Equal(version) {
+			continue
+		}
+
+		session, err := store.Load(name)
+		if err != nil {
+			return err
+		}
+
+		changed = append(changed, session)
+	}
+
+	if len(stale) == 0 && len(changed) == 0 {
+		return nil
+	}
+
+	i.removeAll(stale)
+	for _, session := range changed {
+		i.add(session, versions[session.Name])
+	}
+
+	return i.save()
+}
+
+// Search returns the sessions containing all the clauses of the query in the messages the filter allows,
+// the best matches first. A clause counts more the fewer sessions contain it.
+func (i *Index) Search(q Query, f Filter, limit int) ([]Result, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if err := i.load(); err != nil {
+		return nil, err
+	}
+
+	if len(q.Clauses) == 0 {
+		return nil, nil
+	}
+
+	// hits counts the occurrences of every clause by the sessions and their messages.
+	hits := make([]map[string]map[int]int, len(q.Clauses))
+	for c, clause := range q.Clauses {
+		hits[c] = i.match(clause, f)
+		if len(hits[c]) == 0 {
+			return nil, nil
+		}
+	}
+
+	total := float64(len(i.data.Docs))
+
+	var results []Result
+	for name := range hits[0] {
+		result := Result{Session: name}
+		counts := make(map[int]int)
+		matched := true
+
+		for c := range q.Clauses {
+			messages, ok := hits[c][name]
+			if !ok {
+				matched = false
+				break
+			}
+
+			tf := 0
+			for m, n := range messages {
+				tf += n
+				counts[m] += n
+			}
+
+			idf := math.Log(1 + total/float64(len(hits[c])))
+			result.Score += (1 + math.Log(float64(tf))) * idf
+		}
+
+		if !matched {
+			continue
+		}
+
+		doc := i.data.Docs[name]
+		result.Model = doc.Model
+		result.UpdatedAt = doc.UpdatedAt
+		result.Messages = bestMessages(doc, counts)
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		if !results[a].UpdatedAt.Equal(results[b].UpdatedAt) {
+			return results[a].UpdatedAt.After(results[b].UpdatedAt)
+		}
+		return results[a].Session < results[b].Session
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// match counts the occurrences of the words following each other by the sessions and the message indexes.
+func (i *Index) match(words []string, f Filter) map[string]map[int]int {
+	type key struct {
+		session string
+		message int
+	}
+
+	// positions of the following words by the messages containing them.
+	next := make([]map[key]map[int]bool, len(words))
+	for w := 1; w < len(words); w++ {
+		next[w] = make(map[key]map[int]bool)
+		for _, p := range i.data.Terms[words[w]] {
+			set := make(map[int]bool, len(p.Positions))
+			for _, pos := range p.Positions {
+				set[pos] = true
+			}
+			next[w][key{p.Session, p.Message}] = set
+		}
+	}
+
+	out := make(map[string]map[int]int)
+
+	for _, p := range i.data.Terms[words[0]] {
+		doc := i.data.Docs[p.Session]
+		if doc == nil || !allows(f, doc, doc.Messages[p.Message]) {
+			continue
+		}
+
+		n := 0
+		for _, pos := range p.Positions {
+			found := true
+			for w := 1; w < len(words) && found; w++ {
+				found = next[w][key{p.Session, p.Message}][pos+w]
+			}
+			if found {
+				n++
+			}
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		if out[p.Session] == nil {
+			out[p.Session] = make(map[int]int)
+		}
+		out[p.Session][p.Message] += n
+	}
+
+	return out
+}
+
+func allows(f Filter, doc *document, m indexMessage) bool {
+	model := m.Model
+	if model == "" {
+		model = doc.Model
+	}
+
+	at := m.Time
+	if at.IsZero() {
+		at = doc.UpdatedAt
+	}
+
+	return f.allows(m.Role, model, at)
+}
+
+// bestMessages returns the IDs of the messages with the most hits in the order of the chat.
+func bestMessages(doc *document, counts map[int]int) []int {
+	indexes := make([]int, 0, len(counts))
+	for m := range counts {
+		indexes = append(indexes, m)
+	}
+
+	sort.Slice(indexes, func(a, b int) bool {
+		if counts[indexes[a]] != counts[indexes[b]] {
+			return counts[indexes[a]] > counts[indexes[b]]
+		}
+		return indexes[a] < indexes[b]
+	})
+
+	if len(indexes) > maxMessages {
+		indexes = indexes[:maxMessages]
+	}
+
+	sort.Ints(indexes)
+
+	ids := make([]int, 0, len(indexes))
+	for _, m := range indexes {
+		ids = append(ids, doc.Messages[m].ID)
+	}
+
+	return ids
+}
+
+func (i *Index) add(session *dto.Session, version time.Time) {
+	doc := &document{
+		Version:   version,
+		Model:     session.Model,
+		UpdatedAt: session.UpdatedAt,
+	}
+
+	var contents []string
+
+	chat := session.Chat
+	if chat.System != "" {
+		doc.Messages = append(doc.Messages, indexMessage{Role: dto.RoleSystem})
+		contents = append(contents, chat.System)
+	}
+
+	for _, node := range chat.Nodes {
+		doc.Messages = append(doc.Messages, indexMessage{ID: node.ID, Role: node.Role, Model: node.Model, Time: node.Time})
+		contents = append(contents, node.Content)
+	}
+
+	for m, content := range contents {
+		positions := make(map[string][]int)
+		for pos, term := range terms(content) {
+			positions[term] = append(positions[term], pos)
+		}
+
+		for term, list := range positions {
+			i.data.Terms[term] = append(i.data.Terms[term], posting{Session: session.Name, Message: m, Positions: list})
+		}
+	}
+
+	i.data.Docs[session.Name] = doc
+}
+
+func (i *Index) remove(name string) {
+	if _, ok := i.data.Docs[name]; ok {
+		i.removeAll(map[string]bool{name: true})
+	}
+}
+
+// removeAll drops the sessions in one pass over the terms.
+func (i *Index) removeAll(names map[string]bool) {
+	if len(names) == 0 {
+		return
+	}
+
+	for name := range names {
+		delete(i.data.Docs, name)
+	}
+
+	for term, list := range i.data.Terms {
+		kept := list[:0]
+		for _, p := range list {
+			if !names[p.Session] {
+				kept = append(kept, p)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(i.data.Terms, term)
+		} else {
+			i.data.Terms[term] = kept
+		}
+	}
+}
+
+// load reads the index once, a missing, broken or outdated one starts empty and is rebuilt by Sync.
+func (i *Index) load() error {
+	if i.loaded {
+		return nil
+	}
+
+	i.data = indexData{Format: indexFormat, Docs: make(map[string]*document), Terms: make(map[string][]posting)}
+
+	data, err := os.ReadFile(i.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		i.loaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read search index: %w", err)
+	}
+
+	var stored indexData
+	if err = json.Unmarshal(data, &stored); err == nil && stored.Format == indexFormat && stored.Docs != nil && stored.Terms != nil {
+		i.data = stored
+	}
+
+	i.loaded = true
+
+	return nil
+}
+
+func (i *Index) save() error {
+	data, err := json.Marshal(i.data)
+	if err != nil {
+		return fmt.Errorf("marshal search index: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(i.path), 0o700); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+
+	if err = atomicfile.Write(i.path, data, 0o600); err != nil {
+		return fmt.Errorf("write search index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+package search
+
+type VectorsOption func(*Vectors)
+
+// WithEmbedReporter is told the number of the messages missing in the cache and their estimated tokens
+// before they are sent to be embedded.
+func WithEmbedReporter(report func(texts, tokens int)) VectorsOption {
+	return func(v *Vectors) {
+		v.report = report
+	}
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+// Query is a list of clauses a session must all contain, a clause is a word or a phrase of words.
+type Query struct {
+	Clauses [][]string
+}
+
+// ParseQuery reads the query from the arguments. A quoted part or an argument with spaces is a phrase,
+// e.g. `"consumer group" kafka` or the arguments "consumer group" and kafka.
+func ParseQuery(args []string) Query {
+	var q Query
+
+	add := func(text string, phrase bool) {
+		words := terms(text)
+		switch {
+		case len(words) == 0:
+		case phrase:
+			q.Clauses = append(q.Clauses, words)
+		default:
+			for _, word := range strings.Fields(text) {
+				// A word like max.poll.records is a phrase of its parts.
+				if parts := terms(word); len(parts) > 0 {
+					q.Clauses = append(q.Clauses, parts)
+				}
+			}
+		}
+	}
+
+	for _, arg := range args {
+		if !strings.Contains(arg, `"`) {
+			add(arg, strings.ContainsAny(strings.TrimSpace(arg), " \t\n"))
+			continue
+		}
+
+		for i, part := range strings.Split(arg, `"`) {
+			add(part, i%2 == 1)
+		}
+	}
+
+	return q
+}
+
+// Terms returns the words of all the clauses.
+func (q Query) Terms() []string {
+	var out []string
+	for _, clause := range q.Clauses {
+		out = append(out, clause...)
+	}
+
+	return out
+}
+
+// String returns the query with the phrases quoted.
+func (q Query) String() string {
+	parts := make([]string, 0, len(q.Clauses))
+	for _, clause := range q.Clauses {
+		if len(clause) > 1 {
+			parts = append(parts, `"`+strings.Join(clause, " ")+`"`)
+		} else {
+			parts = append(parts, clause[0])
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Filter narrows the messages the query is matched against, the zero value allows all of them.
+type Filter struct {
+	Roles []dto.Role
+	// Models match the model of the answers, or of the session for the other messages, by a part of the name.
+	Models []string
+	// Since and Until bound the time of the messages, or of the last update of the session
+	// for the messages without one. Until is exclusive.
+	Since, Until time.Time
+}
+
+func (f Filter) allows(role dto.Role, model string, at time.Time) bool {
+	if len(f.Roles) > 0 && !slices.Contains(f.Roles, role) {
+		return false
+	}
+
+	if len(f.Models) > 0 && !containsModel(f.Models, model) {
+		return false
+	}
+
+	if !f.Since.IsZero() && at.Before(f.Since) {
+		return false
+	}
+
+	return f.Until.IsZero() || at.Before(f.Until)
+}
+
+func containsModel(models []string, model string) bool {
+	model = strings.ToLower(model)
+	for _, m := range models {
+		if m != "" && strings.Contains(model, strings.ToLower(m)) {
+			return true
+		}
+	}
+
+	return false
+}
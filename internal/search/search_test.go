@@ -0,0 +1,243 @@
+package search_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/mock"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/search"
+	"github.com/andrian0vv/chatgpt-cli/internal/sessions"
+)
+
+var day = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newSession(name, model, system string, at time.Time, messages ...dto.Message) *dto.Session {
+	chat := dto.NewChat()
+	chat.System = system
+	for _, m := range messages {
+		chat.Add(m)
+		chat.Nodes[len(chat.Nodes)-1].Time = at
+	}
+
+	return &dto.Session{Name: name, Model: model, Chat: chat, UpdatedAt: at}
+}
+
+// newStore saves the sessions into a store indexing them.
+func newStore(t *testing.T, list ...*dto.Session) (*sessions.Store, *search.Index, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	index := search.New(dir)
+	store := sessions.New(dir, sessions.WithIndex(index))
+
+	for _, s := range list {
+		assert.NoError(t, store.Put(s))
+	}
+
+	return store, index, dir
+}
+
+func names(results []search.Result) []string {
+	var out []string
+	for _, r := range results {
+		out = append(out, r.Session)
+	}
+
+	return out
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want [][]string
+	}{
+		{name: "words", args: []string{"Kafka", "consumer"}, want: [][]string{{"kafka"}, {"consumer"}}},
+		{name: "quoted phrase", args: []string{`"consumer group" kafka`}, want: [][]string{{"consumer", "group"}, {"kafka"}}},
+		{name: "argument with spaces", args: []string{"consumer group", "kafka"}, want: [][]string{{"consumer", "group"}, {"kafka"}}},
+		{name: "dotted word", args: []string{"max.poll.records"}, want: [][]string{{"max", "poll", "records"}}},
+		{name: "punctuation only", args: []string{"?!", `""`}, want: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, search.ParseQuery(tc.args).Clauses)
+		})
+	}
+
+	assert.Equal(t, `"consumer group" kafka`, search.ParseQuery([]string{"consumer group", "kafka"}).String())
+}
+
+func TestIndex_Search(t *testing.T) {
+	_, index, _ := newStore(t,
+		newSession("kafka", "gpt-4o", "You are a Kafka expert.", day,
+			dto.Message{Role: dto.RoleUser, Content: "How do I size a consumer group?"},
+			dto.Message{Role: dto.RoleAssistant, Content: "A consumer group scales up to the number of partitions.", Model: "gpt-4o"},
+		),
+		newSession("lisbon", "gpt-4o-mini", "", day.AddDate(0, 1, 0),
+			dto.Message{Role: dto.RoleUser, Content: "Plan a trip to Lisbon, I like the group tours."},
+			dto.Message{Role: dto.RoleAssistant, Content: "Day one: Alfama with a group of locals.", Model: "gpt-4o-mini"},
+		),
+	)
+
+	tests := []struct {
+		name     string
+		args     []string
+		filter   search.Filter
+		want     []string
+		messages []int
+	}{
+		{name: "word in both", args: []string{"group"}, want: []string{"kafka", "lisbon"}},
+		{name: "all words", args: []string{"group", "partitions"}, want: []string{"kafka"}, messages: []int{1, 2}},
+		{name: "phrase", args: []string{`"consumer group"`}, want: []string{"kafka"}, messages: []int{1, 2}},
+		{name: "phrase not in a row", args: []string{`"group consumer"`}},
+		{name: "system prompt", args: []string{"expert"}, want: []string{"kafka"}, messages: []int{0}},
+		{name: "role", args: []string{"partitions"}, filter: search.Filter{Roles: []dto.Role{dto.RoleUser}}},
+		{
+			name: "model", args: []string{"group"},
+			filter: search.Filter{Models: []string{"MINI"}}, want: []string{"lisbon"},
+		},
+		{
+			name: "since", args: []string{"group"},
+			filter: search.Filter{Since: day.AddDate(0, 0, 1)}, want: []string{"lisbon"},
+		},
+		{
+			name: "until", args: []string{"group"},
+			filter: search.Filter{Until: day.AddDate(0, 0, 1)}, want: []string{"kafka"},
+		},
+		{name: "unknown word", args: []string{"group", "zebra"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := index.Search(search.ParseQuery(tc.args), tc.filter, 10)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tc.want, names(results))
+
+			if tc.messages != nil {
+				assert.Equal(t, tc.messages, results[0].Messages)
+			}
+		})
+	}
+}
+
+func TestIndex_Ranking(t *testing.T) {
+	_, index, _ := newStore(t,
+		newSession("once", "gpt-4o", "", day, dto.Message{Role: dto.RoleUser, Content: "kafka"}),
+		newSession("often", "gpt-4o", "", day, dto.Message{Role: dto.RoleUser, Content: "kafka kafka kafka"}),
+	)
+
+	results, err := index.Search(search.ParseQuery([]string{"kafka"}), search.Filter{}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"often", "once"}, names(results))
+
+	results, err = index.Search(search.ParseQuery([]string{"kafka"}), search.Filter{}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"often"}, names(results))
+}
+
+func TestIndex_Sync(t *testing.T) {
+	store, _, dir := newStore(t,
+		newSession("kafka", "gpt-4o", "", day, dto.Message{Role: dto.RoleUser, Content: "consumer group"}),
+		newSession("lisbon", "gpt-4o", "", day, dto.Message{Role: dto.RoleUser, Content: "group tours"}),
+	)
+
+	// A session deleted, one written by hand and one replaced, bypassing the index.
+	assert.NoError(t, os.Remove(filepath.Join(dir, "sessions", "lisbon.json")))
+	assert.NoError(t, sessions.New(dir).Put(
+		newSession("porto", "gpt-4o", "", day, dto.Message{Role: dto.RoleUser, Content: "group dinner"}),
+	))
+	assert.NoError(t, sessions.New(dir).Put(
+		newSession("kafka", "gpt-4o", "", day, dto.Message{Role: dto.RoleUser, Content: "consumer lag"}),
+	))
+
+	index := search.New(dir)
+	assert.NoError(t, index.Sync(store))
+
+	results, err := index.Search(search.ParseQuery([]string{"group"}), search.Filter{}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"porto"}, names(results))
+
+	results, err = index.Search(search.ParseQuery([]string{"consumer"}), search.Filter{}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kafka"}, names(results))
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		words   []string
+		width   int
+		want    []search.Segment
+	}{
+		{
+			name:    "whole content",
+			content: "A consumer\ngroup   scales.",
+			words:   []string{"group"},
+			width:   100,
+			want:    []search.Segment{{Text: "A consumer "}, {Text: "group", Match: true}, {Text: " scales."}},
+		},
+		{
+			name:    "no match",
+			content: "one two three four five",
+			words:   []string{"zebra"},
+			width:   10,
+			want:    []search.Segment{{Text: "one two"}, {Text: "…"}},
+		},
+		{
+			name:    "match in the middle",
+			content: "one two three four five six seven eight nine ten",
+			words:   []string{"seven"},
+			width:   15,
+			want: []search.Segment{
+				{Text: "…"}, {Text: "six "}, {Text: "seven", Match: true}, {Text: " eight"}, {Text: "…"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, search.Snippet(tc.content, tc.words, tc.width))
+		})
+	}
+}
+
+func TestSemantic(t *testing.T) {
+	list := []*dto.Session{
+		newSession("kafka", "gpt-4o", "", day,
+			dto.Message{Role: dto.RoleUser, Content: "kafka consumer group partitions"},
+		),
+		newSession("lisbon", "gpt-4o", "", day.AddDate(0, 1, 0),
+			dto.Message{Role: dto.RoleUser, Content: "trip to lisbon tours"},
+		),
+	}
+
+	dir := t.TempDir()
+	client := mock.New(logger.New(nil, logger.WithEnabled(false)))
+
+	var embedded []int
+	report := search.WithEmbedReporter(func(texts, _ int) {
+		embedded = append(embedded, texts)
+	})
+
+	results, err := search.Semantic(context.Background(), client, search.NewVectors(dir, report), list, "lisbon tours", search.Filter{}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lisbon", "kafka"}, names(results))
+	assert.Equal(t, []int{1}, results[0].Messages)
+	assert.FileExists(t, filepath.Join(dir, "search_vectors.json"))
+
+	// The cached messages are not embedded again.
+	results, err = search.Semantic(context.Background(), client, search.NewVectors(dir, report), list, "lisbon tours",
+		search.Filter{Until: day.AddDate(0, 0, 1)}, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kafka"}, names(results))
+	assert.Equal(t, []int{2}, embedded)
+}
@@ -0,0 +1,288 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+)
+
+const (
+	vectorsFile = "search_vectors.json"
+	// embedBatch is the number of texts sent in one embeddings request.
+	embedBatch = 100
+	// maxEmbedLength bounds the bytes of a message sent for its embedding, about the input limit of the models.
+	maxEmbedLength = 16000
+)
+
+type embedder interface {
+	CreateEmbeddings(ctx context.Context, texts []string) (dto.Embeddings, error)
+}
+
+// Vectors caches the embeddings of the messages by the model and the text,
+// so a message is sent to the provider once.
+type Vectors struct {
+	path    string
+	loaded  bool
+	changed bool
+	// vectors are base64 little-endian float32 values, a JSON array of numbers is several times larger.
+	vectors map[string]string
+	report  func(texts, tokens int)
+}
+
+// NewVectors creates the cache kept in the data directory.
+func NewVectors(dataDir string, opts ...VectorsOption) *Vectors {
+	v := &Vectors{
+		path:   filepath.Join(dataDir, vectorsFile),
+		report: func(int, int) {},
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// candidate is a message the filter allows with its place in the sessions.
+type candidate struct {
+	session int
+	id      int
+	key     string
+	text    string
+}
+
+// Semantic returns the sessions with a message the closest in meaning to the query, the closest first.
+// The messages without a cached embedding are sent to the provider.
+func Semantic(ctx context.Context, embedder embedder, vectors *Vectors, sessions []*dto.Session, query string, f Filter, limit int) ([]Result, error) {
+	if err := vectors.load(); err != nil {
+		return nil, err
+	}
+
+	q, err := embedder.CreateEmbeddings(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Vectors) != 1 {
+		return nil, errors.New("create embeddings: no vector for the query")
+	}
+
+	var candidates []candidate
+	for s, session := range sessions {
+		doc := &document{Model: session.Model, UpdatedAt: session.UpdatedAt}
+
+		add := func(m indexMessage, content string) {
+			if content == "" || !allows(f, doc, m) {
+				return
+			}
+
+			if len(content) > maxEmbedLength {
+				content = content[:runeStart(content, maxEmbedLength)]
+			}
+
+			candidates = append(candidates, candidate{session: s, id: m.ID, key: vectorKey(q.Model, content), text: content})
+		}
+
+		add(indexMessage{Role: dto.RoleSystem}, session.Chat.System)
+		for _, node := range session.Chat.Nodes {
+			add(indexMessage{ID: node.ID, Role: node.Role, Model: node.Model, Time: node.Time}, node.Content)
+		}
+	}
+
+	// Save the embeddings made before a failure, they are paid for.
+	err = vectors.fill(ctx, embedder, candidates)
+	if saveErr := vectors.save(); err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	best := make(map[int]Result)
+	for _, c := range candidates {
+		score := cosine(q.Vectors[0], vectors.get(c.key))
+		if r, ok := best[c.session]; ok && r.Score >= score {
+			continue
+		}
+
+		session := sessions[c.session]
+		best[c.session] = Result{
+			Session:   session.Name,
+			Model:     session.Model,
+			UpdatedAt: session.UpdatedAt,
+			Score:     score,
+			Messages:  []int{c.id},
+		}
+	}
+
+	results := make([]Result, 0, len(best))
+	for _, r := range best {
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		return results[a].Session < results[b].Session
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// fill embeds the texts of the candidates missing in the cache in batches.
+func (v *Vectors) fill(ctx context.Context, embedder embedder, candidates []candidate) error {
+	var (
+		keys  []string
+		texts []string
+		seen  = make(map[string]bool)
+	)
+
+	for _, c := range candidates {
+		if _, ok := v.vectors[c.key]; ok || seen[c.key] {
+			continue
+		}
+
+		seen[c.key] = true
+		keys = append(keys, c.key)
+		texts = append(texts, c.text)
+	}
+
+	if len(texts) > 0 {
+		estimate := 0
+		for _, text := range texts {
+			estimate += tokens.Estimate(text)
+		}
+		v.report(len(texts), estimate)
+	}
+
+	for start := 0; start < len(texts); start += embedBatch {
+		end := min(start+embedBatch, len(texts))
+
+		out, err := embedder.CreateEmbeddings(ctx, texts[start:end])
+		if err != nil {
+			return err
+		}
+
+		for i, vector := range out.Vectors {
+			v.put(keys[start+i], vector)
+		}
+	}
+
+	return nil
+}
+
+func (v *Vectors) get(key string) []float32 {
+	data, err := base64.StdEncoding.DecodeString(v.vectors[key])
+	if err != nil {
+		return nil
+	}
+
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+
+	return vector
+}
+
+func (v *Vectors) put(key string, vector []float32) {
+	data := make([]byte, len(vector)*4)
+	for i, x := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(x))
+	}
+
+	v.vectors[key] = base64.StdEncoding.EncodeToString(data)
+	v.changed = true
+}
+
+func (v *Vectors) load() error {
+	if v.loaded {
+		return nil
+	}
+
+	v.vectors = make(map[string]string)
+
+	data, err := os.ReadFile(v.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		v.loaded = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read search vectors: %w", err)
+	}
+
+	// A broken cache is only a loss of money, it is rebuilt.
+	_ = json.Unmarshal(data, &v.vectors)
+	if v.vectors == nil {
+		v.vectors = make(map[string]string)
+	}
+
+	v.loaded = true
+
+	return nil
+}
+
+func (v *Vectors) save() error {
+	if !v.changed {
+		return nil
+	}
+
+	data, err := json.Marshal(v.vectors)
+	if err != nil {
+		return fmt.Errorf("marshal search vectors: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+
+	if err = atomicfile.Write(v.path, data, 0o600); err != nil {
+		return fmt.Errorf("write search vectors: %w", err)
+	}
+
+	v.changed = false
+
+	return nil
+}
+
+func vectorKey(model, text string) string {
+	sum := sha256.Sum256([]byte(model + "\n" + text))
+	return hex.EncodeToString(sum[:16])
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+
+	if na == 0 || nb == 0 {
+		return 0
+	}
+
+	return dot / math.Sqrt(na*nb)
+}
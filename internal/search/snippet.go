@@ -0,0 +1,86 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+const ellipsis = "…"
+
+var spaces = regexp.MustCompile(`\s+`)
+
+// Segment is a part of a snippet, Match marks the words of the query to highlight.
+type Segment struct {
+	Text  string
+	Match bool
+}
+
+// Snippet returns about width bytes of the content around the first word of the query on one line,
+// or the beginning of the content without one.
+func Snippet(content string, words []string, width int) []Segment {
+	wanted := make(map[string]bool, len(words))
+	for _, w := range words {
+		wanted[w] = true
+	}
+
+	tokens := tokenize(content)
+
+	start := 0
+	for _, t := range tokens {
+		if wanted[t.term] {
+			// Some context before the match, starting with a whole word.
+			start = runeStart(content, max(0, t.start-width/3))
+			if i := strings.IndexAny(content[start:t.start], " \t\n"); start > 0 && i >= 0 {
+				start += i + 1
+			}
+			break
+		}
+	}
+
+	end := len(content)
+	if start+width < end {
+		end = runeStart(content, start+width)
+		// Cut before the last word unless it ends right at the width.
+		if i := strings.LastIndexAny(content[start:end], " \t\n"); i > 0 && !isSpace(rune(content[end])) {
+			end = start + i
+		}
+	}
+
+	var segments []Segment
+	add := func(text string, match bool) {
+		text = spaces.ReplaceAllString(text, " ")
+		if text != "" {
+			segments = append(segments, Segment{Text: text, Match: match})
+		}
+	}
+
+	if start > 0 {
+		add(ellipsis, false)
+	}
+
+	pos := start
+	for pos < end && isSpace(rune(content[pos])) {
+		pos++
+	}
+
+	for _, t := range tokens {
+		if t.start < start || t.end > end || !wanted[t.term] {
+			continue
+		}
+
+		add(content[pos:t.start], false)
+		add(content[t.start:t.end], true)
+		pos = t.end
+	}
+	add(strings.TrimRightFunc(content[pos:end], isSpace), false)
+
+	if end < len(content) {
+		add(ellipsis, false)
+	}
+
+	return segments
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
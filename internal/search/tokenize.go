@@ -0,0 +1,60 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token is a lowercased word of a text with its byte offsets in the text.
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits the text into words of letters and digits, the rest separates them.
+func tokenize(text string) []token {
+	var (
+		tokens []token
+		start  = -1
+	)
+
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+
+	return tokens
+}
+
+// terms returns the words of the text.
+func terms(text string) []string {
+	tokens := tokenize(text)
+
+	out := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		out = append(out, t.term)
+	}
+
+	return out
+}
+
+// runeStart moves the offset back to the start of the rune it points into.
+func runeStart(text string, i int) int {
+	for i > 0 && i < len(text) && !utf8.RuneStart(text[i]) {
+		i--
+	}
+
+	return i
+}
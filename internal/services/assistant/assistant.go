@@ -6,35 +6,79 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/andrian0vv/chatgpt-cli/internal/dto"
 	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/redact"
+	"github.com/andrian0vv/chatgpt-cli/internal/stream"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
 )
 
 type client interface {
 	Model() string
-	CreateChatCompletion(ctx context.Context, chat *dto.Chat) (string, error)
-	ModelExists(ctx context.Context) (bool, error)
+	SetModel(model string)
+	Params() dto.Params
+	CreateChatCompletion(ctx context.Context, chat *dto.Chat) (dto.Completion, error)
 	GetModels(ctx context.Context) ([]string, error)
 }
 
+type recorder interface {
+	Record(record dto.UsageRecord) error
+}
+
+type budget interface {
+	Reserve(model string, promptTokens int) (func(actual dto.Usage), error)
+}
+
+type catalog interface {
+	List(ctx context.Context) ([]dto.Model, error)
+}
+
+type responseCache interface {
+	Cached(ctx context.Context, chat *dto.Chat) (dto.Completion, bool)
+}
+
+type redactor interface {
+	Chat(chat *dto.Chat) *dto.Chat
+	Restore(text string) string
+}
+
+// ErrUnknownModel is returned for a model missing from the list of the available models.
+var ErrUnknownModel = errors.New("unknown model")
+
 // Assistant is a service that provides an interface to interact with the AI assistant.
 type Assistant struct {
-	client client
-	log    *logger.Logger
+	client   client
+	log      *logger.Logger
+	recorder recorder
+	budget   budget
+	catalog  catalog
+	redactor redactor
+	cache    responseCache
+	profile  string
+
+	mu sync.Mutex
+	// known are the models that answered or were found in the list, they are not checked again.
+	known map[string]bool
 }
 
-func New(ctx context.Context, client client, log *logger.Logger) (*Assistant, error) {
+// New creates the assistant without any request, the model is only checked when a request fails.
+func New(client client, log *logger.Logger, opts ...Option) *Assistant {
 	a := &Assistant{
 		client: client,
 		log:    log,
+		known:  make(map[string]bool),
 	}
 
-	if err := a.validateModel(ctx); err != nil {
-		return nil, fmt.Errorf("validate model: %w", err)
+	for _, opt := range opts {
+		opt(a)
 	}
 
-	return a, nil
+	return a
 }
 
 // Model returns the current model name.
@@ -42,14 +86,44 @@ func (a *Assistant) Model() string {
 	return a.client.Model()
 }
 
-// GetModels returns a list of available models.
+// SetModel switches the assistant to another model after checking that it exists.
+func (a *Assistant) SetModel(ctx context.Context, model string) error {
+	if err := a.validateModel(ctx, model); err != nil {
+		return fmt.Errorf("validate model: %w", err)
+	}
+
+	a.client.SetModel(model)
+
+	return nil
+}
+
+// Params returns the default sampling parameters, the chat parameters override them.
+func (a *Assistant) Params() dto.Params {
+	return a.client.Params()
+}
+
+// GetModels returns a list of available models, from the catalog if the assistant has one.
 func (a *Assistant) GetModels(ctx context.Context) ([]string, error) {
-	m, err := a.client.GetModels(ctx)
+	if a.catalog == nil {
+		m, err := a.client.GetModels(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get models: %w", err)
+		}
+
+		return m, nil
+	}
+
+	models, err := a.catalog.List(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("get models: %w", err)
 	}
 
-	return m, nil
+	ids := make([]string, 0, len(models))
+	for _, model := range models {
+		ids = append(ids, model.ID)
+	}
+
+	return ids, nil
 }
 
 // SendMessage sends a message to the AI assistant and returns the response.
@@ -69,26 +143,268 @@ func (a *Assistant) SendChatMessage(ctx context.Context, chat *dto.Chat, questio
 
 	chat.AddMessage(dto.RoleUser, question)
 
-	answer, err := a.client.CreateChatCompletion(ctx, chat)
+	answer, err := a.complete(ctx, chat)
 	if err != nil {
-		return "", fmt.Errorf("create chat completion: %w", err)
+		// Keep the chat as it was so the question can be sent again.
+		chat.Rollback()
+		return "", err
 	}
 
-	chat.AddMessage(dto.RoleAssistant, answer)
-
 	return answer, nil
 }
 
-// validateModel checks if the current model exists.
-func (a *Assistant) validateModel(ctx context.Context) error {
-	exists, err := a.client.ModelExists(ctx)
+// Regenerate requests a new answer to the last question of the chat.
+// The answer is added as a new branch next to the previous answers.
+func (a *Assistant) Regenerate(ctx context.Context, chat *dto.Chat) (string, error) {
+	last, ok := chat.LastMessage()
+	if !ok || last.Role != dto.RoleUser {
+		return "", errors.New("the chat does not end with a question")
+	}
+
+	return a.complete(ctx, chat)
+}
+
+// Alternatives requests n answers to the last question of the chat in one request.
+// None of them is added to the chat, Pick adds the chosen one.
+func (a *Assistant) Alternatives(ctx context.Context, chat *dto.Chat, n int) (dto.Completion, error) {
+	last, ok := chat.LastMessage()
+	if !ok || last.Role != dto.RoleUser {
+		return dto.Completion{}, errors.New("the chat does not end with a question")
+	}
+
+	if n < 1 {
+		return dto.Completion{}, fmt.Errorf("the number of answers must be positive, got %d", n)
+	}
+
+	// The number of answers is set on a copy so it does not stick to the chat.
+	request := *chat
+	request.Params = chat.Params.Clone()
+	request.Params.N = n
+
+	completion, err := a.request(ctx, &request)
 	if err != nil {
-		return fmt.Errorf("model exists: %w", err)
+		return dto.Completion{}, err
+	}
+
+	if len(completion.Choices) == 0 {
+		completion.Choices = []dto.Choice{{Content: completion.Content, FinishReason: completion.FinishReason, ToolCalls: completion.ToolCalls}}
+	}
+
+	return completion, nil
+}
+
+// Complete sends the chat as it is within the budget and records the usage, the answer is not added to the chat.
+// It relays the requests of other tools, e.g. the ones sent to the proxy.
+func (a *Assistant) Complete(ctx context.Context, chat *dto.Chat) (dto.Completion, error) {
+	if _, ok := chat.LastMessage(); !ok {
+		return dto.Completion{}, errors.New("the chat has no messages")
 	}
 
-	if !exists {
-		return fmt.Errorf("model %s does not exist", a.client.Model())
+	return a.request(ctx, chat)
+}
+
+// Pick adds the i-th answer of the completion to the chat, the usage of the request goes with it.
+func (a *Assistant) Pick(chat *dto.Chat, completion dto.Completion, i int) error {
+	if i < 0 || i >= len(completion.Choices) {
+		return fmt.Errorf("there is no answer %d, pick one from 1 to %d", i+1, len(completion.Choices))
 	}
 
+	completion.Content, completion.ToolCalls = completion.Choices[i].Content, completion.Choices[i].ToolCalls
+	chat.Add(toAnswer(completion))
+
 	return nil
 }
+
+func (a *Assistant) complete(ctx context.Context, chat *dto.Chat) (string, error) {
+	completion, err := a.request(ctx, chat)
+	if err != nil {
+		return "", err
+	}
+
+	chat.Add(toAnswer(completion))
+
+	return completion.Content, nil
+}
+
+// request sends the chat within the budget and records the usage.
+func (a *Assistant) request(ctx context.Context, chat *dto.Chat) (dto.Completion, error) {
+	// The chat keeps the messages as they were typed, only the request is redacted.
+	redactor := a.redactorOf(ctx)
+
+	var r *restorer
+	if redactor != nil {
+		chat = redactor.Chat(chat)
+
+		// The streamed pieces are restored too.
+		if handler, ok := stream.FromContext(ctx); ok {
+			r = &restorer{restore: redactor.Restore, handler: handler}
+			ctx = stream.WithHandler(ctx, r.write)
+		}
+	}
+
+	// A cached answer is free, so it is looked up before the budget can refuse the request.
+	completion, cached := dto.Completion{}, false
+	if a.cache != nil {
+		completion, cached = a.cache.Cached(ctx, chat)
+	}
+
+	if !cached {
+		var err error
+		if completion, err = a.send(ctx, chat); err != nil {
+			return dto.Completion{}, err
+		}
+	}
+
+	if r != nil {
+		r.flush()
+	}
+
+	if redactor != nil {
+		completion.Content = redactor.Restore(completion.Content)
+		for i := range completion.Choices {
+			completion.Choices[i].Content = redactor.Restore(completion.Choices[i].Content)
+		}
+	}
+
+	return completion, nil
+}
+
+// redactorOf returns the redactor of the request, the one set in the context replaces the one of the assistant.
+func (a *Assistant) redactorOf(ctx context.Context) redactor {
+	if r, ok := redact.FromContext(ctx); ok {
+		return r
+	}
+
+	return a.redactor
+}
+
+// send sends the chat within the budget and records the usage.
+func (a *Assistant) send(ctx context.Context, chat *dto.Chat) (dto.Completion, error) {
+	release, err := a.reserve(chat)
+	if err != nil {
+		return dto.Completion{}, err
+	}
+
+	completion, err := a.client.CreateChatCompletion(ctx, chat)
+	if completion.Cached {
+		release(dto.Usage{})
+	} else {
+		release(completion.Usage)
+	}
+	if err != nil {
+		return dto.Completion{}, a.checkModel(ctx, a.modelOf(chat), fmt.Errorf("create chat completion: %w", err))
+	}
+
+	a.markKnown(a.modelOf(chat))
+
+	// Nothing is spent on a cached answer.
+	if !completion.Cached {
+		a.record(ctx, completion)
+	}
+
+	return completion, nil
+}
+
+func toAnswer(completion dto.Completion) dto.Message {
+	answer := dto.Message{
+		Role:      dto.RoleAssistant,
+		Content:   completion.Content,
+		Model:     completion.Model,
+		ToolCalls: completion.ToolCalls,
+	}
+
+	if !completion.Cached && !completion.Usage.IsZero() {
+		answer.Usage = &completion.Usage
+	}
+
+	return answer
+}
+
+// reserve checks the request against the budget before it is sent.
+func (a *Assistant) reserve(chat *dto.Chat) (func(actual dto.Usage), error) {
+	if a.budget == nil {
+		return func(dto.Usage) {}, nil
+	}
+
+	release, err := a.budget.Reserve(a.modelOf(chat), tokens.EstimateMessages(chat.Sent()))
+	if err != nil {
+		return nil, fmt.Errorf("check budget: %w", err)
+	}
+
+	return release, nil
+}
+
+// record writes the usage of the completion to the ledger, a failure only gets logged.
+func (a *Assistant) record(ctx context.Context, completion dto.Completion) {
+	if a.recorder == nil || completion.Usage.IsZero() {
+		return
+	}
+
+	err := a.recorder.Record(dto.UsageRecord{
+		Time:    time.Now(),
+		Model:   completion.Model,
+		Session: usage.SessionFromContext(ctx),
+		Profile: a.profile,
+		Usage:   completion.Usage,
+	})
+	if err != nil {
+		a.log.Warn("record usage", logger.WithError(err))
+	}
+}
+
+// modelOf returns the model the chat is sent to.
+func (a *Assistant) modelOf(chat *dto.Chat) string {
+	if chat.Model != "" {
+		return chat.Model
+	}
+
+	return a.client.Model()
+}
+
+// checkModel explains a failed request with an unknown model. A model is only looked up
+// after its first failure, so a working model costs no requests.
+func (a *Assistant) checkModel(ctx context.Context, model string, err error) error {
+	if ctx.Err() != nil || a.isKnown(model) {
+		return err
+	}
+
+	if validateErr := a.validateModel(ctx, model); errors.Is(validateErr, ErrUnknownModel) {
+		return fmt.Errorf("%w: %w", validateErr, err)
+	}
+
+	return err
+}
+
+// validateModel checks that the model is in the list of the available models.
+func (a *Assistant) validateModel(ctx context.Context, model string) error {
+	if a.isKnown(model) {
+		return nil
+	}
+
+	models, err := a.GetModels(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(models, model) {
+		return fmt.Errorf("%w %s, see the models command for the available ones", ErrUnknownModel, model)
+	}
+
+	a.markKnown(model)
+
+	return nil
+}
+
+func (a *Assistant) isKnown(model string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.known[model]
+}
+
+func (a *Assistant) markKnown(model string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.known[model] = true
+}
@@ -4,57 +4,82 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
 	"github.com/andrian0vv/chatgpt-cli/internal/dto"
 	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/redact"
 	"github.com/andrian0vv/chatgpt-cli/internal/services/assistant"
 	"github.com/andrian0vv/chatgpt-cli/internal/services/assistant/mocks"
+	"github.com/andrian0vv/chatgpt-cli/internal/stream"
 )
 
-func TestAssistant_New(t *testing.T) {
+func TestAssistant_ValidateModel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
 	l := logger.New(nil, logger.WithEnabled(false))
 
+	model := "gpt-4o"
+
+	// Creating the assistant and a working request make no other requests.
 	client := mocks.NewMockclient(ctrl)
-	client.EXPECT().Model().Return("test-model").AnyTimes()
+	client.EXPECT().Model().DoAndReturn(func() string { return model }).AnyTimes()
+	client.EXPECT().SetModel(gomock.Any()).Do(func(m string) { model = m }).AnyTimes()
 
-	client.EXPECT().
-		ModelExists(ctx).
-		Return(true, nil)
-	_, err := assistant.New(ctx, client, l)
+	catalog := mocks.NewMockcatalog(ctrl)
+
+	a := assistant.New(client, l, assistant.WithCatalog(catalog))
+
+	apiErr := errors.New("API error")
+
+	gomock.InOrder(
+		client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Hi"}, nil),
+		// A model that has answered is not checked after an error.
+		client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{}, apiErr),
+		// The model is switched after finding it in the catalog.
+		catalog.EXPECT().List(gomock.Any()).Return([]dto.Model{{ID: "gpt-4o"}, {ID: "o3"}}, nil),
+		// An unknown model is only looked up after it fails.
+		client.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{}, apiErr),
+		catalog.EXPECT().List(gomock.Any()).Return([]dto.Model{{ID: "gpt-4o"}, {ID: "o3"}}, nil),
+		catalog.EXPECT().List(gomock.Any()).Return([]dto.Model{{ID: "gpt-4o"}, {ID: "o3"}}, nil),
+	)
+
+	_, err := a.SendMessage(ctx, "Hello")
 	assert.NoError(t, err)
 
-	client.EXPECT().
-		ModelExists(ctx).
-		Return(false, nil)
-	_, err = assistant.New(ctx, client, l)
-	assert.Error(t, err)
+	_, err = a.SendMessage(ctx, "Hello")
+	assert.ErrorIs(t, err, apiErr)
+	assert.NotErrorIs(t, err, assistant.ErrUnknownModel)
 
-	client.EXPECT().
-		ModelExists(ctx).
-		Return(false, errors.New("some error"))
-	_, err = assistant.New(ctx, client, l)
-	assert.Error(t, err)
-	assert.ErrorContains(t, err, "some error")
+	assert.NoError(t, a.SetModel(ctx, "o3"))
+	assert.Equal(t, "o3", a.Model())
+
+	chat := dto.NewChat()
+	chat.Model = "gpt-5"
+
+	_, err = a.SendChatMessage(ctx, chat, "Hello")
+	assert.ErrorIs(t, err, assistant.ErrUnknownModel)
+	assert.ErrorIs(t, err, apiErr)
+
+	assert.ErrorIs(t, a.SetModel(ctx, "gpt-5"), assistant.ErrUnknownModel)
+	assert.Equal(t, "o3", a.Model())
 }
 
 func TestAssistant_Model(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ctx := context.Background()
 	l := logger.New(nil, logger.WithEnabled(false))
 
-	client := newMockClient(ctrl)
+	client := mocks.NewMockclient(ctrl)
 
-	a, err := assistant.New(ctx, client, l)
-	assert.NoError(t, err)
+	a := assistant.New(client, l)
 
 	expected := "test-model"
 	client.EXPECT().
@@ -105,8 +130,7 @@ func TestAssistant_GetModels(t *testing.T) {
 			ctx := context.Background()
 			l := logger.New(nil, logger.WithEnabled(false))
 
-			a, err := assistant.New(ctx, tc.clientFn(ctrl), l)
-			assert.NoError(t, err)
+			a := assistant.New(tc.clientFn(ctrl), l)
 
 			models, err := a.GetModels(ctx)
 			assert.Equal(t, tc.expected, models)
@@ -135,18 +159,16 @@ func TestAssistant_SendChatMessage(t *testing.T) {
 				c := newMockClient(ctrl)
 				c.EXPECT().
 					CreateChatCompletion(gomock.Any(), gomock.Any()).
-					Return("Hi there!", nil)
+					Return(dto.Completion{Content: "Hi there!"}, nil)
 				return c
 			},
 			in:     "Hello",
 			out:    "Hi there!",
 			inChat: dto.NewChat(),
-			outChat: &dto.Chat{
-				Messages: []dto.Message{
-					{Role: dto.RoleUser, Content: "Hello"},
-					{Role: dto.RoleAssistant, Content: "Hi there!"},
-				},
-			},
+			outChat: newChat(
+				dto.Message{Role: dto.RoleUser, Content: "Hello"},
+				dto.Message{Role: dto.RoleAssistant, Content: "Hi there!"},
+			),
 		},
 		{
 			name: "ok with the existing chat",
@@ -154,25 +176,21 @@ func TestAssistant_SendChatMessage(t *testing.T) {
 				c := newMockClient(ctrl)
 				c.EXPECT().
 					CreateChatCompletion(gomock.Any(), gomock.Any()).
-					Return("I can't provide real-time weather updates or current conditions", nil)
+					Return(dto.Completion{Content: "I can't provide real-time weather updates or current conditions"}, nil)
 				return c
 			},
 			in:  "What is the weather in Lisbon?",
 			out: "I can't provide real-time weather updates or current conditions",
-			inChat: &dto.Chat{
-				Messages: []dto.Message{
-					{Role: dto.RoleUser, Content: "Hello"},
-					{Role: dto.RoleAssistant, Content: "Hi there!"},
-				},
-			},
-			outChat: &dto.Chat{
-				Messages: []dto.Message{
-					{Role: dto.RoleUser, Content: "Hello"},
-					{Role: dto.RoleAssistant, Content: "Hi there!"},
-					{Role: dto.RoleUser, Content: "What is the weather in Lisbon?"},
-					{Role: dto.RoleAssistant, Content: "I can't provide real-time weather updates or current conditions"},
-				},
-			},
+			inChat: newChat(
+				dto.Message{Role: dto.RoleUser, Content: "Hello"},
+				dto.Message{Role: dto.RoleAssistant, Content: "Hi there!"},
+			),
+			outChat: newChat(
+				dto.Message{Role: dto.RoleUser, Content: "Hello"},
+				dto.Message{Role: dto.RoleAssistant, Content: "Hi there!"},
+				dto.Message{Role: dto.RoleUser, Content: "What is the weather in Lisbon?"},
+				dto.Message{Role: dto.RoleAssistant, Content: "I can't provide real-time weather updates or current conditions"},
+			),
 		},
 		{
 			name: "error",
@@ -180,7 +198,10 @@ func TestAssistant_SendChatMessage(t *testing.T) {
 				c := newMockClient(ctrl)
 				c.EXPECT().
 					CreateChatCompletion(gomock.Any(), gomock.Any()).
-					Return("", errors.New("API error"))
+					Return(dto.Completion{}, errors.New("API error"))
+				c.EXPECT().
+					GetModels(gomock.Any()).
+					Return([]string{"gpt-4o"}, nil)
 				return c
 			},
 			inChat:  dto.NewChat(),
@@ -194,7 +215,7 @@ func TestAssistant_SendChatMessage(t *testing.T) {
 				c := newMockClient(ctrl)
 				c.EXPECT().
 					CreateChatCompletion(gomock.Any(), gomock.Any()).
-					Return("Hi there!", nil)
+					Return(dto.Completion{Content: "Hi there!"}, nil)
 				return c
 			},
 			in:  "How are you?",
@@ -218,8 +239,7 @@ func TestAssistant_SendChatMessage(t *testing.T) {
 			ctx := context.Background()
 			l := logger.New(nil, logger.WithEnabled(false))
 
-			a, err := assistant.New(ctx, tc.clientFn(ctrl), l)
-			assert.NoError(t, err)
+			a := assistant.New(tc.clientFn(ctrl), l)
 
 			out, err := a.SendChatMessage(ctx, tc.inChat, tc.in)
 			assert.Equal(t, tc.out, out)
@@ -230,17 +250,299 @@ func TestAssistant_SendChatMessage(t *testing.T) {
 				assert.NoError(t, err)
 
 				if tc.inChat != nil {
-					assert.Equal(t, tc.outChat, tc.inChat)
+					assert.Equal(t, untimed(tc.outChat), untimed(tc.inChat))
 				}
 			}
 		})
 	}
 }
 
+func TestAssistant_Regenerate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	client := newMockClient(ctrl)
+	client.EXPECT().
+		CreateChatCompletion(gomock.Any(), gomock.Any()).
+		Return(dto.Completion{Content: "Hello!"}, nil)
+
+	a := assistant.New(client, l)
+
+	chat := newChat(
+		dto.Message{Role: dto.RoleUser, Content: "Hi"},
+		dto.Message{Role: dto.RoleAssistant, Content: "Hi there!"},
+	)
+
+	_, err := a.Regenerate(ctx, chat)
+	assert.Error(t, err)
+
+	chat.Rewind(1)
+
+	answer, err := a.Regenerate(ctx, chat)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", answer)
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "Hi"},
+		{Role: dto.RoleAssistant, Content: "Hello!"},
+	}, chat.Messages())
+	assert.Len(t, chat.Leaves(), 2)
+}
+
+func TestAssistant_Alternatives(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	usage := dto.Usage{PromptTokens: 10, CompletionTokens: 30}
+
+	client := newMockClient(ctrl)
+	client.EXPECT().
+		CreateChatCompletion(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, chat *dto.Chat) (dto.Completion, error) {
+			assert.Equal(t, 2, chat.Params.N)
+			return dto.Completion{
+				Content:      "Hello!",
+				FinishReason: "stop",
+				Choices: []dto.Choice{
+					{Content: "Hello!", FinishReason: "stop"},
+					{Content: "Hi, how can I", FinishReason: "length"},
+				},
+				Model: "gpt-4o",
+				Usage: usage,
+			}, nil
+		})
+
+	a := assistant.New(client, l)
+
+	chat := newChat(dto.Message{Role: dto.RoleUser, Content: "Hi"})
+
+	completion, err := a.Alternatives(ctx, chat, 2)
+	assert.NoError(t, err)
+	assert.Len(t, completion.Choices, 2)
+	assert.Zero(t, chat.Params.N)
+	assert.Len(t, chat.Messages(), 1)
+
+	assert.Error(t, a.Pick(chat, completion, 2))
+	assert.NoError(t, a.Pick(chat, completion, 1))
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "Hi"},
+		{Role: dto.RoleAssistant, Content: "Hi, how can I", Model: "gpt-4o", Usage: &usage},
+	}, chat.Messages())
+
+	_, err = a.Alternatives(ctx, chat, 2)
+	assert.Error(t, err)
+}
+
+func TestAssistant_Complete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	client := newMockClient(ctrl)
+	client.EXPECT().
+		CreateChatCompletion(gomock.Any(), gomock.Any()).
+		Return(dto.Completion{Content: "Hello!", Model: "gpt-4o", Usage: dto.Usage{PromptTokens: 3, CompletionTokens: 2}}, nil)
+
+	recorder := mocks.NewMockrecorder(ctrl)
+	recorder.EXPECT().Record(gomock.Any()).Return(nil)
+
+	a := assistant.New(client, l, assistant.WithRecorder(recorder))
+
+	_, err := a.Complete(ctx, dto.NewChat())
+	assert.Error(t, err)
+
+	chat := newChat(dto.Message{Role: dto.RoleSystem, Content: "Be brief"}, dto.Message{Role: dto.RoleUser, Content: "Hi"})
+
+	completion, err := a.Complete(ctx, chat)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", completion.Content)
+	assert.Len(t, chat.Messages(), 2)
+}
+
+func TestAssistant_Budget(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	client := newMockClient(ctrl)
+	client.EXPECT().Model().Return("gpt-4o").AnyTimes()
+	client.EXPECT().
+		CreateChatCompletion(gomock.Any(), gomock.Any()).
+		Return(dto.Completion{Content: "Hello!", Model: "gpt-4o", Usage: dto.Usage{PromptTokens: 3, CompletionTokens: 2}}, nil)
+
+	var released dto.Usage
+
+	budget := mocks.NewMockbudget(ctrl)
+	gomock.InOrder(
+		budget.EXPECT().
+			Reserve("gpt-4o", 8).
+			Return(func(actual dto.Usage) { released = actual }, nil),
+		budget.EXPECT().
+			Reserve("gpt-4o", gomock.Any()).
+			Return(nil, errors.New("budget exceeded")),
+	)
+
+	recorder := mocks.NewMockrecorder(ctrl)
+	recorder.EXPECT().
+		Record(gomock.Any()).
+		DoAndReturn(func(record dto.UsageRecord) error {
+			assert.Equal(t, "work", record.Profile)
+			return nil
+		})
+
+	a := assistant.New(client, l,
+		assistant.WithBudget(budget),
+		assistant.WithRecorder(recorder),
+		assistant.WithProfile("work"),
+	)
+
+	chat := dto.NewChat()
+
+	answer, err := a.SendChatMessage(ctx, chat, "Hi")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", answer)
+	assert.Equal(t, dto.Usage{PromptTokens: 3, CompletionTokens: 2}, released)
+
+	_, err = a.SendChatMessage(ctx, chat, "Hi again")
+	assert.ErrorContains(t, err, "budget exceeded")
+	assert.Len(t, chat.Messages(), 2)
+}
+
+func TestAssistant_Cache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	client := newMockClient(ctrl)
+	client.EXPECT().Model().Return("gpt-4o").AnyTimes()
+
+	cache := mocks.NewMockresponseCache(ctrl)
+	cache.EXPECT().
+		Cached(gomock.Any(), gomock.Any()).
+		Return(dto.Completion{Content: "Hello!", Model: "gpt-4o", Cached: true}, true)
+
+	// The budget is exhausted, a cached answer is free anyway.
+	budget := mocks.NewMockbudget(ctrl)
+	budget.EXPECT().Reserve(gomock.Any(), gomock.Any()).Times(0)
+
+	a := assistant.New(client, l, assistant.WithBudget(budget), assistant.WithCache(cache))
+
+	answer, err := a.SendChatMessage(ctx, dto.NewChat(), "Hi")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", answer)
+}
+
+func TestAssistant_Redactor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	client := newMockClient(ctrl)
+	client.EXPECT().
+		CreateChatCompletion(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, chat *dto.Chat) (dto.Completion, error) {
+			assert.Equal(t, "My key is [KEY]", chat.Messages()[0].Content)
+			return dto.Completion{Content: "Rotate [KEY]"}, nil
+		})
+
+	redactor := mocks.NewMockredactor(ctrl)
+	redactor.EXPECT().
+		Chat(gomock.Any()).
+		DoAndReturn(func(chat *dto.Chat) *dto.Chat {
+			out := *chat
+			out.Nodes = []dto.Node{chat.Nodes[0]}
+			out.Nodes[0].Content = "My key is [KEY]"
+			return &out
+		})
+	redactor.EXPECT().Restore("Rotate [KEY]").Return("Rotate sk-secret")
+
+	a := assistant.New(client, l, assistant.WithRedactor(redactor))
+
+	chat := dto.NewChat()
+
+	answer, err := a.SendChatMessage(ctx, chat, "My key is sk-secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "Rotate sk-secret", answer)
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "My key is sk-secret"},
+		{Role: dto.RoleAssistant, Content: "Rotate sk-secret"},
+	}, chat.Messages())
+}
+
+func TestAssistant_RedactorStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	// The placeholder is split between the pieces.
+	client := newMockClient(ctrl)
+	client.EXPECT().
+		CreateChatCompletion(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *dto.Chat) (dto.Completion, error) {
+			handler, ok := stream.FromContext(ctx)
+			assert.True(t, ok)
+
+			for _, piece := range []string{"Write to [REDAC", "TED_EMAIL_1] now [", "ok] [REDACTED_"} {
+				handler(piece)
+			}
+
+			return dto.Completion{Content: "Write to [REDACTED_EMAIL_1] now [ok] [REDACTED_"}, nil
+		})
+
+	redactor, err := redact.New(config.Redaction{Restore: true})
+	assert.NoError(t, err)
+
+	var pieces []string
+	ctx := stream.WithHandler(context.Background(), func(piece string) {
+		pieces = append(pieces, piece)
+	})
+
+	answer, err := assistant.New(client, l, assistant.WithRedactor(redactor)).SendChatMessage(ctx, dto.NewChat(), "mail alice@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Write to alice@example.com now [ok] [REDACTED_", answer)
+
+	// The end held back is sent with the answer.
+	assert.Equal(t, []string{"Write to ", "alice@example.com now ", "[ok] ", "[REDACTED_"}, pieces)
+}
+
 func newMockClient(ctrl *gomock.Controller) *mocks.Mockclient {
 	c := mocks.NewMockclient(ctrl)
 	c.EXPECT().
-		ModelExists(gomock.Any()).
-		Return(true, nil)
+		Model().
+		Return("gpt-4o").
+		AnyTimes()
 	return c
 }
+
+func newChat(messages ...dto.Message) *dto.Chat {
+	chat := dto.NewChat()
+	for _, message := range messages {
+		chat.AddMessage(message.Role, message.Content)
+	}
+	return chat
+}
+
+// untimed returns a copy of the chat without the times of the messages.
+func untimed(chat *dto.Chat) *dto.Chat {
+	out := *chat
+	out.Nodes = make([]dto.Node, len(chat.Nodes))
+	for i, node := range chat.Nodes {
+		node.Time = time.Time{}
+		out.Nodes[i] = node
+	}
+	return &out
+}
@@ -36,10 +36,10 @@ func (m *Mockclient) EXPECT() *MockclientMockRecorder {
 }
 
 // CreateChatCompletion mocks base method.
-func (m *Mockclient) CreateChatCompletion(ctx context.Context, chat *dto.Chat) (string, error) {
+func (m *Mockclient) CreateChatCompletion(ctx context.Context, chat *dto.Chat) (dto.Completion, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateChatCompletion", ctx, chat)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(dto.Completion)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -79,17 +79,230 @@ func (mr *MockclientMockRecorder) Model() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Model", reflect.TypeOf((*Mockclient)(nil).Model))
 }
 
-// ModelExists mocks base method.
-func (m *Mockclient) ModelExists(ctx context.Context) (bool, error) {
+// Params mocks base method.
+func (m *Mockclient) Params() dto.Params {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Params")
+	ret0, _ := ret[0].(dto.Params)
+	return ret0
+}
+
+// Params indicates an expected call of Params.
+func (mr *MockclientMockRecorder) Params() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Params", reflect.TypeOf((*Mockclient)(nil).Params))
+}
+
+// SetModel mocks base method.
+func (m *Mockclient) SetModel(model string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetModel", model)
+}
+
+// SetModel indicates an expected call of SetModel.
+func (mr *MockclientMockRecorder) SetModel(model interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetModel", reflect.TypeOf((*Mockclient)(nil).SetModel), model)
+}
+
+// Mockrecorder is a mock of recorder interface.
+type Mockrecorder struct {
+	ctrl     *gomock.Controller
+	recorder *MockrecorderMockRecorder
+}
+
+// MockrecorderMockRecorder is the mock recorder for Mockrecorder.
+type MockrecorderMockRecorder struct {
+	mock *Mockrecorder
+}
+
+// NewMockrecorder creates a new mock instance.
+func NewMockrecorder(ctrl *gomock.Controller) *Mockrecorder {
+	mock := &Mockrecorder{ctrl: ctrl}
+	mock.recorder = &MockrecorderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockrecorder) EXPECT() *MockrecorderMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method.
+func (m *Mockrecorder) Record(record dto.UsageRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockrecorderMockRecorder) Record(record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*Mockrecorder)(nil).Record), record)
+}
+
+// Mockbudget is a mock of budget interface.
+type Mockbudget struct {
+	ctrl     *gomock.Controller
+	recorder *MockbudgetMockRecorder
+}
+
+// MockbudgetMockRecorder is the mock recorder for Mockbudget.
+type MockbudgetMockRecorder struct {
+	mock *Mockbudget
+}
+
+// NewMockbudget creates a new mock instance.
+func NewMockbudget(ctrl *gomock.Controller) *Mockbudget {
+	mock := &Mockbudget{ctrl: ctrl}
+	mock.recorder = &MockbudgetMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockbudget) EXPECT() *MockbudgetMockRecorder {
+	return m.recorder
+}
+
+// Reserve mocks base method.
+func (m *Mockbudget) Reserve(model string, promptTokens int) (func(dto.Usage), error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ModelExists", ctx)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "Reserve", model, promptTokens)
+	ret0, _ := ret[0].(func(dto.Usage))
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ModelExists indicates an expected call of ModelExists.
-func (mr *MockclientMockRecorder) ModelExists(ctx interface{}) *gomock.Call {
+// Reserve indicates an expected call of Reserve.
+func (mr *MockbudgetMockRecorder) Reserve(model, promptTokens interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*Mockbudget)(nil).Reserve), model, promptTokens)
+}
+
+// Mockcatalog is a mock of catalog interface.
+type Mockcatalog struct {
+	ctrl     *gomock.Controller
+	recorder *MockcatalogMockRecorder
+}
+
+// MockcatalogMockRecorder is the mock recorder for Mockcatalog.
+type MockcatalogMockRecorder struct {
+	mock *Mockcatalog
+}
+
+// NewMockcatalog creates a new mock instance.
+func NewMockcatalog(ctrl *gomock.Controller) *Mockcatalog {
+	mock := &Mockcatalog{ctrl: ctrl}
+	mock.recorder = &MockcatalogMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockcatalog) EXPECT() *MockcatalogMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *Mockcatalog) List(ctx context.Context) ([]dto.Model, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]dto.Model)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockcatalogMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*Mockcatalog)(nil).List), ctx)
+}
+
+// MockresponseCache is a mock of responseCache interface.
+type MockresponseCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockresponseCacheMockRecorder
+}
+
+// MockresponseCacheMockRecorder is the mock recorder for MockresponseCache.
+type MockresponseCacheMockRecorder struct {
+	mock *MockresponseCache
+}
+
+// NewMockresponseCache creates a new mock instance.
+func NewMockresponseCache(ctrl *gomock.Controller) *MockresponseCache {
+	mock := &MockresponseCache{ctrl: ctrl}
+	mock.recorder = &MockresponseCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockresponseCache) EXPECT() *MockresponseCacheMockRecorder {
+	return m.recorder
+}
+
+// Cached mocks base method.
+func (m *MockresponseCache) Cached(ctx context.Context, chat *dto.Chat) (dto.Completion, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cached", ctx, chat)
+	ret0, _ := ret[0].(dto.Completion)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Cached indicates an expected call of Cached.
+func (mr *MockresponseCacheMockRecorder) Cached(ctx, chat interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cached", reflect.TypeOf((*MockresponseCache)(nil).Cached), ctx, chat)
+}
+
+// Mockredactor is a mock of redactor interface.
+type Mockredactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockredactorMockRecorder
+}
+
+// MockredactorMockRecorder is the mock recorder for Mockredactor.
+type MockredactorMockRecorder struct {
+	mock *Mockredactor
+}
+
+// NewMockredactor creates a new mock instance.
+func NewMockredactor(ctrl *gomock.Controller) *Mockredactor {
+	mock := &Mockredactor{ctrl: ctrl}
+	mock.recorder = &MockredactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockredactor) EXPECT() *MockredactorMockRecorder {
+	return m.recorder
+}
+
+// Chat mocks base method.
+func (m *Mockredactor) Chat(chat *dto.Chat) *dto.Chat {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Chat", chat)
+	ret0, _ := ret[0].(*dto.Chat)
+	return ret0
+}
+
+// Chat indicates an expected call of Chat.
+func (mr *MockredactorMockRecorder) Chat(chat interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Chat", reflect.TypeOf((*Mockredactor)(nil).Chat), chat)
+}
+
+// Restore mocks base method.
+func (m *Mockredactor) Restore(text string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", text)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockredactorMockRecorder) Restore(text interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModelExists", reflect.TypeOf((*Mockclient)(nil).ModelExists), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*Mockredactor)(nil).Restore), text)
 }
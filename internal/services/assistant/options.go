@@ -0,0 +1,46 @@
+package assistant
+
+type Option func(*Assistant)
+
+// WithRecorder makes the assistant write the usage of every request, e.g. to the usage ledger.
+func WithRecorder(recorder recorder) Option {
+	return func(a *Assistant) {
+		a.recorder = recorder
+	}
+}
+
+// WithBudget makes the assistant check every request against the budget before sending it.
+func WithBudget(budget budget) Option {
+	return func(a *Assistant) {
+		a.budget = budget
+	}
+}
+
+// WithProfile sets the profile the usage is recorded for.
+func WithProfile(profile string) Option {
+	return func(a *Assistant) {
+		a.profile = profile
+	}
+}
+
+// WithCatalog makes the assistant list and check the models with the catalog, e.g. one with a local cache.
+func WithCatalog(catalog catalog) Option {
+	return func(a *Assistant) {
+		a.catalog = catalog
+	}
+}
+
+// WithRedactor makes the assistant send the chats with the secrets replaced by placeholders
+// and restore them in the answers.
+func WithRedactor(redactor redactor) Option {
+	return func(a *Assistant) {
+		a.redactor = redactor
+	}
+}
+
+// WithCache answers the requests found in the response cache before their budget is checked.
+func WithCache(cache responseCache) Option {
+	return func(a *Assistant) {
+		a.cache = cache
+	}
+}
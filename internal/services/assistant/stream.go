@@ -0,0 +1,54 @@
+package assistant
+
+import (
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/stream"
+)
+
+const (
+	placeholderPrefix = "[REDACTED_"
+	// maxPlaceholder bounds the length of a placeholder held back, a longer text is not one.
+	maxPlaceholder = 64
+)
+
+// restorer restores the placeholders in the pieces of a streamed answer. The end of a piece
+// that may be the beginning of a placeholder is held back until the next pieces complete it.
+type restorer struct {
+	restore func(text string) string
+	handler stream.Handler
+	pending string
+}
+
+func (r *restorer) write(piece string) {
+	r.pending += piece
+
+	if i := strings.LastIndex(r.pending, "["); i >= 0 && mayBePlaceholder(r.pending[i:]) {
+		r.send(r.pending[:i])
+		r.pending = r.pending[i:]
+		return
+	}
+
+	r.flush()
+}
+
+// flush sends the piece held back, at the end of the answer.
+func (r *restorer) flush() {
+	r.send(r.pending)
+	r.pending = ""
+}
+
+func (r *restorer) send(text string) {
+	if text != "" {
+		r.handler(r.restore(text))
+	}
+}
+
+// mayBePlaceholder tells if the text is the beginning of a placeholder.
+func mayBePlaceholder(text string) bool {
+	if strings.Contains(text, "]") || len(text) > maxPlaceholder {
+		return false
+	}
+
+	return strings.HasPrefix(placeholderPrefix, text) || strings.HasPrefix(text, placeholderPrefix)
+}
@@ -0,0 +1,182 @@
+// Package batch runs many requests concurrently and collects their answers in the input order.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/budget"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+)
+
+const defaultWorkers = 4
+
+type assistant interface {
+	SendChatMessage(ctx context.Context, chat *dto.Chat, question string) (string, error)
+}
+
+type limiter interface {
+	Wait(ctx context.Context, tokens int) error
+}
+
+// Runner sends the requests with a fixed number of workers.
+type Runner struct {
+	assistant assistant
+	limiter   limiter
+	workers   int
+}
+
+func New(assistant assistant, opts ...Option) *Runner {
+	r := &Runner{
+		assistant: assistant,
+		workers:   defaultWorkers,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run sends the requests and passes the results to write in the order of the requests.
+// A failed request gets a result with the error. Running stops when the budget is exceeded or the price
+// of the model is unknown, the request refused by the budget and the canceled ones get no result
+// so they can be run again.
+func (r *Runner) Run(ctx context.Context, requests []Request, write func(dto.BatchResult) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	jobs := make(chan int)
+	out := newOrdered(len(requests), write)
+
+	var wg sync.WaitGroup
+	for range min(r.workers, len(requests)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				// The jobs taken after the run stopped are not sent.
+				if ctx.Err() != nil {
+					out.skip(i)
+					continue
+				}
+
+				result, err := r.run(ctx, requests[i])
+				if err != nil {
+					cancel(err)
+					out.skip(i)
+					continue
+				}
+
+				if err = out.put(i, result); err != nil {
+					cancel(err)
+				}
+			}
+		}()
+	}
+
+	for i := range requests {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			out.skip(i)
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
+
+// run sends the request. An error means the request has no result and the run has to stop.
+func (r *Runner) run(ctx context.Context, request Request) (dto.BatchResult, error) {
+	chat, question := request.chat()
+
+	if r.limiter != nil {
+		estimate := tokens.EstimateMessages(append(chat.Prompt(), dto.Message{Role: dto.RoleUser, Content: question}))
+		if err := r.limiter.Wait(ctx, estimate+request.Params.MaxTokens); err != nil {
+			return dto.BatchResult{}, err
+		}
+	}
+
+	// An answer is kept even if the run was canceled meanwhile, it is paid for.
+	answer, err := r.assistant.SendChatMessage(ctx, chat, question)
+	if err != nil {
+		switch {
+		case ctx.Err() != nil:
+			return dto.BatchResult{}, ctx.Err()
+		// The budget fails every other request the same way.
+		case errors.Is(err, budget.ErrExceeded), errors.Is(err, budget.ErrUnknownPrice):
+			return dto.BatchResult{}, fmt.Errorf("request %s: %w", request.ID, err)
+		default:
+			return dto.BatchResult{ID: request.ID, Error: err.Error()}, nil
+		}
+	}
+
+	last, _ := chat.LastMessage()
+
+	return dto.BatchResult{
+		ID:      request.ID,
+		Model:   last.Model,
+		Content: answer,
+		Usage:   last.Usage,
+	}, nil
+}
+
+// ordered passes the results to write in the order of their indexes.
+type ordered struct {
+	write func(dto.BatchResult) error
+
+	mu      sync.Mutex
+	next    int
+	results []*dto.BatchResult
+	done    []bool
+	err     error
+}
+
+func newOrdered(n int, write func(dto.BatchResult) error) *ordered {
+	return &ordered{
+		write:   write,
+		results: make([]*dto.BatchResult, n),
+		done:    make([]bool, n),
+	}
+}
+
+func (o *ordered) put(i int, result dto.BatchResult) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.results[i] = &result
+	o.done[i] = true
+
+	return o.flush()
+}
+
+func (o *ordered) skip(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.done[i] = true
+
+	_ = o.flush()
+}
+
+func (o *ordered) flush() error {
+	for o.err == nil && o.next < len(o.done) && o.done[o.next] {
+		if result := o.results[o.next]; result != nil {
+			if err := o.write(*result); err != nil {
+				o.err = fmt.Errorf("write result: %w", err)
+			}
+			o.results[o.next] = nil
+		}
+		o.next++
+	}
+
+	return o.err
+}
@@ -0,0 +1,369 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/budget"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/batch"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
+)
+
+type assistant struct {
+	mu    sync.Mutex
+	calls int
+	limit int
+	// answered is called before an answer is returned.
+	answered func()
+}
+
+func (a *assistant) SendChatMessage(_ context.Context, chat *dto.Chat, question string) (string, error) {
+	a.mu.Lock()
+	a.calls++
+	over := a.limit > 0 && a.calls > a.limit
+	a.mu.Unlock()
+
+	if over {
+		return "", fmt.Errorf("check budget: %w", budget.ErrExceeded)
+	}
+
+	switch question {
+	case "fail":
+		return "", errors.New("API error")
+	case "no price":
+		return "", fmt.Errorf("check budget: %w", budget.ErrUnknownPrice)
+	}
+
+	if !chat.Full {
+		return "", errors.New("only the last messages are sent")
+	}
+
+	// The later requests finish first.
+	time.Sleep(time.Duration(max(10-len(chat.Messages()), 0)) * time.Millisecond)
+
+	answer := fmt.Sprintf("%s: %s (%d)", chat.Model, question, len(chat.Messages()))
+
+	chat.AddMessage(dto.RoleUser, question)
+	chat.Add(dto.Message{
+		Role:    dto.RoleAssistant,
+		Content: answer,
+		Model:   chat.Model,
+		Usage:   &dto.Usage{PromptTokens: 1, CompletionTokens: 2},
+	})
+
+	if a.answered != nil {
+		a.answered()
+	}
+
+	return answer, nil
+}
+
+const input = `{"id": "a", "prompt": "one", "model": "gpt-4o"}
+
+{"prompt": "fail"}
+{"messages": [{"role": "user", "content": "hi"}, {"role": "assistant", "content": "hello"}, {"role": "user", "content": "two"}]}
+`
+
+func TestReadRequests(t *testing.T) {
+	requests, err := batch.ReadRequests(strings.NewReader(input))
+	assert.NoError(t, err)
+	assert.Len(t, requests, 3)
+	assert.Equal(t, []string{"a", "3", "4"}, []string{requests[0].ID, requests[1].ID, requests[2].ID})
+
+	for _, broken := range []string{
+		`{"id": "a"}`,
+		`{"prompt": "x", "messages": [{"role": "user", "content": "y"}]}`,
+		`{"messages": [{"role": "assistant", "content": "y"}]}`,
+		"{\"id\": \"a\", \"prompt\": \"x\"}\n{\"id\": \"a\", \"prompt\": \"y\"}",
+		`not json`,
+	} {
+		_, err = batch.ReadRequests(strings.NewReader(broken))
+		assert.Error(t, err, broken)
+	}
+
+	answered, err := batch.ReadAnswered(strings.NewReader(
+		`{"id": "a", "content": "x"}` + "\n" + `{"id": "3", "error": "API error"}` + "\n" + `{"id": "4", "cont`,
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true}, answered)
+}
+
+func TestTrimPartial(t *testing.T) {
+	complete := `{"id": "a", "content": "x"}` + "\n"
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "empty"},
+		{name: "complete", content: complete, want: complete},
+		{name: "partial", content: complete + `{"id": "b", "cont`, want: complete},
+		{name: "only partial", content: `{"id": "b", "cont`},
+		{name: "long partial", content: complete + `{"id": "b", "content": "` + strings.Repeat("x", 10000), want: complete},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.jsonl")
+			assert.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o600)
+			assert.NoError(t, err)
+			defer f.Close()
+
+			assert.NoError(t, batch.TrimPartial(f))
+
+			// The next result starts on its own line.
+			_, err = f.WriteString(`{"id": "c"}` + "\n")
+			assert.NoError(t, err)
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want+`{"id": "c"}`+"\n", string(data))
+		})
+	}
+}
+
+func TestRunner(t *testing.T) {
+	requests, err := batch.ReadRequests(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	var results []dto.BatchResult
+
+	err = batch.New(&assistant{}, batch.WithWorkers(3)).Run(context.Background(), requests, func(result dto.BatchResult) error {
+		results = append(results, result)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	usage := &dto.Usage{PromptTokens: 1, CompletionTokens: 2}
+	assert.Equal(t, []dto.BatchResult{
+		{ID: "a", Model: "gpt-4o", Content: "gpt-4o: one (0)", Usage: usage},
+		{ID: "3", Error: "API error"},
+		{ID: "4", Content: ": two (2)", Usage: usage},
+	}, results)
+}
+
+// longRequest is a line with more messages than a chat sends by default.
+func longRequest(t *testing.T, n int) []batch.Request {
+	t.Helper()
+
+	messages := make([]string, 0, n)
+	for i := range n {
+		role := "user"
+		if i%2 == 1 {
+			role = "assistant"
+		}
+		messages = append(messages, fmt.Sprintf(`{"role": %q, "content": "%d"}`, role, i))
+	}
+
+	requests, err := batch.ReadRequests(strings.NewReader(`{"id": "long", "messages": [` + strings.Join(messages, ", ") + `]}`))
+	assert.NoError(t, err)
+
+	return requests
+}
+
+func TestRunner_Long(t *testing.T) {
+	var results []dto.BatchResult
+
+	err := batch.New(&assistant{}).Run(context.Background(), longRequest(t, 25), func(result dto.BatchResult) error {
+		results = append(results, result)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// All the messages before the question are sent.
+	assert.Len(t, results, 1)
+	assert.Equal(t, ": 24 (24)", results[0].Content)
+}
+
+func TestRunner_Budget(t *testing.T) {
+	var lines []string
+	for i := range 10 {
+		lines = append(lines, fmt.Sprintf(`{"prompt": "%d"}`, i))
+	}
+
+	requests, err := batch.ReadRequests(strings.NewReader(strings.Join(lines, "\n")))
+	assert.NoError(t, err)
+
+	var results []dto.BatchResult
+
+	err = batch.New(&assistant{limit: 3}, batch.WithWorkers(1)).Run(context.Background(), requests, func(result dto.BatchResult) error {
+		results = append(results, result)
+		return nil
+	})
+	assert.ErrorIs(t, err, budget.ErrExceeded)
+	assert.Len(t, results, 3)
+}
+
+func TestRunner_UnknownPrice(t *testing.T) {
+	requests, err := batch.ReadRequests(strings.NewReader(`{"prompt": "one"}` + "\n" + `{"prompt": "no price"}` + "\n" + `{"prompt": "three"}`))
+	assert.NoError(t, err)
+
+	var results []dto.BatchResult
+
+	err = batch.New(&assistant{}, batch.WithWorkers(1)).Run(context.Background(), requests, func(result dto.BatchResult) error {
+		results = append(results, result)
+		return nil
+	})
+	assert.ErrorIs(t, err, budget.ErrUnknownPrice)
+	// The request without a price stops the run, the next one is not sent.
+	assert.Len(t, results, 1)
+	assert.Equal(t, "1", results[0].ID)
+}
+
+func TestRunner_Canceled(t *testing.T) {
+	requests, err := batch.ReadRequests(strings.NewReader(`{"prompt": "one"}` + "\n" + `{"prompt": "two"}`))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var results []dto.BatchResult
+
+	// The run is canceled while the first answer comes, the answer is written anyway.
+	err = batch.New(&assistant{answered: cancel}, batch.WithWorkers(1)).Run(ctx, requests, func(result dto.BatchResult) error {
+		results = append(results, result)
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, results, 1)
+	assert.Equal(t, ": one (0)", results[0].Content)
+}
+
+type provider struct {
+	statuses []dto.BatchStatus
+	items    []dto.BatchItem
+}
+
+func (p *provider) Model() string {
+	return "gpt-4o-mini"
+}
+
+func (p *provider) CreateBatch(_ context.Context, items []dto.BatchItem) (dto.BatchJob, error) {
+	p.items = items
+	return dto.BatchJob{ID: "batch-1", Status: dto.BatchValidating}, nil
+}
+
+func (p *provider) Batches(context.Context, int) ([]dto.BatchJob, error) {
+	return nil, nil
+}
+
+func (p *provider) Batch(_ context.Context, id string) (dto.BatchJob, error) {
+	status := p.statuses[0]
+	if len(p.statuses) > 1 {
+		p.statuses = p.statuses[1:]
+	}
+	return dto.BatchJob{ID: id, Status: status}, nil
+}
+
+func (p *provider) CancelBatch(_ context.Context, id string) (dto.BatchJob, error) {
+	return dto.BatchJob{ID: id, Status: dto.BatchCancelling}, nil
+}
+
+func (p *provider) BatchResults(_ context.Context, job dto.BatchJob) ([]dto.BatchResult, error) {
+	return []dto.BatchResult{
+		{ID: job.ID, Model: "gpt-4o", Usage: &dto.Usage{PromptTokens: 1, CompletionTokens: 2}},
+		{ID: "failed", Error: "API error"},
+	}, nil
+}
+
+type guard struct {
+	reserved map[string]int
+	released int
+}
+
+func (g *guard) ReserveBatch(model string, _, requests int) (func(actual dto.Usage), error) {
+	if model == "gpt-4" {
+		return nil, budget.ErrExceeded
+	}
+
+	g.reserved[model] += requests
+
+	return func(dto.Usage) { g.released++ }, nil
+}
+
+func TestRemote(t *testing.T) {
+	ctx := context.Background()
+
+	p := &provider{statuses: []dto.BatchStatus{
+		dto.BatchValidating, dto.BatchInProgress, dto.BatchInProgress, dto.BatchCompleted,
+	}}
+	remote := batch.NewRemote(p)
+
+	requests, err := batch.ReadRequests(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	job, err := remote.Submit(ctx, requests)
+	assert.NoError(t, err)
+	assert.Equal(t, "batch-1", job.ID)
+	assert.Len(t, p.items, 3)
+	assert.Equal(t, "gpt-4o", p.items[0].Chat.Model)
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "hi"},
+		{Role: dto.RoleAssistant, Content: "hello"},
+		{Role: dto.RoleUser, Content: "two"},
+	}, p.items[2].Chat.Messages())
+
+	var reported []dto.BatchStatus
+	job, err = remote.Wait(ctx, "batch-1", time.Millisecond, func(job dto.BatchJob) {
+		reported = append(reported, job.Status)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, dto.BatchCompleted, job.Status)
+	assert.Equal(t, []dto.BatchStatus{dto.BatchValidating, dto.BatchInProgress, dto.BatchCompleted}, reported)
+
+	results, err := remote.Download(ctx, "batch-1")
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestRemote_Usage(t *testing.T) {
+	ctx := context.Background()
+
+	p := &provider{statuses: []dto.BatchStatus{dto.BatchCompleted}}
+	g := &guard{reserved: make(map[string]int)}
+	ledger := usage.NewLedger(t.TempDir())
+	remote := batch.NewRemote(p, batch.WithRemoteBudget(g), batch.WithRemoteRecorder(ledger, "work"))
+
+	requests, err := batch.ReadRequests(strings.NewReader(input))
+	assert.NoError(t, err)
+
+	// The requests without a model go to the default one.
+	_, err = remote.Submit(ctx, requests)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"gpt-4o": 1, "gpt-4o-mini": 2}, g.reserved)
+	assert.Equal(t, 2, g.released)
+
+	// A batch over the budget is not submitted.
+	p.items = nil
+	_, err = remote.Submit(ctx, append(requests, batch.Request{ID: "x", Prompt: "x", Model: "gpt-4"}))
+	assert.ErrorIs(t, err, budget.ErrExceeded)
+	assert.Nil(t, p.items)
+	assert.Equal(t, 4, g.released)
+
+	// The usage is recorded once however many times the batch is downloaded.
+	for range 2 {
+		_, err = remote.Download(ctx, "batch-1")
+		assert.NoError(t, err)
+	}
+
+	records, err := ledger.Records(time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "gpt-4o", records[0].Model)
+	assert.Equal(t, "work", records[0].Profile)
+	assert.Equal(t, "batch-1", records[0].Batch)
+	assert.Equal(t, dto.Usage{PromptTokens: 1, CompletionTokens: 2}, records[0].Usage)
+}
@@ -0,0 +1,44 @@
+package batch
+
+type Option func(*Runner)
+
+// WithWorkers sets the number of requests sent at the same time.
+func WithWorkers(workers int) Option {
+	return func(r *Runner) {
+		if workers > 0 {
+			r.workers = workers
+		}
+	}
+}
+
+// WithLimiter keeps the requests under the rate limits.
+func WithLimiter(limiter limiter) Option {
+	return func(r *Runner) {
+		r.limiter = limiter
+	}
+}
+
+type RemoteOption func(*Remote)
+
+// WithRemoteRedactor uploads the requests with the secrets replaced by placeholders.
+// The answers keep the placeholders, the batch outlives the values known to the process.
+func WithRemoteRedactor(redactor redactor) RemoteOption {
+	return func(r *Remote) {
+		r.redactor = redactor
+	}
+}
+
+// WithRemoteBudget checks the estimated cost of a batch against the budget before it is submitted.
+func WithRemoteBudget(guard guard) RemoteOption {
+	return func(r *Remote) {
+		r.guard = guard
+	}
+}
+
+// WithRemoteRecorder writes the usage of the downloaded batches to the ledger under the profile.
+func WithRemoteRecorder(recorder recorder, profile string) RemoteOption {
+	return func(r *Remote) {
+		r.recorder = recorder
+		r.profile = profile
+	}
+}
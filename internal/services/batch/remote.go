@@ -0,0 +1,226 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+)
+
+type provider interface {
+	Model() string
+	CreateBatch(ctx context.Context, items []dto.BatchItem) (dto.BatchJob, error)
+	Batches(ctx context.Context, limit int) ([]dto.BatchJob, error)
+	Batch(ctx context.Context, id string) (dto.BatchJob, error)
+	CancelBatch(ctx context.Context, id string) (dto.BatchJob, error)
+	BatchResults(ctx context.Context, job dto.BatchJob) ([]dto.BatchResult, error)
+}
+
+type redactor interface {
+	Chat(chat *dto.Chat) *dto.Chat
+}
+
+type guard interface {
+	ReserveBatch(model string, promptTokens, requests int) (func(actual dto.Usage), error)
+}
+
+type recorder interface {
+	Record(record dto.UsageRecord) error
+	Records(since time.Time) ([]dto.UsageRecord, error)
+}
+
+// Remote runs the requests as a batch of the provider, it is cheaper but takes up to a day.
+type Remote struct {
+	provider provider
+	redactor redactor
+	guard    guard
+	recorder recorder
+	profile  string
+}
+
+func NewRemote(provider provider, opts ...RemoteOption) *Remote {
+	r := &Remote{
+		provider: provider,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Submit uploads the requests and creates the batch once its estimated cost fits the budget.
+func (r *Remote) Submit(ctx context.Context, requests []Request) (dto.BatchJob, error) {
+	items := make([]dto.BatchItem, 0, len(requests))
+	for _, request := range requests {
+		chat, question := request.chat()
+		chat.AddMessage(dto.RoleUser, question)
+
+		if r.redactor != nil {
+			chat = r.redactor.Chat(chat)
+		}
+
+		items = append(items, dto.BatchItem{ID: request.ID, Chat: chat})
+	}
+
+	releases, err := r.reserve(items)
+	// The spend is only known when the batch is done, Download records it.
+	defer func() {
+		for _, release := range releases {
+			release(dto.Usage{})
+		}
+	}()
+	if err != nil {
+		return dto.BatchJob{}, err
+	}
+
+	return r.provider.CreateBatch(ctx, items)
+}
+
+// reserve checks the estimated cost of the requests to every model against the budget.
+func (r *Remote) reserve(items []dto.BatchItem) ([]func(actual dto.Usage), error) {
+	if r.guard == nil {
+		return nil, nil
+	}
+
+	type estimate struct {
+		promptTokens int
+		requests     int
+	}
+
+	var (
+		models    []string
+		estimates = make(map[string]estimate)
+	)
+
+	for _, item := range items {
+		model := item.Chat.Model
+		if model == "" {
+			model = r.provider.Model()
+		}
+
+		e, ok := estimates[model]
+		if !ok {
+			models = append(models, model)
+		}
+		e.promptTokens += tokens.EstimateMessages(item.Chat.Prompt())
+		e.requests++
+		estimates[model] = e
+	}
+
+	releases := make([]func(actual dto.Usage), 0, len(models))
+	for _, model := range models {
+		release, err := r.guard.ReserveBatch(model, estimates[model].promptTokens, estimates[model].requests)
+		if err != nil {
+			return releases, fmt.Errorf("check budget: %w", err)
+		}
+
+		releases = append(releases, release)
+	}
+
+	return releases, nil
+}
+
+// List returns the latest batches.
+func (r *Remote) List(ctx context.Context, limit int) ([]dto.BatchJob, error) {
+	return r.provider.Batches(ctx, limit)
+}
+
+func (r *Remote) Status(ctx context.Context, id string) (dto.BatchJob, error) {
+	return r.provider.Batch(ctx, id)
+}
+
+func (r *Remote) Cancel(ctx context.Context, id string) (dto.BatchJob, error) {
+	return r.provider.CancelBatch(ctx, id)
+}
+
+// Wait polls the batch until it is done and reports every change of its progress.
+func (r *Remote) Wait(ctx context.Context, id string, interval time.Duration, report func(dto.BatchJob)) (dto.BatchJob, error) {
+	var last dto.BatchJob
+
+	for {
+		job, err := r.provider.Batch(ctx, id)
+		if err != nil {
+			return dto.BatchJob{}, err
+		}
+
+		if job.Status != last.Status || job.Completed != last.Completed || job.Failed != last.Failed {
+			report(job)
+			last = job
+		}
+
+		if job.Status.Done() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Download returns the results of the batch in the order of its requests.
+func (r *Remote) Download(ctx context.Context, id string) ([]dto.BatchResult, error) {
+	job, err := r.provider.Batch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := r.provider.BatchResults(ctx, job)
+	if err != nil {
+		return nil, fmt.Errorf("batch results: %w", err)
+	}
+
+	if err := r.record(job, results); err != nil {
+		return nil, fmt.Errorf("record usage: %w", err)
+	}
+
+	return results, nil
+}
+
+// record writes the usage of the results to the ledger once, a batch downloaded again is already recorded.
+func (r *Remote) record(job dto.BatchJob, results []dto.BatchResult) error {
+	if r.recorder == nil || !job.Status.Done() {
+		return nil
+	}
+
+	records, err := r.recorder.Records(job.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		if record.Batch == job.ID {
+			return nil
+		}
+	}
+
+	at := job.DoneAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	for _, result := range results {
+		if result.Usage == nil || result.Usage.IsZero() {
+			continue
+		}
+
+		err := r.recorder.Record(dto.UsageRecord{
+			Time:    at,
+			Model:   result.Model,
+			Profile: r.profile,
+			Batch:   job.ID,
+			Usage:   *result.Usage,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const maxLineSize = 16 << 20
+
+// Request is a line of the input. It has either a prompt or messages ending with a user message.
+type Request struct {
+	// ID identifies the line in the output, the line number is used if it is empty.
+	ID       string        `json:"id"`
+	Prompt   string        `json:"prompt,omitempty"`
+	Messages []dto.Message `json:"messages,omitempty"`
+	System   string        `json:"system,omitempty"`
+	Model    string        `json:"model,omitempty"`
+	Params   dto.Params    `json:"params"`
+}
+
+// ReadRequests parses the JSON lines of the input, empty lines are skipped.
+func ReadRequests(r io.Reader) ([]Request, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var (
+		requests []Request
+		ids      = make(map[string]int)
+	)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var request Request
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		if request.ID == "" {
+			request.ID = strconv.Itoa(n)
+		}
+
+		if prev, ok := ids[request.ID]; ok {
+			return nil, fmt.Errorf("line %d: id %q is already used on line %d", n, request.ID, prev)
+		}
+		ids[request.ID] = n
+
+		if err := request.validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		requests = append(requests, request)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+// ReadAnswered returns the ids of the answered requests in the output of a previous run, the failed ones are run again.
+func ReadAnswered(r io.Reader) (map[string]bool, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	answered := make(map[string]bool)
+
+	for scanner.Scan() {
+		var result dto.BatchResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			// A line cut by an interrupted run is answered again.
+			continue
+		}
+
+		if result.Error == "" {
+			answered[result.ID] = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read results: %w", err)
+	}
+
+	return answered, nil
+}
+
+// TrimPartial cuts the line an interrupted run left without its newline at the end of the output,
+// so the next result is not glued to it. The request of the cut line is answered again.
+func TrimPartial(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("check output: %w", err)
+	}
+
+	end := info.Size()
+	buf := make([]byte, 4096)
+
+	for pos := end; pos > 0; {
+		n := min(int64(len(buf)), pos)
+		pos -= n
+
+		if _, err = f.ReadAt(buf[:n], pos); err != nil {
+			return fmt.Errorf("check output: %w", err)
+		}
+
+		i := bytes.LastIndexByte(buf[:n], '\n')
+		if i < 0 {
+			continue
+		}
+
+		keep := pos + int64(i) + 1
+		if keep == end {
+			return nil
+		}
+
+		return truncate(f, keep)
+	}
+
+	return truncate(f, 0)
+}
+
+func truncate(f *os.File, size int64) error {
+	if err := f.Truncate(size); err != nil {
+		return fmt.Errorf("cut the partial result: %w", err)
+	}
+
+	return nil
+}
+
+func (r Request) validate() error {
+	switch {
+	case r.Prompt != "" && len(r.Messages) > 0:
+		return errors.New("both prompt and messages are set")
+	case r.Prompt == "" && len(r.Messages) == 0:
+		return errors.New("neither prompt nor messages are set")
+	case len(r.Messages) > 0 && r.Messages[len(r.Messages)-1].Role != dto.RoleUser:
+		return errors.New("the last message is not from the user")
+	}
+
+	return nil
+}
+
+// chat returns the chat with the request and its question.
+// A line is an explicit request, all of its messages are sent.
+func (r Request) chat() (*dto.Chat, string) {
+	chat := dto.NewChat()
+	chat.System = r.System
+	chat.Model = r.Model
+	chat.Params = r.Params
+	chat.Full = true
+
+	if r.Prompt != "" {
+		return chat, r.Prompt
+	}
+
+	last := len(r.Messages) - 1
+	for _, message := range r.Messages[:last] {
+		chat.Add(message)
+	}
+
+	return chat, r.Messages[last].Content
+}
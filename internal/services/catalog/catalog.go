@@ -0,0 +1,144 @@
+// Package catalog lists the models with their capabilities and keeps the list in a local cache.
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/cache"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+var ErrNotFound = errors.New("model not found")
+
+type lister interface {
+	ListModels(ctx context.Context) ([]dto.Model, error)
+}
+
+type store interface {
+	Get(key string, value any) (bool, error)
+	Put(key string, value any) error
+}
+
+// Catalog lists the models available to the API key.
+type Catalog struct {
+	lister  lister
+	cache   store
+	scope   string
+	refresh bool
+}
+
+func New(lister lister, opts ...Option) *Catalog {
+	c := &Catalog{
+		lister: lister,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// List returns the models sorted by ID, from the cache unless it has expired.
+func (c *Catalog) List(ctx context.Context) ([]dto.Model, error) {
+	key, err := cache.Key("models " + c.scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var models []dto.Model
+
+	// A broken cache entry is fetched again.
+	if c.cache != nil && !c.refresh {
+		if found, _ := c.cache.Get(key, &models); found {
+			return describeAll(models), nil
+		}
+	}
+
+	models, err = c.lister.ListModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The list is fetched again next time if it cannot be cached.
+	if c.cache != nil {
+		_ = c.cache.Put(key, models)
+	}
+
+	return describeAll(models), nil
+}
+
+// Info returns the model with the ID.
+func (c *Catalog) Info(ctx context.Context, id string) (dto.Model, error) {
+	models, err := c.List(ctx)
+	if err != nil {
+		return dto.Model{}, err
+	}
+
+	for _, model := range models {
+		if model.ID == id {
+			return model, nil
+		}
+	}
+
+	return dto.Model{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Filter selects the models by ID and capabilities, the zero filter selects all of them.
+type Filter struct {
+	Pattern *regexp.Regexp
+	// Capabilities must all be supported by a model.
+	Capabilities []dto.Capability
+}
+
+// Apply returns the models matching the filter.
+func (f Filter) Apply(models []dto.Model) []dto.Model {
+	var matched []dto.Model
+
+	for _, model := range models {
+		if f.Match(model) {
+			matched = append(matched, model)
+		}
+	}
+
+	return matched
+}
+
+// Match reports whether the model matches the filter.
+func (f Filter) Match(model dto.Model) bool {
+	if f.Pattern != nil && !f.Pattern.MatchString(model.ID) {
+		return false
+	}
+
+	for _, capability := range f.Capabilities {
+		if !model.Can(capability) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseCapability checks the name of a capability.
+func ParseCapability(name string) (dto.Capability, error) {
+	for _, capability := range dto.Capabilities {
+		if string(capability) == name {
+			return capability, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown capability %s, use one of %v", name, dto.Capabilities)
+}
+
+// describeAll adds the capabilities on every read, so the cached list picks up a newer table.
+func describeAll(models []dto.Model) []dto.Model {
+	described := make([]dto.Model, len(models))
+	for i, model := range models {
+		described[i] = describe(model)
+	}
+
+	return described
+}
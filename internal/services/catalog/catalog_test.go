@@ -0,0 +1,122 @@
+package catalog_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/cache"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/catalog"
+)
+
+type lister struct {
+	models []dto.Model
+	calls  int
+}
+
+func (l *lister) ListModels(context.Context) ([]dto.Model, error) {
+	l.calls++
+	if l.models == nil {
+		return nil, errors.New("offline")
+	}
+	return l.models, nil
+}
+
+func TestCatalog_List(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2024, 8, 6, 0, 0, 0, 0, time.UTC)
+
+	l := &lister{models: []dto.Model{
+		{ID: "custom-llm", OwnedBy: "me"},
+		{ID: "gpt-4o-2024-08-06", OwnedBy: "system", Created: created},
+		{ID: "o3-mini", OwnedBy: "system"},
+		{ID: "text-embedding-3-small", OwnedBy: "system"},
+	}}
+
+	now := created
+	store := cache.New(t.TempDir(), cache.WithTTL(time.Hour), cache.WithClock(func() time.Time { return now }))
+
+	c := catalog.New(l, catalog.WithCache(store, "https://api.openai.com/v1"))
+
+	models, err := c.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.Model{
+		{ID: "custom-llm", OwnedBy: "me"},
+		{
+			ID: "gpt-4o-2024-08-06", OwnedBy: "system", Created: created,
+			Capabilities:  []dto.Capability{dto.CapabilityChat, dto.CapabilityVision},
+			ContextWindow: 128_000,
+		},
+		{
+			ID: "o3-mini", OwnedBy: "system",
+			Capabilities:  []dto.Capability{dto.CapabilityChat, dto.CapabilityReasoning},
+			ContextWindow: 200_000,
+		},
+		{
+			ID: "text-embedding-3-small", OwnedBy: "system",
+			Capabilities:  []dto.Capability{dto.CapabilityEmbeddings},
+			ContextWindow: 8_191,
+		},
+	}, models)
+
+	// The cached list is reused until it expires, even when the API is not reachable.
+	l.models = nil
+
+	cached, err := c.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, models, cached)
+	assert.Equal(t, 1, l.calls)
+
+	model, err := c.Info(ctx, "o3-mini")
+	assert.NoError(t, err)
+	assert.True(t, model.Can(dto.CapabilityReasoning))
+
+	_, err = c.Info(ctx, "gpt-5")
+	assert.ErrorIs(t, err, catalog.ErrNotFound)
+
+	now = now.Add(2 * time.Hour)
+
+	_, err = c.List(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 2, l.calls)
+
+	// Another API keeps its own list.
+	l.models = []dto.Model{{ID: "llama3"}}
+
+	models, err = catalog.New(l, catalog.WithCache(store, "http://localhost:11434/v1")).List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []dto.Model{{ID: "llama3"}}, models)
+
+	_, err = catalog.New(l, catalog.WithCache(store, "http://localhost:11434/v1"), catalog.WithRefresh(true)).List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, l.calls)
+}
+
+func TestFilter(t *testing.T) {
+	models := []dto.Model{
+		{ID: "gpt-4o", Capabilities: []dto.Capability{dto.CapabilityChat, dto.CapabilityVision}},
+		{ID: "gpt-4o-mini", Capabilities: []dto.Capability{dto.CapabilityChat, dto.CapabilityVision}},
+		{ID: "o3-mini", Capabilities: []dto.Capability{dto.CapabilityChat, dto.CapabilityReasoning}},
+		{ID: "whisper-1", Capabilities: []dto.Capability{dto.CapabilityAudio}},
+	}
+
+	assert.Equal(t, models, catalog.Filter{}.Apply(models))
+	assert.Equal(t, models[1:3], catalog.Filter{Pattern: regexp.MustCompile("mini$")}.Apply(models))
+	assert.Equal(t, models[:2], catalog.Filter{Capabilities: []dto.Capability{dto.CapabilityChat, dto.CapabilityVision}}.Apply(models))
+	assert.Empty(t, catalog.Filter{
+		Pattern:      regexp.MustCompile("^o"),
+		Capabilities: []dto.Capability{dto.CapabilityVision},
+	}.Apply(models))
+
+	capability, err := catalog.ParseCapability("vision")
+	assert.NoError(t, err)
+	assert.Equal(t, dto.CapabilityVision, capability)
+
+	_, err = catalog.ParseCapability("telepathy")
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+package catalog
+
+import (
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+type info struct {
+	capabilities  []dto.Capability
+	contextWindow int
+}
+
+var (
+	chat          = []dto.Capability{dto.CapabilityChat}
+	chatVision    = []dto.Capability{dto.CapabilityChat, dto.CapabilityVision}
+	chatAudio     = []dto.Capability{dto.CapabilityChat, dto.CapabilityAudio}
+	chatReasoning = []dto.Capability{dto.CapabilityChat, dto.CapabilityReasoning}
+	allReasoning  = []dto.Capability{dto.CapabilityChat, dto.CapabilityVision, dto.CapabilityReasoning}
+	audio         = []dto.Capability{dto.CapabilityAudio}
+	embeddings    = []dto.Capability{dto.CapabilityEmbeddings}
+	image         = []dto.Capability{dto.CapabilityImage}
+)
+
+// known are the capabilities of the public OpenAI models, the API does not report them.
+var known = map[string]info{
+	"gpt-3.5-turbo":             {chat, 16_385},
+	"gpt-3.5-turbo-instruct":    {nil, 4_096},
+	"gpt-4":                     {chat, 8_192},
+	"gpt-4-turbo":               {chatVision, 128_000},
+	"gpt-4o":                    {chatVision, 128_000},
+	"gpt-4o-mini":               {chatVision, 128_000},
+	"gpt-4o-audio-preview":      {chatAudio, 128_000},
+	"gpt-4o-mini-audio-preview": {chatAudio, 128_000},
+	"gpt-4o-realtime-preview":   {audio, 128_000},
+	"gpt-4o-transcribe":         {audio, 16_000},
+	"gpt-4o-mini-transcribe":    {audio, 16_000},
+	"gpt-4o-mini-tts":           {audio, 0},
+	"chatgpt-4o-latest":         {chatVision, 128_000},
+	"gpt-4.1":                   {chatVision, 1_047_576},
+	"gpt-4.1-mini":              {chatVision, 1_047_576},
+	"gpt-4.1-nano":              {chatVision, 1_047_576},
+	"o1":                        {allReasoning, 200_000},
+	"o1-mini":                   {chatReasoning, 128_000},
+	"o3":                        {allReasoning, 200_000},
+	"o3-mini":                   {chatReasoning, 200_000},
+	"o4-mini":                   {allReasoning, 200_000},
+	"text-embedding-3-small":    {embeddings, 8_191},
+	"text-embedding-3-large":    {embeddings, 8_191},
+	"text-embedding-ada-002":    {embeddings, 8_191},
+	"whisper-1":                 {audio, 0},
+	"tts-1":                     {audio, 0},
+	"dall-e-2":                  {image, 0},
+	"dall-e-3":                  {image, 0},
+	"gpt-image-1":               {image, 0},
+}
+
+// describe adds the known capabilities to the model, matching the longest known model name it starts with.
+func describe(model dto.Model) dto.Model {
+	var best string
+
+	for name := range known {
+		if strings.HasPrefix(model.ID, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+
+	if best == "" {
+		return model
+	}
+
+	model.Capabilities = known[best].capabilities
+	model.ContextWindow = known[best].contextWindow
+
+	return model
+}
@@ -0,0 +1,18 @@
+package catalog
+
+type Option func(*Catalog)
+
+// WithCache keeps the list in the cache, the scope separates the lists of different APIs.
+func WithCache(cache store, scope string) Option {
+	return func(c *Catalog) {
+		c.cache = cache
+		c.scope = scope
+	}
+}
+
+// WithRefresh fetches the list even if it is cached.
+func WithRefresh(refresh bool) Option {
+	return func(c *Catalog) {
+		c.refresh = refresh
+	}
+}
@@ -0,0 +1,105 @@
+// Package compare asks several models the same question at once.
+package compare
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+type assistant interface {
+	SendChatMessage(ctx context.Context, chat *dto.Chat, question string) (string, error)
+}
+
+type pricer interface {
+	Cost(model string, usage dto.Usage) (float64, bool)
+}
+
+// Answer is the answer of a model with what it took, Error is set instead of Content if the request failed.
+type Answer struct {
+	Model   string        `json:"model"`
+	Content string        `json:"content,omitempty"`
+	Latency time.Duration `json:"-"`
+	Usage   *dto.Usage    `json:"usage,omitempty"`
+	// Cost is not set if the price of the model is unknown.
+	Cost  *float64 `json:"cost,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// MarshalJSON writes the latency in milliseconds.
+func (a Answer) MarshalJSON() ([]byte, error) {
+	type answer Answer
+
+	return json.Marshal(struct {
+		answer
+		LatencyMS int64 `json:"latency_ms"`
+	}{answer(a), a.Latency.Milliseconds()})
+}
+
+// Comparer sends the question to every model concurrently.
+type Comparer struct {
+	assistant assistant
+	prices    pricer
+}
+
+func New(assistant assistant, prices pricer) *Comparer {
+	return &Comparer{
+		assistant: assistant,
+		prices:    prices,
+	}
+}
+
+// Compare asks the question in a copy of the chat for every model and returns the answers in the order of the models.
+func (c *Comparer) Compare(ctx context.Context, base dto.Chat, models []string, question string) []Answer {
+	answers := make([]Answer, len(models))
+
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			chat := base
+			chat.Nodes = slices.Clone(base.Nodes)
+			chat.Model = model
+
+			answers[i] = c.ask(ctx, &chat, question)
+		}()
+	}
+
+	wg.Wait()
+
+	return answers
+}
+
+func (c *Comparer) ask(ctx context.Context, chat *dto.Chat, question string) Answer {
+	answer := Answer{Model: chat.Model}
+
+	start := time.Now()
+	content, err := c.assistant.SendChatMessage(ctx, chat, question)
+	answer.Latency = time.Since(start)
+
+	if err != nil {
+		answer.Error = err.Error()
+		return answer
+	}
+
+	answer.Content = content
+
+	last, _ := chat.LastMessage()
+	if last.Usage == nil {
+		return answer
+	}
+
+	answer.Usage = last.Usage
+
+	if cost, ok := c.prices.Cost(last.Model, *last.Usage); ok {
+		answer.Cost = &cost
+	}
+
+	return answer
+}
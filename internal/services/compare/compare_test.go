@@ -0,0 +1,58 @@
+package compare_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/compare"
+)
+
+type assistant struct{}
+
+func (assistant) SendChatMessage(_ context.Context, chat *dto.Chat, question string) (string, error) {
+	if chat.Model == "missing" {
+		return "", errors.New("model missing does not exist")
+	}
+
+	chat.AddMessage(dto.RoleUser, question)
+	chat.Add(dto.Message{
+		Role:    dto.RoleAssistant,
+		Content: chat.System + " " + chat.Model,
+		Model:   chat.Model,
+		Usage:   &dto.Usage{PromptTokens: 1_000_000},
+	})
+
+	return chat.System + " " + chat.Model, nil
+}
+
+func TestComparer(t *testing.T) {
+	base := dto.NewChat()
+	base.System = "Hi from"
+
+	answers := compare.New(assistant{}, pricing.New(nil)).
+		Compare(context.Background(), *base, []string{"gpt-4o", "custom", "missing"}, "Who are you?")
+
+	assert.Len(t, answers, 3)
+	assert.Empty(t, base.Nodes)
+
+	assert.Equal(t, "gpt-4o", answers[0].Model)
+	assert.Equal(t, "Hi from gpt-4o", answers[0].Content)
+	assert.Equal(t, 2.5, *answers[0].Cost)
+
+	assert.Equal(t, "Hi from custom", answers[1].Content)
+	assert.NotNil(t, answers[1].Usage)
+	assert.Nil(t, answers[1].Cost)
+
+	assert.Equal(t, "model missing does not exist", answers[2].Error)
+	assert.Nil(t, answers[2].Usage)
+
+	data, err := json.Marshal(answers[2])
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"model": "missing", "error": "model missing does not exist", "latency_ms": 0}`, string(data))
+}
@@ -0,0 +1,115 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	defaultAlternatives = 3
+
+	messageOnAlternatives = "Type /pick <n> to continue the chat with one of the answers."
+	messageOnPick         = "The answer %d has been added to the chat."
+)
+
+var errNoAlternatives = errors.New("there are no answers to pick from, request them with /alternatives")
+
+// alternatives are the answers waiting for /pick.
+type alternatives struct {
+	// question is the node of the question they answer.
+	question   int
+	completion dto.Completion
+}
+
+func (d *Dialog) alternatives(ctx context.Context, args []string) error {
+	n := defaultAlternatives
+	if len(args) > 0 {
+		var err error
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 2 {
+			return fmt.Errorf("the number of answers must be a number above 1, got %q", args[0])
+		}
+	}
+
+	last, ok := d.chat.LastMessage()
+	if !ok {
+		return errNothingToRegenerate
+	}
+
+	// The answers go next to the current answer, the chat stays where it is until /pick.
+	previous := d.chat.Head
+	if last.Role == dto.RoleAssistant {
+		d.chat.Rewind(1)
+	}
+	question := d.chat.Head
+
+	cancel := d.printer.Loading(messageOnLoading)
+
+	completion, err := d.assistant.Alternatives(d.usageContext(ctx), d.chat, n)
+	cancel()
+
+	if checkoutErr := d.chat.Checkout(previous); checkoutErr != nil {
+		return checkoutErr
+	}
+	if err != nil {
+		return err
+	}
+
+	d.pending = &alternatives{question: question, completion: completion}
+
+	d.printer.AI(FormatChoices(completion.Choices))
+	d.printer.System(messageOnAlternatives)
+
+	return nil
+}
+
+func (d *Dialog) pick(_ context.Context, args []string) error {
+	if d.pending == nil {
+		return errNoAlternatives
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("the answer number must be a number, got %q", args[0])
+	}
+
+	previous := d.chat.Head
+	if err = d.chat.Checkout(d.pending.question); err != nil {
+		return err
+	}
+
+	if err = d.assistant.Pick(d.chat, d.pending.completion, n-1); err != nil {
+		_ = d.chat.Checkout(previous)
+		return err
+	}
+
+	d.pending = nil
+	d.failed = ""
+	d.printer.System(fmt.Sprintf(messageOnPick, n))
+
+	return nil
+}
+
+// FormatChoices numbers the answers and shows why each of them ended.
+func FormatChoices(choices []dto.Choice) string {
+	var b strings.Builder
+
+	for i, choice := range choices {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&b, "**Answer %d**", i+1)
+		if choice.FinishReason != "" {
+			fmt.Fprintf(&b, " (finish reason: %s)", choice.FinishReason)
+		}
+		b.WriteString("\n\n")
+		b.WriteString(choice.Content)
+	}
+
+	return b.String()
+}
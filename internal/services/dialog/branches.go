@@ -0,0 +1,172 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	messageOnCheckout = "Switched to the branch ending with message %d (%d messages)."
+	messageOnNoChat   = "The chat is empty."
+
+	previewLength = 60
+)
+
+var errNothingToRegenerate = errors.New("nothing to regenerate")
+
+func (d *Dialog) regen(ctx context.Context, _ []string) error {
+	if d.failed != "" {
+		return d.send(ctx, d.failed)
+	}
+
+	last, ok := d.chat.LastMessage()
+	if !ok {
+		return errNothingToRegenerate
+	}
+
+	previous := d.chat.Head
+	if last.Role == dto.RoleAssistant {
+		d.chat.Rewind(1)
+	}
+
+	cancel := d.printer.Loading(messageOnLoading)
+
+	answer, err := d.assistant.Regenerate(d.usageContext(ctx), d.chat)
+	cancel()
+
+	if err != nil {
+		_ = d.chat.Checkout(previous)
+		return err
+	}
+
+	d.printer.AI(answer)
+
+	return nil
+}
+
+func (d *Dialog) edit(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return d.compose(ctx, "")
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("the message number must be a number, got %q", args[0])
+	}
+
+	node, ok := d.question(n)
+	if !ok {
+		return fmt.Errorf("there is no question %d in the current branch", n)
+	}
+
+	var text string
+	if len(args) > 1 {
+		text = args[1]
+	} else if text, err = d.composeText(node.Content); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(text) == "" {
+		d.printer.System(messageOnEmpty)
+		return nil
+	}
+
+	// The edited question becomes a sibling of the original one.
+	if err = d.chat.Checkout(node.ParentID); err != nil {
+		return err
+	}
+
+	return d.send(ctx, text)
+}
+
+func (d *Dialog) branches(_ context.Context, _ []string) error {
+	leaves := d.chat.Leaves()
+	if len(leaves) == 0 {
+		d.printer.System(messageOnNoChat)
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Branches:")
+
+	for _, leaf := range leaves {
+		marker := " "
+		if leaf.ID == d.chat.Head {
+			marker = "*"
+		}
+
+		path := d.chat.PathTo(leaf.ID)
+		fmt.Fprintf(&b, "\n%s %d  %d messages  %s", marker, leaf.ID, len(path), preview(path))
+	}
+
+	d.printer.System(b.String())
+
+	return nil
+}
+
+func (d *Dialog) checkout(_ context.Context, args []string) error {
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("the branch id must be a number, got %q", args[0])
+	}
+
+	if err = d.chat.Checkout(id); err != nil {
+		return err
+	}
+
+	d.failed = ""
+	d.printer.System(fmt.Sprintf(messageOnCheckout, id, len(d.chat.Path())))
+
+	return nil
+}
+
+func (d *Dialog) completeBranches(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+
+	var ids []string
+	for _, leaf := range d.chat.Leaves() {
+		ids = append(ids, strconv.Itoa(leaf.ID))
+	}
+
+	return ids
+}
+
+// question returns the n-th question of the active branch counting from one.
+func (d *Dialog) question(n int) (dto.Node, bool) {
+	for _, node := range d.chat.Path() {
+		if node.Role != dto.RoleUser {
+			continue
+		}
+
+		if n--; n == 0 {
+			return node, true
+		}
+	}
+
+	return dto.Node{}, false
+}
+
+// preview returns the beginning of the last question of the branch.
+func preview(path []dto.Node) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i].Role != dto.RoleUser {
+			continue
+		}
+
+		text := strings.Join(strings.Fields(path[i].Content), " ")
+		if runes := []rune(text); len(runes) > previewLength {
+			text = string(runes[:previewLength]) + "..."
+		}
+
+		return text
+	}
+
+	return ""
+}
@@ -0,0 +1,463 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/sessions"
+	"github.com/andrian0vv/chatgpt-cli/internal/slash"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+)
+
+const (
+	messageOnReset   = "The chat has been reset."
+	messageOnUndo    = "The last exchange has been removed, it stays available in /branches."
+	messageOnEmpty   = "The message is empty, nothing has been sent."
+	messageOnSave    = "The chat has been saved as %s."
+	messageOnLoad    = "The chat %s has been loaded (%d messages)."
+	messageOnModel   = "The model has been switched to %s."
+	messageOnTokens  = "The current branch has %d messages, the last %d of them are sent with the next question, about %d tokens."
+	messageOnNoModel = "The session model %s is not available, keeping %s: %v"
+)
+
+var (
+	errNothingToUndo = errors.New("nothing to undo")
+	errNoComposer    = errors.New("composing in an editor is not supported here")
+)
+
+func (d *Dialog) registerCommands() *slash.Registry {
+	r := slash.NewRegistry()
+
+	r.Register(
+		slash.Command{
+			Name:     "help",
+			Args:     "[command]",
+			Help:     "Show the commands or the help for one command",
+			MaxArgs:  1,
+			Complete: d.completeCommands,
+			Run:      d.help,
+		},
+		slash.Command{
+			Name:     "model",
+			Args:     "[name]",
+			Help:     "Show the current model or switch to another one",
+			MaxArgs:  1,
+			Complete: d.completeModels,
+			Run:      d.model,
+		},
+		slash.Command{
+			Name:     "preset",
+			Args:     "[name|none]",
+			Help:     "Show the current preset or apply another one",
+			MaxArgs:  1,
+			Complete: d.completePresets,
+			Run:      d.preset,
+		},
+		slash.Command{
+			Name:     "set",
+			Args:     "<param> [value]",
+			Help:     "Set a sampling parameter for the chat, without a value reset it to the default",
+			MinArgs:  1,
+			MaxArgs:  2,
+			Complete: d.completeParams,
+			Run:      d.set,
+		},
+		slash.Command{
+			Name: "params",
+			Help: "Show the sampling parameters of the next request",
+			Run:  d.params,
+		},
+		slash.Command{
+			Name: "models",
+			Help: "List the available models",
+			Run:  d.models,
+		},
+		slash.Command{
+			Name: "reset",
+			Help: "Start the chat from scratch",
+			Run:  d.reset,
+		},
+		slash.Command{
+			Name:    "regen",
+			Aliases: []string{"retry"},
+			Help:    "Get a new answer to the last question, the previous answer stays in its branch",
+			Run:     d.regen,
+		},
+		slash.Command{
+			Name:    "alternatives",
+			Args:    "[n]",
+			Help:    "Get n answers to the last question in one request, 3 by default",
+			MaxArgs: 1,
+			Run:     d.alternatives,
+		},
+		slash.Command{
+			Name:    "pick",
+			Args:    "<n>",
+			Help:    "Continue the chat with the n-th answer from /alternatives",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Run:     d.pick,
+		},
+		slash.Command{
+			Name: "undo",
+			Help: "Remove the last question and its answer",
+			Run:  d.undo,
+		},
+		slash.Command{
+			Name:    "edit",
+			Args:    "[n] [text]",
+			Help:    "Rewrite your n-th question in a new branch, without arguments compose the next message in $EDITOR",
+			MaxArgs: 2,
+			Run:     d.edit,
+		},
+		slash.Command{
+			Name: "branches",
+			Help: "List the branches of the chat",
+			Run:  d.branches,
+		},
+		slash.Command{
+			Name:     "checkout",
+			Args:     "<id>",
+			Help:     "Switch to the branch ending with the message",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: d.completeBranches,
+			Run:      d.checkout,
+		},
+		slash.Command{
+			Name:     "save",
+			Args:     "[name]",
+			Help:     "Save the chat, by default under the current or a generated name",
+			MaxArgs:  1,
+			Complete: d.completeSessions,
+			Run:      d.save,
+		},
+		slash.Command{
+			Name:     "load",
+			Args:     "<name>",
+			Help:     "Load a saved chat",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: d.completeSessions,
+			Run:      d.load,
+		},
+		slash.Command{
+			Name:    "title",
+			Args:    "[text]",
+			Help:    "Set the title of the chat, without a text generate a new title and tags",
+			MaxArgs: 1,
+			Run:     d.title,
+		},
+		slash.Command{
+			Name:    "export",
+			Args:    "<path>",
+			Help:    "Write the chat to a file, the extension picks the format: .md, .html, .json or .txt",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Run:     d.export,
+		},
+		slash.Command{
+			Name: "tokens",
+			Help: "Show the size of the conversation",
+			Run:  d.tokens,
+		},
+		slash.Command{
+			Name:    "exit",
+			Aliases: []string{"quit"},
+			Help:    "Leave the chat",
+			Run:     d.exit,
+		},
+	)
+
+	return r
+}
+
+func (d *Dialog) help(_ context.Context, args []string) error {
+	if len(args) == 0 {
+		d.printer.System("Commands:\n" + d.commands.Help() + "\nStart a message with // to send it as is, e.g. //etc/hosts.")
+		return nil
+	}
+
+	c, ok := d.commands.Lookup(args[0])
+	if !ok {
+		return fmt.Errorf("%w %s", slash.ErrUnknownCommand, args[0])
+	}
+
+	d.printer.System(fmt.Sprintf("%s  %s", c.Usage(), c.Help))
+
+	return nil
+}
+
+func (d *Dialog) model(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		d.printer.AI(d.assistant.Model())
+		return nil
+	}
+
+	if err := d.assistant.SetModel(ctx, args[0]); err != nil {
+		return fmt.Errorf("switch model: %w", err)
+	}
+
+	d.printer.System(fmt.Sprintf(messageOnModel, d.assistant.Model()))
+
+	return nil
+}
+
+func (d *Dialog) models(ctx context.Context, _ []string) error {
+	models, err := d.assistant.GetModels(ctx)
+	if err != nil {
+		return err
+	}
+
+	var answer strings.Builder
+	for _, model := range models {
+		answer.WriteString(fmt.Sprintf("* %s\n", model))
+	}
+
+	d.printer.AI(answer.String())
+
+	return nil
+}
+
+func (d *Dialog) reset(_ context.Context, _ []string) error {
+	d.chat.Reset()
+	d.session = nil
+	d.failed = ""
+	d.pending = nil
+	d.titled = false
+	d.printer.System(messageOnReset)
+
+	return nil
+}
+
+func (d *Dialog) undo(_ context.Context, _ []string) error {
+	last, ok := d.chat.LastMessage()
+	if !ok {
+		return errNothingToUndo
+	}
+
+	if last.Role == dto.RoleAssistant {
+		d.chat.Rewind(2)
+	} else {
+		d.chat.Rewind(1)
+	}
+
+	d.failed = ""
+	d.printer.System(messageOnUndo)
+
+	return nil
+}
+
+// compose sends a message written with the composer.
+func (d *Dialog) compose(ctx context.Context, initial string) error {
+	message, err := d.composeText(initial)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(message) == "" {
+		d.printer.System(messageOnEmpty)
+		return nil
+	}
+
+	return d.send(ctx, message)
+}
+
+func (d *Dialog) composeText(initial string) (string, error) {
+	if d.composer == nil {
+		return "", errNoComposer
+	}
+
+	message, err := d.composer(initial)
+	if err != nil {
+		return "", fmt.Errorf("compose message: %w", err)
+	}
+
+	return message, nil
+}
+
+func (d *Dialog) save(_ context.Context, args []string) error {
+	session := d.session
+	if session == nil || len(args) > 0 && args[0] != session.Name {
+		session = &dto.Session{}
+		// A copy saved under another name keeps the title.
+		if d.session != nil {
+			session.Title, session.Tags = d.session.Title, d.session.Tags
+		}
+	}
+
+	if len(args) > 0 {
+		session.Name = args[0]
+	}
+
+	session.Model = d.assistant.Model()
+	session.Chat = d.chat
+
+	// A name given by the user replaces the session, a generated one must not.
+	var err error
+	if len(args) > 0 || !session.CreatedAt.IsZero() {
+		err = d.store.Save(session)
+	} else {
+		err = d.create(session)
+	}
+	if err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
+	d.session = session
+	d.printer.System(fmt.Sprintf(messageOnSave, session.Name))
+
+	return nil
+}
+
+// create saves a new chat under a generated name, the next free one is taken
+// if another chat has been saved under the name in the meantime.
+func (d *Dialog) create(session *dto.Session) error {
+	for {
+		if session.Name == "" {
+			name, err := d.store.NewName(time.Now())
+			if err != nil {
+				return fmt.Errorf("generate session name: %w", err)
+			}
+			session.Name = name
+		}
+
+		err := d.store.Create(session)
+		if !errors.Is(err, sessions.ErrExists) {
+			return err
+		}
+
+		session.Name = ""
+	}
+}
+
+// LoadSession continues the saved chat, e.g. one picked when the chat starts.
+func (d *Dialog) LoadSession(ctx context.Context, name string) error {
+	return d.load(ctx, []string{name})
+}
+
+func (d *Dialog) load(ctx context.Context, args []string) error {
+	session, err := d.store.Load(args[0])
+	if err != nil {
+		return fmt.Errorf("load session: %w", err)
+	}
+
+	d.session = session
+	d.chat = session.Chat
+	d.pending = nil
+	d.titled = false
+
+	if session.Model != "" && session.Model != d.assistant.Model() {
+		if err = d.assistant.SetModel(ctx, session.Model); err != nil {
+			d.printer.System(fmt.Sprintf(messageOnNoModel, session.Model, d.assistant.Model(), err))
+		}
+	}
+
+	d.printer.System(fmt.Sprintf(messageOnLoad, session.Name, len(d.chat.Messages())))
+
+	return nil
+}
+
+func (d *Dialog) tokens(_ context.Context, _ []string) error {
+	messages := d.chat.Messages()
+
+	var b strings.Builder
+	sent := d.chat.Sent()
+	fmt.Fprintf(&b, messageOnTokens, len(messages), len(sent)-len(d.chat.Preamble()), tokens.EstimateMessages(sent))
+
+	spent := d.chat.Usage()
+	if len(spent) == 0 {
+		d.printer.System(b.String())
+		return nil
+	}
+
+	models := make([]string, 0, len(spent))
+	for model := range spent {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	var (
+		total    dto.Usage
+		cost     float64
+		unpriced []string
+	)
+
+	for _, model := range models {
+		total = total.Add(spent[model])
+
+		if d.prices == nil {
+			continue
+		}
+
+		if c, ok := d.prices.Cost(model, spent[model]); ok {
+			cost += c
+		} else {
+			unpriced = append(unpriced, model)
+		}
+	}
+
+	fmt.Fprintf(&b, "\nSpent in the chat: %d prompt tokens (%d cached), %d completion tokens (%d reasoning)",
+		total.PromptTokens, total.CachedTokens, total.CompletionTokens, total.ReasoningTokens)
+
+	if d.prices != nil {
+		fmt.Fprintf(&b, ", about $%.4f", cost)
+		if len(unpriced) > 0 {
+			fmt.Fprintf(&b, " without %s, their price is unknown", strings.Join(unpriced, ", "))
+		}
+	}
+
+	b.WriteString(".")
+
+	d.printer.System(b.String())
+
+	return nil
+}
+
+func (d *Dialog) exit(_ context.Context, _ []string) error {
+	return ErrExit
+}
+
+func (d *Dialog) completeCommands(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+
+	var names []string
+	for _, c := range d.commands.Commands() {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+func (d *Dialog) completeModels(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+
+	models, err := d.assistant.GetModels(context.Background())
+	if err != nil {
+		return nil
+	}
+
+	return models
+}
+
+func (d *Dialog) completeSessions(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+
+	names, err := d.store.Names()
+	if err != nil {
+		return nil
+	}
+
+	return names
+}
@@ -0,0 +1,156 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/presets"
+	"github.com/andrian0vv/chatgpt-cli/internal/slash"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
+)
+
+// ErrExit is returned by Handle when the user asks to leave the chat.
+var ErrExit = errors.New("exit")
+
+const messageOnLoading = "Thinking"
+
+type assistant interface {
+	Model() string
+	SetModel(ctx context.Context, model string) error
+	Params() dto.Params
+	GetModels(ctx context.Context) ([]string, error)
+	SendChatMessage(ctx context.Context, chat *dto.Chat, question string) (string, error)
+	Regenerate(ctx context.Context, chat *dto.Chat) (string, error)
+	Alternatives(ctx context.Context, chat *dto.Chat, n int) (dto.Completion, error)
+	Pick(chat *dto.Chat, completion dto.Completion, i int) error
+}
+
+type printer interface {
+	System(message string)
+	AI(message string)
+	Loading(message string) func()
+}
+
+type store interface {
+	Save(session *dto.Session) error
+	Create(session *dto.Session) error
+	Load(name string) (*dto.Session, error)
+	Names() ([]string, error)
+	NewName(now time.Time) (string, error)
+}
+
+type pricer interface {
+	Cost(model string, usage dto.Usage) (float64, bool)
+}
+
+type presetStore interface {
+	Load(name string) (*presets.Preset, error)
+	Names() ([]string, error)
+}
+
+// Composer lets the user write a message outside the chat prompt, e.g. in $EDITOR.
+type Composer func(initial string) (string, error)
+
+// Dialog is a service that keeps the chat state and handles the user input with the chat commands.
+type Dialog struct {
+	assistant assistant
+	printer   printer
+	store     store
+	composer  Composer
+	prices    pricer
+	presets   presetStore
+	titler    titler
+	commands  *slash.Registry
+
+	// autoTitles generates the title of a chat after its first answer.
+	autoTitles bool
+
+	chat    *dto.Chat
+	session *dto.Session
+	// failed keeps the question of the last failed request for /retry.
+	failed string
+	// pending keeps the answers of /alternatives for /pick.
+	pending *alternatives
+	// titled is set once the title of the chat has been generated or tried.
+	titled bool
+}
+
+func New(assistant assistant, printer printer, store store, opts ...Option) *Dialog {
+	d := &Dialog{
+		assistant: assistant,
+		printer:   printer,
+		store:     store,
+		chat:      dto.NewChat(),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	d.commands = d.registerCommands()
+
+	return d
+}
+
+// Chat returns the current chat.
+func (d *Dialog) Chat() *dto.Chat {
+	return d.chat
+}
+
+// Handle runs a command or sends the line to the AI.
+func (d *Dialog) Handle(ctx context.Context, line string) error {
+	line = strings.TrimSpace(line)
+
+	switch {
+	case line == "":
+		return nil
+	case slash.IsCommand(line):
+		return d.commands.Execute(ctx, line)
+	default:
+		return d.send(ctx, slash.Unescape(line))
+	}
+}
+
+// Complete suggests commands and their arguments for the typed line.
+func (d *Dialog) Complete(line string) []string {
+	return d.commands.Complete(line)
+}
+
+func (d *Dialog) send(ctx context.Context, question string) error {
+	cancel := d.printer.Loading(messageOnLoading)
+
+	answer, err := d.assistant.SendChatMessage(d.usageContext(ctx), d.chat, question)
+	cancel()
+
+	if err != nil {
+		d.failed = question
+		return fmt.Errorf("%w, type /retry to send it again", err)
+	}
+
+	d.failed = ""
+	d.printer.AI(answer)
+
+	d.autoTitle(ctx)
+
+	return nil
+}
+
+// usageContext marks the requests with the session name in the usage ledger.
+// A chat that has not been saved yet gets the name it will be saved under.
+func (d *Dialog) usageContext(ctx context.Context) context.Context {
+	if d.session == nil {
+		d.session = &dto.Session{}
+	}
+
+	if d.session.Name == "" {
+		if name, err := d.store.NewName(time.Now()); err == nil {
+			d.session.Name = name
+		}
+	}
+
+	return usage.WithSession(ctx, d.session.Name)
+}
@@ -0,0 +1,668 @@
+package dialog_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/export"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/presets"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/assistant"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/assistant/mocks"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/dialog"
+	"github.com/andrian0vv/chatgpt-cli/internal/sessions"
+	"github.com/andrian0vv/chatgpt-cli/internal/slash"
+	"github.com/andrian0vv/chatgpt-cli/internal/tokens"
+)
+
+type printer struct {
+	system  []string
+	ai      []string
+	loading []string
+}
+
+func (p *printer) System(message string) {
+	p.system = append(p.system, message)
+}
+
+func (p *printer) AI(message string) {
+	p.ai = append(p.ai, message)
+}
+
+func (p *printer) Loading(message string) func() {
+	p.loading = append(p.loading, message)
+	return func() {}
+}
+
+func newDialog(t *testing.T, clientFn func(c *mocks.Mockclient)) (*dialog.Dialog, *printer) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	c := mocks.NewMockclient(ctrl)
+	clientFn(c)
+	c.EXPECT().Model().Return("gpt-4o").AnyTimes()
+
+	a := assistant.New(c, l)
+
+	p := &printer{}
+
+	return dialog.New(a, p, sessions.New(t.TempDir())), p
+}
+
+func TestDialog_Messages(t *testing.T) {
+	d, p := newDialog(t, func(c *mocks.Mockclient) {
+		gomock.InOrder(
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Hi!"}, nil),
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{}, errors.New("API error")),
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Fine"}, nil),
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Great"}, nil),
+		)
+	})
+
+	ctx := context.Background()
+
+	assert.NoError(t, d.Handle(ctx, "Hello"))
+	assert.Error(t, d.Handle(ctx, "How are you?"))
+	assert.NoError(t, d.Handle(ctx, "/retry"))
+	assert.NoError(t, d.Handle(ctx, "/retry"))
+
+	assert.Equal(t, []string{"Hi!", "Fine", "Great"}, p.ai)
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "Hello"},
+		{Role: dto.RoleAssistant, Content: "Hi!"},
+		{Role: dto.RoleUser, Content: "How are you?"},
+		{Role: dto.RoleAssistant, Content: "Great"},
+	}, d.Chat().Messages())
+
+	assert.NoError(t, d.Handle(ctx, "/undo"))
+	assert.Len(t, d.Chat().Messages(), 2)
+
+	assert.NoError(t, d.Handle(ctx, "/reset"))
+	assert.Empty(t, d.Chat().Messages())
+	assert.Error(t, d.Handle(ctx, "/undo"))
+	assert.Error(t, d.Handle(ctx, "/retry"))
+}
+
+func TestDialog_Branches(t *testing.T) {
+	d, p := newDialog(t, func(c *mocks.Mockclient) {
+		gomock.InOrder(
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Hi!"}, nil),
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Lisbon"}, nil),
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Porto"}, nil),
+			c.EXPECT().
+				CreateChatCompletion(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, chat *dto.Chat) (dto.Completion, error) {
+					assert.Equal(t, []dto.Message{
+						{Role: dto.RoleUser, Content: "Hello"},
+						{Role: dto.RoleAssistant, Content: "Hi!"},
+						{Role: dto.RoleUser, Content: "Capital of Spain?"},
+					}, chat.Messages())
+					return dto.Completion{Content: "Madrid"}, nil
+				}),
+		)
+	})
+
+	ctx := context.Background()
+
+	assert.NoError(t, d.Handle(ctx, "Hello"))
+	assert.NoError(t, d.Handle(ctx, "Capital of Portugal?"))
+	assert.NoError(t, d.Handle(ctx, "/regen"))
+	assert.NoError(t, d.Handle(ctx, "/edit 2 Capital of Spain?"))
+
+	assert.Equal(t, []string{"Hi!", "Lisbon", "Porto", "Madrid"}, p.ai)
+
+	p.system = nil
+	assert.NoError(t, d.Handle(ctx, "/branches"))
+	assert.Equal(t, []string{"Branches:\n" +
+		"  4  4 messages  Capital of Portugal?\n" +
+		"  5  4 messages  Capital of Portugal?\n" +
+		"* 7  4 messages  Capital of Spain?",
+	}, p.system)
+
+	assert.NoError(t, d.Handle(ctx, "/checkout 4"))
+	assert.Equal(t, "Lisbon", d.Chat().Messages()[3].Content)
+	assert.Equal(t, []string{"/checkout 4", "/checkout 5", "/checkout 7"}, d.Complete("/checkout "))
+
+	assert.Error(t, d.Handle(ctx, "/checkout 42"))
+	assert.Error(t, d.Handle(ctx, "/edit 3 text"))
+	assert.Error(t, d.Handle(ctx, "/edit first text"))
+}
+
+func TestDialog_Commands(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		clientFn func(c *mocks.Mockclient)
+		system   []string
+		ai       []string
+		wantErr  error
+	}{
+		{
+			name: "model",
+			line: "/model",
+			clientFn: func(c *mocks.Mockclient) {
+				c.EXPECT().Model().Return("gpt-4o")
+			},
+			ai: []string{"gpt-4o"},
+		},
+		{
+			name: "switch model",
+			line: "/model gpt-4o-mini",
+			clientFn: func(c *mocks.Mockclient) {
+				gomock.InOrder(
+					c.EXPECT().GetModels(gomock.Any()).Return([]string{"gpt-4o", "gpt-4o-mini"}, nil),
+					c.EXPECT().SetModel("gpt-4o-mini"),
+					c.EXPECT().Model().Return("gpt-4o-mini"),
+				)
+			},
+			system: []string{"The model has been switched to gpt-4o-mini."},
+		},
+		{
+			name: "switch to unknown model",
+			line: "/model unknown",
+			clientFn: func(c *mocks.Mockclient) {
+				c.EXPECT().GetModels(gomock.Any()).Return([]string{"gpt-4o", "gpt-4o-mini"}, nil)
+			},
+			wantErr: errors.New("switch model: validate model: unknown model unknown, see the models command for the available ones"),
+		},
+		{
+			name: "models",
+			line: "/models",
+			clientFn: func(c *mocks.Mockclient) {
+				c.EXPECT().GetModels(gomock.Any()).Return([]string{"gpt-4o", "o3-mini"}, nil)
+			},
+			ai: []string{"* gpt-4o\n* o3-mini\n"},
+		},
+		{
+			name:     "tokens",
+			line:     "/tokens",
+			clientFn: func(*mocks.Mockclient) {},
+			system:   []string{"The current branch has 0 messages, the last 0 of them are sent with the next question, about 0 tokens."},
+		},
+		{
+			name:     "exit",
+			line:     "/exit",
+			clientFn: func(*mocks.Mockclient) {},
+			wantErr:  dialog.ErrExit,
+		},
+		{
+			name:     "unknown",
+			line:     "/unknown",
+			clientFn: func(*mocks.Mockclient) {},
+			wantErr:  slash.ErrUnknownCommand,
+		},
+		{
+			name:     "empty line",
+			line:     "   ",
+			clientFn: func(*mocks.Mockclient) {},
+		},
+		{
+			name: "escaped slash",
+			line: "//etc/hosts",
+			clientFn: func(c *mocks.Mockclient) {
+				c.EXPECT().
+					CreateChatCompletion(gomock.Any(), messagesAre(dto.Message{Role: dto.RoleUser, Content: "/etc/hosts"})).
+					Return(dto.Completion{Content: "A file"}, nil)
+			},
+			ai: []string{"A file"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, p := newDialog(t, tc.clientFn)
+
+			err := d.Handle(context.Background(), tc.line)
+			switch {
+			case tc.wantErr == nil:
+				assert.NoError(t, err)
+			case errors.Is(err, tc.wantErr):
+			default:
+				assert.EqualError(t, err, tc.wantErr.Error())
+			}
+
+			assert.Equal(t, tc.system, p.system)
+			assert.Equal(t, tc.ai, p.ai)
+		})
+	}
+}
+
+func TestDialog_Tokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	c := mocks.NewMockclient(ctrl)
+	c.EXPECT().Model().Return("gpt-4o").AnyTimes()
+	gomock.InOrder(
+		c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{
+			Content: "Hi!",
+			Model:   "gpt-4o-2024-08-06",
+			Usage:   dto.Usage{PromptTokens: 1000, CompletionTokens: 100, CachedTokens: 200},
+		}, nil),
+		c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{
+			Content: "Hello!",
+			Model:   "unknown-model",
+			Usage:   dto.Usage{PromptTokens: 10, CompletionTokens: 5},
+		}, nil),
+	)
+
+	a := assistant.New(c, l)
+
+	p := &printer{}
+	d := dialog.New(a, p, sessions.New(t.TempDir()), dialog.WithPrices(pricing.New(nil)))
+
+	assert.NoError(t, d.Handle(ctx, "Hello"))
+	assert.NoError(t, d.Handle(ctx, "/regen"))
+	assert.NoError(t, d.Handle(ctx, "/tokens"))
+
+	assert.Equal(t, []string{
+		"The current branch has 2 messages, the last 2 of them are sent with the next question, about 15 tokens.\n" +
+			"Spent in the chat: 1010 prompt tokens (200 cached), 105 completion tokens (0 reasoning), " +
+			"about $0.0032 without unknown-model, their price is unknown.",
+	}, p.system)
+}
+
+func TestDialog_TokensLong(t *testing.T) {
+	d, p := newDialog(t, func(*mocks.Mockclient) {})
+
+	d.Chat().System = "Be brief."
+	for i := range 25 {
+		d.Chat().AddMessage(dto.RoleUser, fmt.Sprintf("Question %d", i))
+	}
+
+	// Only the preamble and the last messages are sent, the estimate counts them.
+	sent := append([]dto.Message{{Role: dto.RoleSystem, Content: "Be brief."}}, d.Chat().Messages()[5:]...)
+
+	assert.NoError(t, d.Handle(context.Background(), "/tokens"))
+	assert.Equal(t, []string{fmt.Sprintf(
+		"The current branch has 25 messages, the last 20 of them are sent with the next question, about %d tokens.",
+		tokens.EstimateMessages(sent),
+	)}, p.system)
+	assert.Less(t, tokens.EstimateMessages(sent), tokens.EstimateMessages(d.Chat().Prompt()))
+}
+
+func TestDialog_SaveLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	c := mocks.NewMockclient(ctrl)
+	c.EXPECT().Model().Return("gpt-4o").AnyTimes()
+	c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Hi!"}, nil)
+
+	a := assistant.New(c, l)
+
+	store := sessions.New(t.TempDir())
+
+	first := dialog.New(a, &printer{}, store)
+	assert.NoError(t, first.Handle(ctx, "Hello"))
+	assert.NoError(t, first.Handle(ctx, "/save greeting"))
+
+	p := &printer{}
+	second := dialog.New(a, p, store)
+	assert.NoError(t, second.Handle(ctx, "/load greeting"))
+	assert.Equal(t, untimed(first.Chat()), untimed(second.Chat()))
+	assert.Equal(t, []string{"The chat greeting has been loaded (2 messages)."}, p.system)
+
+	assert.Equal(t, []string{"/load greeting"}, second.Complete("/load gr"))
+	assert.ErrorIs(t, second.Handle(ctx, "/load missing"), sessions.ErrNotFound)
+
+	third := dialog.New(a, &printer{}, store)
+	assert.NoError(t, third.LoadSession(ctx, "greeting"))
+	assert.Equal(t, first.Chat().Messages(), third.Chat().Messages())
+}
+
+func TestDialog_SaveGeneratedName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	c := mocks.NewMockclient(ctrl)
+	c.EXPECT().Model().Return("gpt-4o").AnyTimes()
+	c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Hi!"}, nil).Times(2)
+
+	a := assistant.New(c, l)
+	store := sessions.New(t.TempDir())
+
+	// Both chats get the same name before any of them is saved, e.g. in two terminals.
+	first, second := dialog.New(a, &printer{}, store), dialog.New(a, &printer{}, store)
+	assert.NoError(t, first.Handle(ctx, "Hello"))
+	assert.NoError(t, second.Handle(ctx, "Bonjour"))
+
+	assert.NoError(t, first.Handle(ctx, "/save"))
+	assert.NoError(t, second.Handle(ctx, "/save"))
+
+	names, err := store.Names()
+	assert.NoError(t, err)
+	assert.Len(t, names, 2)
+
+	for i, question := range []string{"Hello", "Bonjour"} {
+		session, err := store.Load(names[i])
+		assert.NoError(t, err)
+		assert.Equal(t, question, session.Chat.Messages()[0].Content)
+	}
+
+	// Saving again keeps the name.
+	assert.NoError(t, second.Handle(ctx, "/save"))
+	names, err = store.Names()
+	assert.NoError(t, err)
+	assert.Len(t, names, 2)
+}
+
+func TestDialog_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	c := mocks.NewMockclient(ctrl)
+	c.EXPECT().Model().Return("gpt-4o").AnyTimes()
+	c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Hi!", Model: "gpt-4o"}, nil)
+
+	p := &printer{}
+	d := dialog.New(assistant.New(c, l), p, sessions.New(t.TempDir()))
+	dir := t.TempDir()
+
+	assert.NoError(t, d.Handle(ctx, "/export "+filepath.Join(dir, "empty.md")))
+	assert.NoFileExists(t, filepath.Join(dir, "empty.md"))
+
+	assert.NoError(t, d.Handle(ctx, "Hello"))
+	assert.NoError(t, d.Handle(ctx, "/save greeting"))
+
+	path := filepath.Join(dir, "greeting.md")
+	assert.NoError(t, d.Handle(ctx, "/export "+path))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# greeting\n")
+	assert.Contains(t, string(data), "\n\nHello\n")
+	assert.Regexp(t, `## AI · [0-9-]+ [0-9:]+ · gpt-4o\n\nHi!\n`, string(data))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	assert.ErrorIs(t, d.Handle(ctx, "/export "+filepath.Join(dir, "greeting.pdf")), export.ErrUnknownFormat)
+	assert.Equal(t, []string{
+		"The chat is empty.",
+		"The chat has been saved as greeting.",
+		"The chat has been exported to " + path + ".",
+	}, p.system)
+}
+
+// titler names the chats after the number of the call.
+type titler struct {
+	calls int
+}
+
+func (t *titler) Generate(_ context.Context, chat *dto.Chat) (string, []string, error) {
+	t.calls++
+	if len(chat.Path()) == 0 {
+		return "", nil, errors.New("the chat has no messages")
+	}
+
+	return fmt.Sprintf("Title %d", t.calls), []string{"greeting"}, nil
+}
+
+func TestDialog_Title(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	c := mocks.NewMockclient(ctrl)
+	c.EXPECT().Model().Return("gpt-4o").AnyTimes()
+	c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Hi!"}, nil).Times(4)
+
+	store := sessions.New(t.TempDir())
+	tt := &titler{}
+	p := &printer{}
+	d := dialog.New(assistant.New(c, l), p, store, dialog.WithTitler(tt, true))
+
+	// The first answer names the chat, the next ones keep the name.
+	assert.NoError(t, d.Handle(ctx, "Hello"))
+	assert.NoError(t, d.Handle(ctx, "Hello again"))
+	assert.NoError(t, d.Handle(ctx, "/save greeting"))
+	assert.Equal(t, 1, tt.calls)
+
+	// The title is generated after the answer with its own loading indicator.
+	assert.Equal(t, []string{"Thinking", "Naming the chat", "Thinking"}, p.loading)
+
+	session, err := store.Load("greeting")
+	assert.NoError(t, err)
+	assert.Equal(t, "Title 1", session.Title)
+	assert.Equal(t, []string{"greeting"}, session.Tags)
+
+	assert.NoError(t, d.Handle(ctx, "/title"))
+	assert.NoError(t, d.Handle(ctx, "/title  My own title "))
+	assert.NoError(t, d.Handle(ctx, "/save copy"))
+
+	session, err = store.Load("copy")
+	assert.NoError(t, err)
+	assert.Equal(t, "My own title", session.Title)
+
+	assert.Equal(t, []string{
+		"The chat has been saved as greeting.",
+		`The chat is titled "Title 2" with the tags greeting, /save keeps the title.`,
+		`The chat is titled "My own title" with the tags greeting, /save keeps the title.`,
+		"The chat has been saved as copy.",
+	}, p.system)
+
+	// A chat loaded with a title keeps it.
+	second := dialog.New(assistant.New(c, l), &printer{}, store, dialog.WithTitler(tt, true))
+	assert.NoError(t, second.LoadSession(ctx, "greeting"))
+	assert.NoError(t, second.Handle(ctx, "Hello"))
+	assert.Equal(t, 2, tt.calls)
+
+	// Without the automatic titles only /title names the chat.
+	third := dialog.New(assistant.New(c, l), &printer{}, store, dialog.WithTitler(tt, false))
+	assert.NoError(t, third.Handle(ctx, "Hello"))
+	assert.Equal(t, 2, tt.calls)
+	assert.NoError(t, third.Handle(ctx, "/title"))
+	assert.Equal(t, 3, tt.calls)
+
+	assert.ErrorContains(t, dialog.New(assistant.New(c, l), &printer{}, store).Handle(ctx, "/title"), "not supported")
+}
+
+func TestDialog_Preset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	model := "gpt-4o-mini"
+
+	c := mocks.NewMockclient(ctrl)
+	c.EXPECT().GetModels(gomock.Any()).Return([]string{"gpt-4o", "gpt-4o-mini"}, nil)
+	c.EXPECT().Model().DoAndReturn(func() string { return model }).AnyTimes()
+	c.EXPECT().SetModel("gpt-4o").Do(func(m string) { model = m })
+	c.EXPECT().
+		CreateChatCompletion(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, chat *dto.Chat) (dto.Completion, error) {
+			assert.Equal(t, []dto.Message{
+				{Role: dto.RoleSystem, Content: "You write SQL."},
+				{Role: dto.RoleUser, Content: "Count users"},
+				{Role: dto.RoleAssistant, Content: "SELECT count(*) FROM users;"},
+				{Role: dto.RoleUser, Content: "Count orders"},
+			}, chat.Prompt())
+			return dto.Completion{Content: "SELECT count(*) FROM orders;"}, nil
+		})
+
+	a := assistant.New(c, l)
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "sql.yaml"), []byte(`model: gpt-4o
+system: You write SQL.
+examples:
+  - user: Count users
+    assistant: SELECT count(*) FROM users;
+params:
+  temperature: 0
+`), 0o600))
+
+	p := &printer{}
+	d := dialog.New(a, p, sessions.New(t.TempDir()), dialog.WithPresets(presets.NewWithDirs(dir)))
+
+	assert.NoError(t, d.Handle(ctx, "/preset"))
+	assert.NoError(t, d.Handle(ctx, "/set top_p 0.5"))
+	assert.NoError(t, d.Handle(ctx, "/preset sql"))
+	assert.NoError(t, d.Handle(ctx, "Count orders"))
+	assert.ErrorIs(t, d.Handle(ctx, "/preset missing"), presets.ErrNotFound)
+	assert.Equal(t, []string{"/preset sql", "/preset none"}, d.Complete("/preset "))
+
+	assert.Equal(t, "sql", d.Chat().Preset)
+	assert.Equal(t, float32(0), *d.Chat().Params.Temperature)
+	assert.Equal(t, float32(0.5), *d.Chat().Params.TopP)
+
+	// The parameters set by the user stay when the preset is removed.
+	assert.NoError(t, d.Handle(ctx, "/set max_tokens 100"))
+	assert.NoError(t, d.Handle(ctx, "/preset none"))
+	assert.Empty(t, d.Chat().Preamble())
+	assert.Len(t, d.Chat().Messages(), 2)
+	assert.Nil(t, d.Chat().Params.Temperature)
+	assert.Equal(t, float32(0.5), *d.Chat().Params.TopP)
+	assert.Equal(t, 100, d.Chat().Params.MaxTokens)
+
+	assert.Equal(t, []string{
+		"The current preset is none. Available presets: sql.",
+		"The top_p has been set to 0.5.",
+		"The preset sql has been applied, the model has been switched to gpt-4o.",
+		"The max_tokens has been set to 100.",
+		"The preset has been removed.",
+	}, p.system)
+}
+
+func TestDialog_Params(t *testing.T) {
+	temperature := float32(0.7)
+
+	d, p := newDialog(t, func(c *mocks.Mockclient) {
+		c.EXPECT().Params().Return(dto.Params{Temperature: &temperature, User: "alice"}).AnyTimes()
+	})
+
+	ctx := context.Background()
+
+	assert.NoError(t, d.Handle(ctx, "/set temperature 0.2"))
+	assert.NoError(t, d.Handle(ctx, "/set top-p 0.9"))
+	assert.NoError(t, d.Handle(ctx, `/set stop "END OF ANSWER"`))
+	assert.NoError(t, d.Handle(ctx, "/set logit_bias 50256=-100,1734=5"))
+	assert.NoError(t, d.Handle(ctx, "/set top_p"))
+	assert.Error(t, d.Handle(ctx, "/set temperature 3"))
+	assert.Error(t, d.Handle(ctx, "/set logit_bias hello=1"))
+	assert.Error(t, d.Handle(ctx, "/set color blue"))
+	assert.Equal(t, []string{"/set top_p"}, d.Complete("/set top_"))
+
+	params := d.Chat().Params
+	assert.Equal(t, float32(0.2), *params.Temperature)
+	assert.Nil(t, params.TopP)
+	assert.Equal(t, []string{"END OF ANSWER"}, params.Stop)
+	assert.Equal(t, map[string]int{"50256": -100, "1734": 5}, params.LogitBias)
+
+	p.system = nil
+	assert.NoError(t, d.Handle(ctx, "/params"))
+	assert.Equal(t, []string{`Parameters of the next request, /set <name> <value> changes them:
+temperature        0.2                chat
+top_p              not set
+max_tokens         not set
+presence_penalty   not set
+frequency_penalty  not set
+seed               not set
+stop               "END OF ANSWER"    chat
+logit_bias         1734=5,50256=-100  chat
+user               alice              default`}, p.system)
+}
+
+func TestDialog_Alternatives(t *testing.T) {
+	d, p := newDialog(t, func(c *mocks.Mockclient) {
+		gomock.InOrder(
+			c.EXPECT().CreateChatCompletion(gomock.Any(), gomock.Any()).Return(dto.Completion{Content: "Blue"}, nil),
+			c.EXPECT().
+				CreateChatCompletion(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, chat *dto.Chat) (dto.Completion, error) {
+					assert.Equal(t, []dto.Message{{Role: dto.RoleUser, Content: "Pick a color"}}, chat.Messages())
+					assert.Equal(t, 2, chat.Params.N)
+					return dto.Completion{
+						Content: "Red",
+						Choices: []dto.Choice{
+							{Content: "Red", FinishReason: "stop"},
+							{Content: "Green is", FinishReason: "length"},
+						},
+					}, nil
+				}),
+		)
+	})
+
+	ctx := context.Background()
+
+	assert.ErrorContains(t, d.Handle(ctx, "/pick 1"), "/alternatives")
+	assert.NoError(t, d.Handle(ctx, "Pick a color"))
+	assert.Error(t, d.Handle(ctx, "/alternatives 1"))
+	assert.NoError(t, d.Handle(ctx, "/alternatives 2"))
+
+	assert.Equal(t, "Blue", d.Chat().Messages()[1].Content)
+	assert.Equal(t, "**Answer 1** (finish reason: stop)\n\nRed\n\n**Answer 2** (finish reason: length)\n\nGreen is", p.ai[1])
+
+	assert.Error(t, d.Handle(ctx, "/pick 3"))
+	assert.Equal(t, "Blue", d.Chat().Messages()[1].Content)
+
+	assert.NoError(t, d.Handle(ctx, "/pick 2"))
+	assert.Equal(t, []dto.Message{
+		{Role: dto.RoleUser, Content: "Pick a color"},
+		{Role: dto.RoleAssistant, Content: "Green is"},
+	}, d.Chat().Messages())
+	assert.Len(t, d.Chat().Leaves(), 2)
+	assert.ErrorContains(t, d.Handle(ctx, "/pick 1"), "/alternatives")
+}
+
+// messagesAre matches a chat by the messages of its active branch, the times of the messages differ.
+func messagesAre(messages ...dto.Message) gomock.Matcher {
+	return chatMessages(messages)
+}
+
+type chatMessages []dto.Message
+
+func (m chatMessages) Matches(x any) bool {
+	chat, ok := x.(*dto.Chat)
+	return ok && reflect.DeepEqual([]dto.Message(m), chat.Messages())
+}
+
+func (m chatMessages) String() string {
+	return fmt.Sprintf("is a chat with the messages %v", []dto.Message(m))
+}
+
+// untimed returns a copy of the chat without the times of the messages,
+// they lose the monotonic clock reading and the location in a saved chat.
+func untimed(chat *dto.Chat) *dto.Chat {
+	out := *chat
+	out.Nodes = make([]dto.Node, len(chat.Nodes))
+	for i, node := range chat.Nodes {
+		node.Time = time.Time{}
+		out.Nodes[i] = node
+	}
+	return &out
+}
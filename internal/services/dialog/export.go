@@ -0,0 +1,50 @@
+package dialog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/export"
+)
+
+const messageOnExport = "The chat has been exported to %s."
+
+func (d *Dialog) export(_ context.Context, args []string) error {
+	if len(d.chat.Path()) == 0 {
+		d.printer.System(messageOnNoChat)
+		return nil
+	}
+
+	path := args[0]
+
+	format, err := export.FormatOf(path)
+	if err != nil {
+		return err
+	}
+
+	// An unsaved chat is exported without a name, a saved one with the name and the dates of its session.
+	session := &dto.Session{}
+	if d.session != nil {
+		*session = *d.session
+	}
+	session.Model = d.assistant.Model()
+	session.Chat = d.chat
+
+	// Render first so a failure does not leave a truncated file behind.
+	var b bytes.Buffer
+	if err = export.Write(&b, session, format); err != nil {
+		return fmt.Errorf("export chat: %w", err)
+	}
+
+	// The chat may hold secrets, the export is private like the saved chats.
+	if err = atomicfile.Write(path, b.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+
+	d.printer.System(fmt.Sprintf(messageOnExport, path))
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package dialog
+
+type Option func(*Dialog)
+
+func WithComposer(composer Composer) Option {
+	return func(d *Dialog) {
+		d.composer = composer
+	}
+}
+
+// WithPrices enables the cost estimates in /tokens.
+func WithPrices(prices pricer) Option {
+	return func(d *Dialog) {
+		d.prices = prices
+	}
+}
+
+// WithPresets enables /preset.
+func WithPresets(presets presetStore) Option {
+	return func(d *Dialog) {
+		d.presets = presets
+	}
+}
+
+// WithTitler enables /title generating the titles, with auto the chats are titled after the first answer.
+func WithTitler(titler titler, auto bool) Option {
+	return func(d *Dialog) {
+		d.titler = titler
+		d.autoTitles = auto
+	}
+}
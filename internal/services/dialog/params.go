@@ -0,0 +1,81 @@
+package dialog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	messageOnSet   = "The %s has been set to %s."
+	messageOnUnset = "The %s has been reset to the default."
+	messageParams  = "Parameters of the next request, /set <name> <value> changes them:\n%s"
+
+	paramNotSet = "not set"
+)
+
+func (d *Dialog) set(_ context.Context, args []string) error {
+	var value string
+	if len(args) > 1 {
+		value = args[1]
+	}
+
+	params := d.chat.Params.Clone()
+	if err := params.Set(args[0], value); err != nil {
+		return err
+	}
+
+	d.chat.Params = params
+
+	name := strings.ReplaceAll(args[0], "-", "_")
+	if value == "" {
+		d.printer.System(fmt.Sprintf(messageOnUnset, name))
+	} else {
+		d.printer.System(fmt.Sprintf(messageOnSet, name, params.Get(name)))
+	}
+
+	return nil
+}
+
+func (d *Dialog) params(_ context.Context, _ []string) error {
+	defaults := d.assistant.Params()
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	for _, name := range dto.ParamNames {
+		value, source := d.chat.Params.Get(name), "chat"
+		if value == "" {
+			value, source = defaults.Get(name), "default"
+		}
+		if value == "" {
+			value, source = paramNotSet, ""
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, value, source)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	d.printer.System(fmt.Sprintf(messageParams, strings.Join(lines, "\n")))
+
+	return nil
+}
+
+func (d *Dialog) completeParams(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+
+	return dto.ParamNames
+}
@@ -0,0 +1,99 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/presets"
+)
+
+// presetNone removes the preset from the chat.
+const presetNone = "none"
+
+const (
+	messageOnPreset        = "The preset %s has been applied."
+	messageOnPresetModel   = "The preset %s has been applied, the model has been switched to %s."
+	messageOnPresetRemoved = "The preset has been removed."
+	messageOnPresets       = "The current preset is %s. Available presets: %s."
+)
+
+var errNoPresets = errors.New("presets are not available here")
+
+// UsePreset applies the preset to the chat and switches to its model.
+func (d *Dialog) UsePreset(ctx context.Context, name string) error {
+	if d.presets == nil {
+		return errNoPresets
+	}
+
+	if name == presetNone {
+		presets.Remove(d.chat)
+		d.printer.System(messageOnPresetRemoved)
+
+		return nil
+	}
+
+	p, err := d.presets.Load(name)
+	if err != nil {
+		return fmt.Errorf("load preset: %w", err)
+	}
+
+	if p.Model == "" || p.Model == d.assistant.Model() {
+		p.Apply(d.chat)
+		d.printer.System(fmt.Sprintf(messageOnPreset, p.Name))
+
+		return nil
+	}
+
+	if err = d.assistant.SetModel(ctx, p.Model); err != nil {
+		return fmt.Errorf("switch model: %w", err)
+	}
+
+	p.Apply(d.chat)
+	d.printer.System(fmt.Sprintf(messageOnPresetModel, p.Name, p.Model))
+
+	return nil
+}
+
+func (d *Dialog) preset(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return d.UsePreset(ctx, args[0])
+	}
+
+	if d.presets == nil {
+		return errNoPresets
+	}
+
+	names, err := d.presets.Names()
+	if err != nil {
+		return fmt.Errorf("list presets: %w", err)
+	}
+
+	current := d.chat.Preset
+	if current == "" {
+		current = presetNone
+	}
+
+	available := presetNone
+	if len(names) > 0 {
+		available = strings.Join(names, ", ")
+	}
+
+	d.printer.System(fmt.Sprintf(messageOnPresets, current, available))
+
+	return nil
+}
+
+func (d *Dialog) completePresets(args []string) []string {
+	if len(args) > 0 || d.presets == nil {
+		return nil
+	}
+
+	names, err := d.presets.Names()
+	if err != nil {
+		return nil
+	}
+
+	return append(names, presetNone)
+}
@@ -0,0 +1,89 @@
+package dialog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	messageOnTitle  = "The chat is titled %q%s, /save keeps the title."
+	messageOnNaming = "Naming the chat"
+)
+
+var errNoTitler = errors.New("generating titles is not supported here, set one with /title <text>")
+
+type titler interface {
+	Generate(ctx context.Context, chat *dto.Chat) (string, []string, error)
+}
+
+// title sets the title given as the argument or generates a new one with the tags.
+func (d *Dialog) title(ctx context.Context, args []string) error {
+	if d.session == nil {
+		d.session = &dto.Session{}
+	}
+
+	if len(args) > 0 {
+		d.session.Title = strings.TrimSpace(args[0])
+		d.printer.System(fmt.Sprintf(messageOnTitle, d.session.Title, tagsOf(d.session.Tags)))
+		return nil
+	}
+
+	if d.titler == nil {
+		return errNoTitler
+	}
+
+	if len(d.chat.Path()) == 0 {
+		d.printer.System(messageOnNoChat)
+		return nil
+	}
+
+	cancel := d.printer.Loading(messageOnLoading)
+	title, tags, err := d.titler.Generate(d.usageContext(ctx), d.chat)
+	cancel()
+
+	if err != nil {
+		return err
+	}
+
+	d.session.Title, d.session.Tags = title, tags
+	d.titled = true
+	d.printer.System(fmt.Sprintf(messageOnTitle, title, tagsOf(tags)))
+
+	return nil
+}
+
+// autoTitle names the chat after its first answer. It is tried once per chat
+// and a failure is not reported, the chat goes on without a title.
+func (d *Dialog) autoTitle(ctx context.Context) {
+	if d.titler == nil || !d.autoTitles || d.titled {
+		return
+	}
+
+	if d.session != nil && d.session.Title != "" {
+		return
+	}
+
+	d.titled = true
+
+	cancel := d.printer.Loading(messageOnNaming)
+	title, tags, err := d.titler.Generate(d.usageContext(ctx), d.chat)
+	cancel()
+
+	if err != nil {
+		return
+	}
+
+	d.session.Title, d.session.Tags = title, tags
+}
+
+func tagsOf(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	return " with the tags " + strings.Join(tags, ", ")
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import "github.com/andrian0vv/chatgpt-cli/internal/redact"
+
+type Option func(*Server)
+
+// WithToken requires the clients to send the token as their API key.
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithSession sets the session the usage of the relayed requests is recorded for, serve by default.
+func WithSession(session string) Option {
+	return func(s *Server) {
+		if session != "" {
+			s.session = session
+		}
+	}
+}
+
+// WithRedactor redacts every request with its own placeholders of the redactor,
+// so a client never gets the values sent by another one restored.
+func WithRedactor(redactor *redact.Redactor) Option {
+	return func(s *Server) {
+		s.redactor = redactor
+	}
+}
+
+// WithToolCalls accepts the tools of the requests and relays the tool calls of the answers, for a provider
+// deciding the calls itself, e.g. the mock one with its fixtures. The tools are not passed on to the provider.
+func WithToolCalls() Option {
+	return func(s *Server) {
+		s.toolCalls = true
+	}
+}
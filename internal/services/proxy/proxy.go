@@ -0,0 +1,370 @@
+// Package proxy serves an OpenAI compatible API relaying the requests through the assistant,
+// so other tools share the config, the response cache, the budget and the usage ledger of the CLI.
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/redact"
+	"github.com/andrian0vv/chatgpt-cli/internal/stream"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
+)
+
+const (
+	defaultSession = "serve"
+	// maxBodySize bounds the size of a request body.
+	maxBodySize = 10 << 20
+	// statusClientClosed is the status of the requests canceled by the client, it is only logged.
+	statusClientClosed = 499
+	finishStop         = "stop"
+)
+
+type assistant interface {
+	Complete(ctx context.Context, chat *dto.Chat) (dto.Completion, error)
+}
+
+type catalog interface {
+	List(ctx context.Context) ([]dto.Model, error)
+}
+
+// Server is an http.Handler of the chat completions and the models endpoints of the OpenAI API.
+type Server struct {
+	assistant assistant
+	catalog   catalog
+	log       *logger.Logger
+	token     string
+	session   string
+	redactor  *redact.Redactor
+	toolCalls bool
+
+	mux *http.ServeMux
+}
+
+func New(assistant assistant, catalog catalog, log *logger.Logger, opts ...Option) *Server {
+	s := &Server{
+		assistant: assistant,
+		catalog:   catalog,
+		log:       log,
+		session:   defaultSession,
+		mux:       http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("POST /v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("GET /v1/models", s.handleModels)
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("unknown endpoint %s %s", r.Method, r.URL.Path))
+	})
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	if s.authorized(r) {
+		s.mux.ServeHTTP(rec, r)
+	} else {
+		writeError(rec, http.StatusUnauthorized, "invalid_request_error", "invalid_api_key", "invalid proxy token")
+	}
+
+	s.log.Info("request",
+		logger.WithField("method", r.Method),
+		logger.WithField("path", r.URL.Path),
+		logger.WithField("status", rec.status),
+		logger.WithField("duration", time.Since(start).Round(time.Millisecond).String()),
+		logger.WithField("remote", r.RemoteAddr),
+	)
+}
+
+// authorized checks the bearer token of the request if the server has one.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var in chatRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := decoder.Decode(&in); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("parse request: %v", err))
+		return
+	}
+
+	chat, err := toChat(in, s.toolCalls)
+	if err != nil {
+		code := ""
+		if errors.Is(err, errUnsupported) {
+			code = "unsupported_parameter"
+		}
+		writeError(w, http.StatusBadRequest, "invalid_request_error", code, err.Error())
+		return
+	}
+
+	ctx := usage.WithSession(r.Context(), s.session)
+	if s.redactor != nil {
+		ctx = redact.WithRedactor(ctx, s.redactor.Scope())
+	}
+
+	// The pieces of an answer are sent as they come from a provider that streams.
+	var sw *streamWriter
+	if in.Stream {
+		sw = newStreamWriter(w, in.Model)
+		ctx = stream.WithHandler(ctx, sw.content)
+	}
+
+	completion, err := s.assistant.Complete(ctx, chat)
+	if err != nil {
+		// The status was sent with the first piece, the stream just ends.
+		if sw != nil && sw.started {
+			s.log.Warn("relay request", logger.WithError(err))
+			return
+		}
+
+		s.writeFailure(w, err)
+		return
+	}
+
+	s.log.Info("chat completion",
+		logger.WithField("model", completion.Model),
+		logger.WithField("prompt_tokens", completion.Usage.PromptTokens),
+		logger.WithField("completion_tokens", completion.Usage.CompletionTokens),
+		logger.WithField("cached", completion.Cached),
+	)
+
+	if completion.Cached {
+		w.Header().Set("X-Cache", "HIT")
+	}
+
+	if sw != nil {
+		sw.finish(completion, in.StreamOptions != nil && in.StreamOptions.IncludeUsage)
+		return
+	}
+
+	out := chatResponse{
+		ID:      newID(),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   completion.Model,
+		Usage:   toUsage(completion.Usage),
+	}
+
+	for i, choice := range choicesOf(completion) {
+		out.Choices = append(out.Choices, chatChoice{
+			Index:        i,
+			Message:      &responseMessage{Role: string(dto.RoleAssistant), Content: choice.Content, ToolCalls: toToolCalls(choice.ToolCalls)},
+			FinishReason: finishReason(choice),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// streamWriter sends a completion as server-sent events. The pieces of the content are sent as they come
+// from a provider that streams, otherwise every choice is sent in one chunk. The tool calls and the finish
+// reason of every choice follow the content.
+type streamWriter struct {
+	w       http.ResponseWriter
+	id      string
+	created int64
+	model   string
+	// started is set once the first chunk was sent, the content of the first choice was streamed then.
+	started bool
+}
+
+func newStreamWriter(w http.ResponseWriter, model string) *streamWriter {
+	return &streamWriter{
+		w:       w,
+		id:      newID(),
+		created: time.Now().Unix(),
+		model:   model,
+	}
+}
+
+// content sends a piece of the content of the first choice.
+func (s *streamWriter) content(piece string) {
+	delta := &responseMessage{Content: piece}
+	if !s.started {
+		s.start()
+		delta.Role = string(dto.RoleAssistant)
+	}
+
+	s.chunk([]chatChoice{{Delta: delta}}, nil)
+}
+
+// finish sends what was not streamed of the completion and ends the stream.
+func (s *streamWriter) finish(completion dto.Completion, includeUsage bool) {
+	s.model = completion.Model
+
+	streamed := s.started
+	if !s.started {
+		s.start()
+	}
+
+	for i, choice := range choicesOf(completion) {
+		delta := &responseMessage{ToolCalls: toToolCalls(choice.ToolCalls)}
+		if i > 0 || !streamed {
+			delta.Role, delta.Content = string(dto.RoleAssistant), choice.Content
+		}
+
+		if delta.Role != "" || len(delta.ToolCalls) > 0 {
+			for j := range delta.ToolCalls {
+				delta.ToolCalls[j].Index = &j
+			}
+			s.chunk([]chatChoice{{Index: i, Delta: delta}}, nil)
+		}
+
+		s.chunk([]chatChoice{{
+			Index:        i,
+			Delta:        &responseMessage{},
+			FinishReason: finishReason(choice),
+		}}, nil)
+	}
+
+	if includeUsage {
+		s.chunk([]chatChoice{}, toUsage(completion.Usage))
+	}
+
+	fmt.Fprint(s.w, "data: [DONE]\n\n")
+}
+
+func (s *streamWriter) start() {
+	s.w.Header().Set("Content-Type", "text/event-stream")
+	s.w.Header().Set("Cache-Control", "no-cache")
+	s.w.WriteHeader(http.StatusOK)
+	s.started = true
+}
+
+func (s *streamWriter) chunk(choices []chatChoice, u *tokenUsage) {
+	data, _ := json.Marshal(chatResponse{
+		ID:      s.id,
+		Object:  "chat.completion.chunk",
+		Created: s.created,
+		Model:   s.model,
+		Choices: choices,
+		Usage:   u,
+	})
+	fmt.Fprintf(s.w, "data: %s\n\n", data)
+
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	models, err := s.catalog.List(r.Context())
+	if err != nil {
+		s.writeFailure(w, err)
+		return
+	}
+
+	out := modelList{Object: "list", Data: make([]model, 0, len(models))}
+	for _, m := range models {
+		var created int64
+		if !m.Created.IsZero() {
+			created = m.Created.Unix()
+		}
+
+		out.Data = append(out.Data, model{ID: m.ID, Object: "model", Created: created, OwnedBy: m.OwnedBy})
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// writeFailure answers with the status and the error code the OpenAI API uses for the kind of the error.
+func (s *Server) writeFailure(w http.ResponseWriter, err error) {
+	s.log.Warn("relay request", logger.WithError(err))
+
+	status, errType, code := http.StatusInternalServerError, "server_error", ""
+
+	switch failure.KindOf(err) {
+	case failure.KindUsage:
+		status, errType = http.StatusBadRequest, "invalid_request_error"
+	case failure.KindAuth:
+		status, errType, code = http.StatusUnauthorized, "invalid_request_error", "invalid_api_key"
+	case failure.KindQuota:
+		status, errType, code = http.StatusTooManyRequests, "insufficient_quota", "insufficient_quota"
+	case failure.KindRateLimit:
+		status, errType, code = http.StatusTooManyRequests, "requests", "rate_limit_exceeded"
+	case failure.KindModelNotFound:
+		status, errType, code = http.StatusNotFound, "invalid_request_error", "model_not_found"
+	case failure.KindContextLength:
+		status, errType, code = http.StatusBadRequest, "invalid_request_error", "context_length_exceeded"
+	case failure.KindContentFilter:
+		status, errType, code = http.StatusBadRequest, "invalid_request_error", "content_filter"
+	case failure.KindNetwork:
+		status = http.StatusBadGateway
+	case failure.KindTimeout:
+		status = http.StatusGatewayTimeout
+	case failure.KindCanceled:
+		status = statusClientClosed
+	}
+
+	writeError(w, status, errType, code, err.Error())
+}
+
+func writeError(w http.ResponseWriter, status int, errType, code, message string) {
+	writeJSON(w, status, errorResponse{Error: errorBody{Message: message, Type: errType, Code: code}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func finishReason(choice dto.Choice) *string {
+	reason := choice.FinishReason
+	if reason == "" {
+		reason = finishStop
+	}
+
+	return &reason
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
+
+// statusRecorder keeps the status of the response for the log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,436 @@
+package proxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	goopenai "github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/mock"
+	"github.com/andrian0vv/chatgpt-cli/internal/clients/openai"
+	"github.com/andrian0vv/chatgpt-cli/internal/config"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/logger"
+	"github.com/andrian0vv/chatgpt-cli/internal/redact"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/assistant"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/catalog"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/proxy"
+)
+
+const token = "secret"
+
+// upstream is a stand-in of the OpenAI API, it answers with the last message and keeps the requests.
+type upstream struct {
+	mu       sync.Mutex
+	requests []goopenai.ChatCompletionRequest
+	fail     bool
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case "/v1/models":
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"gpt-4o","object":"model","created":1715367049,"owned_by":"system"}]}`)
+	case "/v1/chat/completions":
+		var in goopenai.ChatCompletionRequest
+		_ = json.NewDecoder(r.Body).Decode(&in)
+
+		u.mu.Lock()
+		u.requests = append(u.requests, in)
+		fail := u.fail
+		u.mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(goopenai.ChatCompletionResponse{
+			Model: in.Model,
+			Choices: []goopenai.ChatCompletionChoice{{
+				Message:      goopenai.ChatCompletionMessage{Role: "assistant", Content: "echo: " + in.Messages[len(in.Messages)-1].Content},
+				FinishReason: goopenai.FinishReasonStop,
+			}},
+			Usage: goopenai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+type recorder struct {
+	mu      sync.Mutex
+	records []dto.UsageRecord
+}
+
+func (r *recorder) Record(record dto.UsageRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, record)
+
+	return nil
+}
+
+// newProxy starts the proxy relaying to the upstream stand-in and returns the base URL of the proxy.
+func newProxy(t *testing.T) (*upstream, *recorder, string) {
+	t.Helper()
+
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	up := &upstream{}
+	upServer := httptest.NewServer(up)
+	t.Cleanup(upServer.Close)
+
+	client := openai.New(config.Config{OpenaiApiKey: "key", OpenaiBaseURL: upServer.URL + "/v1"}, l, openai.WithModel("gpt-4o"))
+
+	rec := &recorder{}
+	a := assistant.New(client, l, assistant.WithRecorder(rec))
+
+	server := httptest.NewServer(proxy.New(a, catalog.New(client), l, proxy.WithToken(token)))
+	t.Cleanup(server.Close)
+
+	return up, rec, server.URL + "/v1"
+}
+
+func newCaller(baseURL, token string) *goopenai.Client {
+	cfg := goopenai.DefaultConfig(token)
+	cfg.BaseURL = baseURL
+
+	return goopenai.NewClientWithConfig(cfg)
+}
+
+func TestServer_ChatCompletion(t *testing.T) {
+	up, rec, baseURL := newProxy(t)
+	caller := newCaller(baseURL, token)
+
+	// More messages than a chat of the CLI sends, none of them is dropped.
+	messages := []goopenai.ChatCompletionMessage{{Role: "system", Content: "Be brief"}}
+	for i := range 30 {
+		messages = append(messages, goopenai.ChatCompletionMessage{Role: "user", Content: fmt.Sprintf("question %d", i)})
+	}
+
+	out, err := caller.CreateChatCompletion(context.Background(), goopenai.ChatCompletionRequest{
+		Model:       "gpt-4o-mini",
+		Messages:    messages,
+		Temperature: 0.2,
+		Stop:        []string{"###"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "echo: question 29", out.Choices[0].Message.Content)
+	assert.Equal(t, goopenai.FinishReasonStop, out.Choices[0].FinishReason)
+	assert.Equal(t, 15, out.Usage.TotalTokens)
+
+	assert.Len(t, up.requests, 1)
+	assert.Equal(t, "gpt-4o-mini", up.requests[0].Model)
+	assert.Len(t, up.requests[0].Messages, 31)
+	assert.InDelta(t, 0.2, up.requests[0].Temperature, 1e-6)
+	assert.Equal(t, []string{"###"}, up.requests[0].Stop)
+
+	assert.Len(t, rec.records, 1)
+	assert.Equal(t, "serve", rec.records[0].Session)
+	assert.Equal(t, 15, rec.records[0].Total())
+}
+
+func TestServer_Stream(t *testing.T) {
+	_, _, baseURL := newProxy(t)
+	caller := newCaller(baseURL, token)
+
+	stream, err := caller.CreateChatCompletionStream(context.Background(), goopenai.ChatCompletionRequest{
+		Model:         "gpt-4o",
+		Messages:      []goopenai.ChatCompletionMessage{{Role: "user", Content: "Hi"}},
+		Stream:        true,
+		StreamOptions: &goopenai.StreamOptions{IncludeUsage: true},
+	})
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	var (
+		content string
+		reason  goopenai.FinishReason
+		usage   *goopenai.Usage
+	)
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			if choice.FinishReason != "" {
+				reason = choice.FinishReason
+			}
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+
+	assert.Equal(t, "echo: Hi", content)
+	assert.Equal(t, goopenai.FinishReasonStop, reason)
+	assert.Equal(t, &goopenai.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, usage)
+}
+
+func TestServer_Redaction(t *testing.T) {
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	up := &upstream{}
+	upServer := httptest.NewServer(up)
+	t.Cleanup(upServer.Close)
+
+	client := openai.New(config.Config{OpenaiApiKey: "key", OpenaiBaseURL: upServer.URL + "/v1"}, l, openai.WithModel("gpt-4o"))
+
+	redactor, err := redact.New(config.Redaction{Restore: true})
+	assert.NoError(t, err)
+
+	a := assistant.New(client, l, assistant.WithRedactor(redactor))
+	server := httptest.NewServer(proxy.New(a, catalog.New(client), l, proxy.WithRedactor(redactor)))
+	t.Cleanup(server.Close)
+
+	caller := newCaller(server.URL+"/v1", "")
+	ask := func(content string) string {
+		out, err := caller.CreateChatCompletion(context.Background(), goopenai.ChatCompletionRequest{
+			Model:    "gpt-4o",
+			Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: content}},
+		})
+		assert.NoError(t, err)
+
+		return out.Choices[0].Message.Content
+	}
+
+	assert.Equal(t, "echo: mail alice@example.com", ask("mail alice@example.com"))
+	assert.Equal(t, "mail [REDACTED_EMAIL_1]", up.requests[0].Messages[0].Content)
+
+	// Another client cannot get the value restored by sending its placeholder.
+	assert.Equal(t, "echo: who is [REDACTED_EMAIL_1]", ask("who is [REDACTED_EMAIL_1]"))
+}
+
+func TestServer_Models(t *testing.T) {
+	_, _, baseURL := newProxy(t)
+	caller := newCaller(baseURL, token)
+
+	models, err := caller.ListModels(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, models.Models, 1)
+	assert.Equal(t, "gpt-4o", models.Models[0].ID)
+	assert.Equal(t, "system", models.Models[0].OwnedBy)
+	assert.Equal(t, int64(1715367049), models.Models[0].CreatedAt)
+}
+
+func TestServer_Errors(t *testing.T) {
+	up, rec, baseURL := newProxy(t)
+	caller := newCaller(baseURL, token)
+	ctx := context.Background()
+	request := goopenai.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: "Hi"}},
+	}
+
+	// The upstream error keeps its status and code.
+	up.fail = true
+
+	_, err := caller.CreateChatCompletion(ctx, request)
+
+	var apiErr *goopenai.APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.HTTPStatusCode)
+	assert.Equal(t, "insufficient_quota", apiErr.Code)
+	assert.Empty(t, rec.records)
+
+	// A request without messages is refused before it is sent.
+	_, err = caller.CreateChatCompletion(ctx, goopenai.ChatCompletionRequest{Model: "gpt-4o"})
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.HTTPStatusCode)
+	assert.Len(t, up.requests, 1)
+
+	// The clients must send the token of the proxy.
+	_, err = newCaller(baseURL, "wrong").CreateChatCompletion(ctx, request)
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.HTTPStatusCode)
+	assert.Len(t, up.requests, 1)
+}
+
+func TestServer_Unsupported(t *testing.T) {
+	up, _, baseURL := newProxy(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"tools", `{"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}], "tools": [{"type": "function", "function": {"name": "f"}}]}`},
+		{"tool choice", `{"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}], "tool_choice": "auto"}`},
+		{"functions", `{"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}], "functions": [{"name": "f"}]}`},
+		{"json output", `{"model": "gpt-4o", "messages": [{"role": "user", "content": "Hi"}], "response_format": {"type": "json_object"}}`},
+		{"tool message", `{"model": "gpt-4o", "messages": [{"role": "tool", "tool_call_id": "call_1", "content": "42"}]}`},
+		{"tool calls", `{"model": "gpt-4o", "messages": [{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1"}]}]}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, baseURL+"/chat/completions", strings.NewReader(tc.body))
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			var out struct {
+				Error struct {
+					Code string `json:"code"`
+				} `json:"error"`
+			}
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&out))
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, "unsupported_parameter", out.Error.Code)
+		})
+	}
+
+	assert.Empty(t, up.requests)
+
+	// The text format is the default one, the temperature is not set to the one of the CLI.
+	_, err := newCaller(baseURL, token).CreateChatCompletion(context.Background(), goopenai.ChatCompletionRequest{
+		Model:          "gpt-4o",
+		Messages:       []goopenai.ChatCompletionMessage{{Role: "user", Content: "Hi"}},
+		ResponseFormat: &goopenai.ChatCompletionResponseFormat{Type: goopenai.ChatCompletionResponseFormatTypeText},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, up.requests, 1)
+	assert.Zero(t, up.requests[0].Temperature)
+}
+
+const mockFixtures = `
+rules:
+  - match: weather
+    script:
+      - tool_calls:
+          - name: get_weather
+            arguments: {city: Paris}
+      - chunks: [It is, " sunny."]
+`
+
+func TestServer_ToolCalls(t *testing.T) {
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	fixtures, err := mock.ParseFixtures([]byte(mockFixtures))
+	assert.NoError(t, err)
+
+	client := mock.New(l, mock.WithFixtures(fixtures))
+	server := httptest.NewServer(proxy.New(assistant.New(client, l), catalog.New(client), l, proxy.WithToolCalls()))
+	t.Cleanup(server.Close)
+
+	caller := newCaller(server.URL+"/v1", "")
+	ctx := context.Background()
+
+	request := goopenai.ChatCompletionRequest{
+		Model:    "mock",
+		Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: "What is the weather?"}},
+		Tools: []goopenai.Tool{{
+			Type:     goopenai.ToolTypeFunction,
+			Function: &goopenai.FunctionDefinition{Name: "get_weather"},
+		}},
+	}
+
+	out, err := caller.CreateChatCompletion(ctx, request)
+	assert.NoError(t, err)
+	assert.Equal(t, goopenai.FinishReasonToolCalls, out.Choices[0].FinishReason)
+	assert.Len(t, out.Choices[0].Message.ToolCalls, 1)
+
+	call := out.Choices[0].Message.ToolCalls[0]
+	assert.Equal(t, "get_weather", call.Function.Name)
+	assert.JSONEq(t, `{"city": "Paris"}`, call.Function.Arguments)
+
+	// The result of the call is sent back, the answer is streamed in its chunks.
+	request.Messages = append(request.Messages,
+		goopenai.ChatCompletionMessage{Role: "assistant", ToolCalls: out.Choices[0].Message.ToolCalls},
+		goopenai.ChatCompletionMessage{Role: "tool", ToolCallID: call.ID, Content: `{"sky": "clear"}`},
+	)
+	request.Stream = true
+
+	stream, err := caller.CreateChatCompletionStream(ctx, request)
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	var (
+		pieces []string
+		reason goopenai.FinishReason
+	)
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				pieces = append(pieces, choice.Delta.Content)
+			}
+			if choice.FinishReason != "" {
+				reason = choice.FinishReason
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"It is", " sunny."}, pieces)
+	assert.Equal(t, goopenai.FinishReasonStop, reason)
+}
+
+func TestServer_StreamToolCalls(t *testing.T) {
+	l := logger.New(nil, logger.WithEnabled(false))
+
+	fixtures, err := mock.ParseFixtures([]byte(mockFixtures))
+	assert.NoError(t, err)
+
+	client := mock.New(l, mock.WithFixtures(fixtures))
+	server := httptest.NewServer(proxy.New(assistant.New(client, l), catalog.New(client), l, proxy.WithToolCalls()))
+	t.Cleanup(server.Close)
+
+	stream, err := newCaller(server.URL+"/v1", "").CreateChatCompletionStream(context.Background(), goopenai.ChatCompletionRequest{
+		Model:    "mock",
+		Messages: []goopenai.ChatCompletionMessage{{Role: "user", Content: "weather"}},
+		Stream:   true,
+	})
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	var (
+		calls  []goopenai.ToolCall
+		reason goopenai.FinishReason
+	)
+
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+
+		for _, choice := range chunk.Choices {
+			calls = append(calls, choice.Delta.ToolCalls...)
+			if choice.FinishReason != "" {
+				reason = choice.FinishReason
+			}
+		}
+	}
+
+	assert.Len(t, calls, 1)
+	assert.Equal(t, 0, *calls[0].Index)
+	assert.Equal(t, "get_weather", calls[0].Function.Name)
+	assert.Equal(t, goopenai.FinishReasonToolCalls, reason)
+}
@@ -0,0 +1,299 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+// chatRequest is the body of a chat completion request in the OpenAI format.
+type chatRequest struct {
+	Model    string           `json:"model"`
+	Messages []requestMessage `json:"messages"`
+	Stream   bool             `json:"stream"`
+	// StreamOptions asks for the usage in the last chunk of the stream.
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options"`
+
+	Temperature         *float32       `json:"temperature"`
+	TopP                *float32       `json:"top_p"`
+	MaxTokens           int            `json:"max_tokens"`
+	MaxCompletionTokens int            `json:"max_completion_tokens"`
+	PresencePenalty     *float32       `json:"presence_penalty"`
+	FrequencyPenalty    *float32       `json:"frequency_penalty"`
+	Seed                *int           `json:"seed"`
+	Stop                stop           `json:"stop"`
+	LogitBias           map[string]int `json:"logit_bias"`
+	User                string         `json:"user"`
+	N                   int            `json:"n"`
+
+	// The tools, the functions and the structured outputs are not relayed, the requests using them are refused.
+	// The tools are accepted for a provider deciding the calls itself, see WithToolCalls.
+	Tools          json.RawMessage `json:"tools"`
+	ToolChoice     json.RawMessage `json:"tool_choice"`
+	Functions      json.RawMessage `json:"functions"`
+	FunctionCall   json.RawMessage `json:"function_call"`
+	ResponseFormat *struct {
+		Type string `json:"type"`
+	} `json:"response_format"`
+}
+
+type requestMessage struct {
+	Role    string  `json:"role"`
+	Content content `json:"content"`
+
+	ToolCalls    []toolCall      `json:"tool_calls"`
+	ToolCallID   string          `json:"tool_call_id"`
+	FunctionCall json.RawMessage `json:"function_call"`
+}
+
+// toolCall is a call of a function tool, the index is only set in the chunks of a stream.
+type toolCall struct {
+	Index    *int   `json:"index,omitempty"`
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// errUnsupported is returned for the requests using the features the proxy cannot relay.
+var errUnsupported = errors.New("not supported by the proxy")
+
+// content is a text or a list of parts of which only the text ones are supported.
+type content string
+
+func (c *content) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = content(text)
+		return nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return errors.New("content must be a string or a list of parts")
+	}
+
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type != "text" {
+			return fmt.Errorf("content parts of type %s are not supported", part.Type)
+		}
+		texts = append(texts, part.Text)
+	}
+
+	*c = content(strings.Join(texts, "\n"))
+
+	return nil
+}
+
+// stop is a stop sequence or a list of them.
+type stop []string
+
+func (s *stop) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var sequence string
+	if err := json.Unmarshal(data, &sequence); err == nil {
+		*s = stop{sequence}
+		return nil
+	}
+
+	var sequences []string
+	if err := json.Unmarshal(data, &sequences); err != nil {
+		return errors.New("stop must be a string or a list of strings")
+	}
+
+	*s = sequences
+
+	return nil
+}
+
+type chatResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []chatChoice `json:"choices"`
+	Usage   *tokenUsage  `json:"usage,omitempty"`
+}
+
+type chatChoice struct {
+	Index        int              `json:"index"`
+	Message      *responseMessage `json:"message,omitempty"`
+	Delta        *responseMessage `json:"delta,omitempty"`
+	FinishReason *string          `json:"finish_reason"`
+}
+
+type responseMessage struct {
+	Role      string     `json:"role,omitempty"`
+	Content   string     `json:"content"`
+	ToolCalls []toolCall `json:"tool_calls,omitempty"`
+}
+
+type tokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type modelList struct {
+	Object string  `json:"object"`
+	Data   []model `json:"data"`
+}
+
+type model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+// toChat converts the request to a chat sent with all its messages, the tool calls are kept if tools are accepted.
+func toChat(in chatRequest, tools bool) (*dto.Chat, error) {
+	if len(in.Messages) == 0 {
+		return nil, errors.New("messages must not be empty")
+	}
+
+	if err := checkSupported(in, tools); err != nil {
+		return nil, err
+	}
+
+	chat := dto.NewChat()
+	chat.Model = in.Model
+	chat.Full = true
+	chat.OwnParams = true
+
+	for i, message := range in.Messages {
+		if message.Role == "" {
+			return nil, fmt.Errorf("message %d has no role", i)
+		}
+		chat.Add(dto.Message{
+			Role:       dto.Role(message.Role),
+			Content:    string(message.Content),
+			ToolCalls:  fromToolCalls(message.ToolCalls),
+			ToolCallID: message.ToolCallID,
+		})
+	}
+
+	chat.Params = dto.Params{
+		Temperature:      in.Temperature,
+		TopP:             in.TopP,
+		MaxTokens:        max(in.MaxTokens, in.MaxCompletionTokens),
+		PresencePenalty:  in.PresencePenalty,
+		FrequencyPenalty: in.FrequencyPenalty,
+		Seed:             in.Seed,
+		Stop:             in.Stop,
+		LogitBias:        in.LogitBias,
+		User:             in.User,
+		N:                in.N,
+	}
+
+	return chat, nil
+}
+
+// checkSupported refuses the requests that would lose their meaning if the unsupported fields were dropped.
+func checkSupported(in chatRequest, tools bool) error {
+	type field struct {
+		name  string
+		value json.RawMessage
+	}
+
+	fields := []field{
+		{"functions", in.Functions},
+		{"function_call", in.FunctionCall},
+	}
+	if !tools {
+		fields = append(fields, field{"tools", in.Tools}, field{"tool_choice", in.ToolChoice})
+	}
+
+	for _, f := range fields {
+		if isSet(f.value) {
+			return fmt.Errorf("%s is %w", f.name, errUnsupported)
+		}
+	}
+
+	if in.ResponseFormat != nil && in.ResponseFormat.Type != "" && in.ResponseFormat.Type != "text" {
+		return fmt.Errorf("response_format %s is %w", in.ResponseFormat.Type, errUnsupported)
+	}
+
+	for i, message := range in.Messages {
+		switch {
+		case message.Role == "function", isSet(message.FunctionCall):
+			return fmt.Errorf("message %d: function calls are %w", i, errUnsupported)
+		case !tools && message.Role == string(dto.RoleTool):
+			return fmt.Errorf("message %d: the %s role is %w", i, message.Role, errUnsupported)
+		case !tools && (len(message.ToolCalls) > 0 || message.ToolCallID != ""):
+			return fmt.Errorf("message %d: tool calls are %w", i, errUnsupported)
+		}
+	}
+
+	return nil
+}
+
+func isSet(value json.RawMessage) bool {
+	return len(value) > 0 && !bytes.Equal(value, []byte("null"))
+}
+
+// choicesOf returns all the answers of the completion.
+func choicesOf(completion dto.Completion) []dto.Choice {
+	if len(completion.Choices) > 0 {
+		return completion.Choices
+	}
+
+	return []dto.Choice{{Content: completion.Content, FinishReason: completion.FinishReason, ToolCalls: completion.ToolCalls}}
+}
+
+func toToolCalls(calls []dto.ToolCall) []toolCall {
+	var out []toolCall
+	for _, call := range calls {
+		tc := toolCall{ID: call.ID, Type: "function"}
+		tc.Function.Name, tc.Function.Arguments = call.Name, call.Arguments
+		out = append(out, tc)
+	}
+
+	return out
+}
+
+func fromToolCalls(calls []toolCall) []dto.ToolCall {
+	var out []dto.ToolCall
+	for _, call := range calls {
+		out = append(out, dto.ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+
+	return out
+}
+
+func toUsage(u dto.Usage) *tokenUsage {
+	return &tokenUsage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.Total(),
+	}
+}
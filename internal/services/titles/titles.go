@@ -0,0 +1,175 @@
+// Package titles names the chats with a short title and a few tags generated by a model.
+package titles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	// DefaultModel is a cheap model, a title is not worth more.
+	DefaultModel = "gpt-4o-mini"
+
+	// maxMessages and maxMessageLength bound the part of the chat sent, its beginning tells what it is about.
+	maxMessages      = 4
+	maxMessageLength = 1500
+
+	maxTitleLength = 80
+	// maxTags is the number of tags asked for in the instructions, the extra ones are dropped.
+	maxTags = 4
+
+	instructions = `Name the conversation the user sends for a list of saved chats.
+Answer with exactly two lines and nothing else:
+Title: a specific title of at most 6 words in the language of the conversation, without quotes
+Tags: 1 to 4 short lowercase topics, comma separated`
+)
+
+// ErrNoTitle is returned when the answer of the model has no title in it.
+var ErrNoTitle = errors.New("no title in the answer")
+
+type assistant interface {
+	Complete(ctx context.Context, chat *dto.Chat) (dto.Completion, error)
+}
+
+// Titler generates the titles with its own model, the model of the chat is not used.
+type Titler struct {
+	assistant assistant
+	model     string
+}
+
+// New creates the titler with the model, DefaultModel if it is empty.
+func New(assistant assistant, model string) *Titler {
+	if model == "" {
+		model = DefaultModel
+	}
+
+	return &Titler{
+		assistant: assistant,
+		model:     model,
+	}
+}
+
+// Generate returns a title and the tags for the active branch of the chat.
+func (t *Titler) Generate(ctx context.Context, chat *dto.Chat) (string, []string, error) {
+	transcript := transcriptOf(chat)
+	if transcript == "" {
+		return "", nil, errors.New("the chat has no messages")
+	}
+
+	temperature := float32(0.2)
+
+	request := dto.NewChat()
+	request.System = instructions
+	request.Model = t.model
+	request.Params = dto.Params{Temperature: &temperature, MaxTokens: 60}
+	request.AddMessage(dto.RoleUser, transcript)
+
+	completion, err := t.assistant.Complete(ctx, request)
+	if err != nil {
+		return "", nil, fmt.Errorf("generate title: %w", err)
+	}
+
+	title, tags := Parse(completion.Content)
+	if title == "" {
+		return "", nil, ErrNoTitle
+	}
+
+	return title, tags, nil
+}
+
+// Parse reads the title and the tags from the answer. The first line is the title
+// when the answer does not follow the format.
+func Parse(answer string) (string, []string) {
+	var (
+		title string
+		tags  []string
+		first string
+	)
+
+	for _, line := range strings.Split(answer, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "*")
+		if line == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		switch key := strings.ToLower(strings.Trim(name, "* ")); {
+		case ok && key == "title":
+			title = value
+		case ok && key == "tags":
+			tags = parseTags(value)
+		case first == "":
+			first = line
+		}
+	}
+
+	if title == "" {
+		title = first
+	}
+
+	return cleanTitle(title), tags
+}
+
+func cleanTitle(title string) string {
+	title = strings.Trim(strings.TrimSpace(title), "\"'`*#. ")
+	title = strings.Join(strings.Fields(title), " ")
+
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		runes := []rune(title)
+		title = strings.TrimSpace(string(runes[:maxTitleLength-1])) + "…"
+	}
+
+	return title
+}
+
+func parseTags(value string) []string {
+	var tags []string
+
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.Trim(strings.TrimSpace(tag), "#\"'`*. "))
+		tag = strings.Join(strings.Fields(tag), "-")
+
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+		if len(tags) == maxTags {
+			break
+		}
+	}
+
+	return tags
+}
+
+// transcriptOf writes the first messages of the active branch with their authors.
+func transcriptOf(chat *dto.Chat) string {
+	var b strings.Builder
+
+	for i, node := range chat.Path() {
+		if i == maxMessages {
+			break
+		}
+
+		content := strings.TrimSpace(node.Content)
+		if len(content) > maxMessageLength {
+			content = strings.ToValidUTF8(content[:maxMessageLength], "") + "…"
+		}
+
+		author := "User"
+		if node.Role == dto.RoleAssistant {
+			author = "Assistant"
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "%s: %s", author, content)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package titles_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/services/titles"
+)
+
+// assistant answers with the content and keeps the request.
+type assistant struct {
+	content string
+	err     error
+	request *dto.Chat
+}
+
+func (a *assistant) Complete(_ context.Context, chat *dto.Chat) (dto.Completion, error) {
+	a.request = chat
+	return dto.Completion{Content: a.content}, a.err
+}
+
+func TestTitler_Generate(t *testing.T) {
+	chat := dto.NewChat()
+	chat.Model = "gpt-4o"
+	chat.AddMessage(dto.RoleUser, "How do I size a Kafka consumer group?")
+	chat.AddMessage(dto.RoleAssistant, "Up to the number of partitions.")
+
+	a := &assistant{content: "Title: Sizing Kafka consumer groups\nTags: kafka, Consumer Groups"}
+
+	title, tags, err := titles.New(a, "").Generate(context.Background(), chat)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sizing Kafka consumer groups", title)
+	assert.Equal(t, []string{"kafka", "consumer-groups"}, tags)
+
+	assert.Equal(t, titles.DefaultModel, a.request.Model)
+	assert.Equal(t, "User: How do I size a Kafka consumer group?\n\nAssistant: Up to the number of partitions.",
+		a.request.Messages()[0].Content)
+	assert.Equal(t, "gpt-4o", chat.Model)
+
+	_, _, err = titles.New(&assistant{content: "  "}, "cheap").Generate(context.Background(), chat)
+	assert.ErrorIs(t, err, titles.ErrNoTitle)
+
+	failure := errors.New("API error")
+	_, _, err = titles.New(&assistant{err: failure}, "cheap").Generate(context.Background(), chat)
+	assert.ErrorIs(t, err, failure)
+
+	_, _, err = titles.New(a, "").Generate(context.Background(), dto.NewChat())
+	assert.Error(t, err)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		title  string
+		tags   []string
+	}{
+		{
+			name:   "format",
+			answer: "Title: Trip to Lisbon\nTags: travel, portugal",
+			title:  "Trip to Lisbon",
+			tags:   []string{"travel", "portugal"},
+		},
+		{
+			name:   "markdown and quotes",
+			answer: "**Title:** \"Trip to Lisbon\".\n**Tags:** #travel, #Travel, city break",
+			title:  "Trip to Lisbon",
+			tags:   []string{"travel", "city-break"},
+		},
+		{
+			name:   "first line",
+			answer: "\nTrip to Lisbon\nsome explanation",
+			title:  "Trip to Lisbon",
+		},
+		{
+			name:   "long title",
+			answer: "Title: " + strings.Repeat("word ", 30),
+			title:  strings.TrimSpace(strings.Repeat("word ", 16)) + "…",
+		},
+		{
+			name:   "too many tags",
+			answer: "Title: Tags\nTags: a, b, c, d, e, f",
+			title:  "Tags",
+			tags:   []string{"a", "b", "c", "d"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			title, tags := titles.Parse(tc.answer)
+			assert.Equal(t, tc.title, title)
+			assert.Equal(t, tc.tags, tags)
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package sessions
+
+type Option func(*Store)
+
+// WithIndex updates the search index with every saved session.
+func WithIndex(index indexer) Option {
+	return func(s *Store) {
+		s.index = index
+	}
+}
@@ -0,0 +1,253 @@
+// Package sessions stores chats on disk as JSON files.
+package sessions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/atomicfile"
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	dirName   = "sessions"
+	extension = ".json"
+)
+
+var (
+	ErrNotFound    = errors.New("session not found")
+	ErrInvalidName = errors.New("invalid session name")
+	ErrExists      = errors.New("session already exists")
+
+	validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// indexer keeps a search index of the sessions, version is the modification time of the session file.
+type indexer interface {
+	Update(session *dto.Session, version time.Time) error
+}
+
+type Store struct {
+	dir   string
+	index indexer
+}
+
+// New creates a store keeping sessions in the sessions folder of the data directory.
+func New(dataDir string, opts ...Option) *Store {
+	s := &Store{
+		dir: filepath.Join(dataDir, dirName),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Save writes the session, creating or overwriting it.
+func (s *Store) Save(session *dto.Session) error {
+	if !validName.MatchString(session.Name) {
+		return fmt.Errorf("%w %q", ErrInvalidName, session.Name)
+	}
+
+	now := time.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+
+	return s.write(session, false)
+}
+
+// Create writes a new session and returns ErrExists if the name is taken,
+// e.g. by a chat saved in another terminal under the same generated name.
+func (s *Store) Create(session *dto.Session) error {
+	if !validName.MatchString(session.Name) {
+		return fmt.Errorf("%w %q", ErrInvalidName, session.Name)
+	}
+
+	now := time.Now()
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = now
+	}
+	session.UpdatedAt = now
+
+	return s.write(session, true)
+}
+
+// Put writes the session keeping its times, e.g. for a chat imported from elsewhere.
+func (s *Store) Put(session *dto.Session) error {
+	if !validName.MatchString(session.Name) {
+		return fmt.Errorf("%w %q", ErrInvalidName, session.Name)
+	}
+
+	return s.write(session, false)
+}
+
+// write replaces the session file, an exclusive write fails if the file exists.
+func (s *Store) write(session *dto.Session, exclusive bool) error {
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal session: %w", err)
+	}
+
+	if err = os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("create sessions dir: %w", err)
+	}
+
+	if exclusive {
+		err = atomicfile.Create(s.path(session.Name), data, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%w: %s", ErrExists, session.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("create session: %w", err)
+		}
+	} else if err = atomicfile.Write(s.path(session.Name), data, 0o600); err != nil {
+		return fmt.Errorf("write session: %w", err)
+	}
+
+	s.updateIndex(session)
+
+	return nil
+}
+
+// updateIndex adds the session to the index. The session is saved anyway,
+// a failed update is caught up by the next search that checks the versions.
+func (s *Store) updateIndex(session *dto.Session) {
+	if s.index == nil {
+		return
+	}
+
+	info, err := os.Stat(s.path(session.Name))
+	if err != nil {
+		return
+	}
+
+	_ = s.index.Update(session, info.ModTime())
+}
+
+// Load reads the session by its name.
+func (s *Store) Load(name string) (*dto.Session, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+
+	var session dto.Session
+	if err = json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("unmarshal session %s: %w", name, err)
+	}
+
+	if session.Chat == nil {
+		session.Chat = dto.NewChat()
+	}
+
+	return &session, nil
+}
+
+// List returns all sessions, the most recently updated first.
+func (s *Store) List() ([]*dto.Session, error) {
+	names, err := s.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*dto.Session, 0, len(names))
+	for _, name := range names {
+		session, err := s.Load(name)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, session)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+
+	return list, nil
+}
+
+// Names returns the names of all sessions in alphabetical order.
+func (s *Store) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read sessions dir: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), extension) {
+			continue
+		}
+
+		names = append(names, strings.TrimSuffix(entry.Name(), extension))
+	}
+
+	return names, nil
+}
+
+// Versions returns the modification times of the session files by the session names.
+func (s *Store) Versions() (map[string]time.Time, error) {
+	names, err := s.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]time.Time, len(names))
+	for _, name := range names {
+		info, err := os.Stat(s.path(name))
+		if err != nil {
+			return nil, fmt.Errorf("stat session: %w", err)
+		}
+
+		versions[name] = info.ModTime()
+	}
+
+	return versions, nil
+}
+
+// NewName returns an unused name like chat-2006-01-02-1.
+func (s *Store) NewName(now time.Time) (string, error) {
+	names, err := s.Names()
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(names))
+	for _, name := range names {
+		used[name] = true
+	}
+
+	prefix := "chat-" + now.Format(time.DateOnly)
+	for i := 1; ; i++ {
+		if name := fmt.Sprintf("%s-%d", prefix, i); !used[name] {
+			return name, nil
+		}
+	}
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+extension)
+}
@@ -0,0 +1,266 @@
+// Package slash implements a registry of "/"-prefixed commands typed in the chat.
+package slash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const prefix = "/"
+
+// Unlimited allows any number of arguments.
+const Unlimited = -1
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrUsage          = errors.New("wrong usage")
+)
+
+// Handler runs a command with the parsed arguments.
+type Handler func(ctx context.Context, args []string) error
+
+// Completer suggests values for the last argument, args holds the arguments typed so far.
+type Completer func(args []string) []string
+
+type Command struct {
+	Name    string
+	Aliases []string
+	// Args describes the arguments in the help, e.g. "<name>".
+	Args string
+	Help string
+	// MinArgs and MaxArgs limit the number of arguments, a command takes no arguments by default.
+	// With a positive MaxArgs the last argument takes the rest of the line as is.
+	MinArgs  int
+	MaxArgs  int
+	Complete Completer
+	Run      Handler
+}
+
+// Usage returns the command with its arguments, e.g. "/load <name>".
+func (c *Command) Usage() string {
+	if c.Args == "" {
+		return prefix + c.Name
+	}
+
+	return prefix + c.Name + " " + c.Args
+}
+
+type Registry struct {
+	commands []*Command
+	index    map[string]*Command
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		index: make(map[string]*Command),
+	}
+}
+
+// Register adds commands to the registry, a later command overrides an earlier one with the same name.
+func (r *Registry) Register(commands ...Command) {
+	for _, c := range commands {
+		if _, ok := r.index[c.Name]; !ok {
+			r.commands = append(r.commands, &c)
+		} else {
+			for i, existing := range r.commands {
+				if existing.Name == c.Name {
+					r.commands[i] = &c
+				}
+			}
+		}
+
+		r.index[c.Name] = &c
+		for _, alias := range c.Aliases {
+			r.index[alias] = &c
+		}
+	}
+}
+
+// Commands returns the registered commands in the registration order.
+func (r *Registry) Commands() []*Command {
+	return r.commands
+}
+
+// Lookup finds a command by its name or alias, with or without the prefix.
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	c, ok := r.index[strings.TrimPrefix(name, prefix)]
+	return c, ok
+}
+
+// Execute parses the line and runs the matching command.
+func (r *Registry) Execute(ctx context.Context, line string) error {
+	name, rest := split(line)
+
+	c, ok := r.Lookup(name)
+	if !ok {
+		return fmt.Errorf("%w %s%s, type /help to list commands", ErrUnknownCommand, prefix, name)
+	}
+
+	args, err := parseArgs(rest, c.MaxArgs)
+	if err != nil {
+		return fmt.Errorf("%w: %w, usage: %s", ErrUsage, err, c.Usage())
+	}
+
+	if len(args) < c.MinArgs || c.MaxArgs != Unlimited && len(args) > c.MaxArgs {
+		return fmt.Errorf("%w, usage: %s", ErrUsage, c.Usage())
+	}
+
+	return c.Run(ctx, args)
+}
+
+// Complete returns full line candidates for a partially typed command or its argument.
+func (r *Registry) Complete(line string) []string {
+	if !IsCommand(line) {
+		return nil
+	}
+
+	name, rest, hasArgs := strings.Cut(strings.TrimPrefix(line, prefix), " ")
+	if !hasArgs {
+		var candidates []string
+		for key := range r.index {
+			if strings.HasPrefix(key, name) {
+				candidates = append(candidates, prefix+key+" ")
+			}
+		}
+
+		sort.Strings(candidates)
+
+		return candidates
+	}
+
+	c, ok := r.Lookup(name)
+	if !ok || c.Complete == nil {
+		return nil
+	}
+
+	args := strings.Fields(rest)
+	if rest == "" || strings.HasSuffix(rest, " ") {
+		args = append(args, "")
+	}
+
+	typed := args[:len(args)-1]
+	last := args[len(args)-1]
+
+	base := prefix + name + " "
+	if len(typed) > 0 {
+		base += strings.Join(typed, " ") + " "
+	}
+
+	var candidates []string
+	for _, value := range c.Complete(typed) {
+		if strings.HasPrefix(value, last) {
+			candidates = append(candidates, base+value)
+		}
+	}
+
+	return candidates
+}
+
+// Help returns the list of commands with their descriptions.
+func (r *Registry) Help() string {
+	width := 0
+	for _, c := range r.commands {
+		width = max(width, len(c.Usage()))
+	}
+
+	var b strings.Builder
+	for _, c := range r.commands {
+		fmt.Fprintf(&b, "%-*s  %s\n", width, c.Usage(), c.Help)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// IsCommand reports whether the line is a command. A doubled prefix escapes it, e.g. "//etc".
+func IsCommand(line string) bool {
+	return strings.HasPrefix(line, prefix) && !strings.HasPrefix(line, prefix+prefix)
+}
+
+// Unescape removes the escaping prefix from a line that is not a command.
+func Unescape(line string) string {
+	if strings.HasPrefix(line, prefix+prefix) {
+		return line[len(prefix):]
+	}
+
+	return line
+}
+
+func split(line string) (string, string) {
+	line = strings.TrimPrefix(strings.TrimSpace(line), prefix)
+
+	i := strings.IndexFunc(line, unicode.IsSpace)
+	if i < 0 {
+		return line, ""
+	}
+
+	return line[:i], strings.TrimSpace(line[i:])
+}
+
+// parseArgs splits arguments by spaces honoring double quotes.
+// With a positive limit the last argument keeps the rest of the line.
+func parseArgs(line string, limit int) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+		started bool
+	)
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if !quoted && !started && limit > 0 && len(args) == limit-1 {
+			rest, err := unquoteRest(strings.TrimSpace(string(runes[i:])))
+			if err != nil {
+				return nil, err
+			}
+
+			return append(args, rest), nil
+		}
+
+		r := runes[i]
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case unicode.IsSpace(r) && !quoted:
+			if started {
+				args = append(args, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+
+	if quoted {
+		return nil, errors.New("unclosed quote")
+	}
+
+	if started {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// unquoteRest strips the quotes around the last argument when it is entirely quoted.
+func unquoteRest(rest string) (string, error) {
+	switch strings.Count(rest, `"`) {
+	case 1:
+		if strings.HasPrefix(rest, `"`) {
+			return "", errors.New("unclosed quote")
+		}
+	case 2:
+		if len(rest) > 1 && strings.HasPrefix(rest, `"`) && strings.HasSuffix(rest, `"`) {
+			return rest[1 : len(rest)-1], nil
+		}
+	}
+
+	return rest, nil
+}
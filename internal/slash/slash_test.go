@@ -0,0 +1,118 @@
+package slash_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/slash"
+)
+
+func TestRegistry_Execute(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		expected []string
+		wantErr  error
+	}{
+		{
+			name:     "no args",
+			line:     "/reset",
+			expected: nil,
+		},
+		{
+			name:     "alias",
+			line:     "/quit",
+			expected: nil,
+		},
+		{
+			name:     "one arg",
+			line:     "/load  my-chat ",
+			expected: []string{"my-chat"},
+		},
+		{
+			name:     "quoted arg",
+			line:     `/load "my chat"`,
+			expected: []string{"my chat"},
+		},
+		{
+			name:     "rest of the line",
+			line:     `/edit 2 what is "go"?  `,
+			expected: []string{"2", `what is "go"?`},
+		},
+		{
+			name:    "unknown command",
+			line:    "/unknown",
+			wantErr: slash.ErrUnknownCommand,
+		},
+		{
+			name:    "too many args",
+			line:    "/reset now",
+			wantErr: slash.ErrUsage,
+		},
+		{
+			name:    "missing arg",
+			line:    "/load",
+			wantErr: slash.ErrUsage,
+		},
+		{
+			name:    "unclosed quote",
+			line:    `/load "my chat`,
+			wantErr: slash.ErrUsage,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			run := func(_ context.Context, args []string) error {
+				got = args
+				return nil
+			}
+
+			r := slash.NewRegistry()
+			r.Register(
+				slash.Command{Name: "reset", Run: run},
+				slash.Command{Name: "exit", Aliases: []string{"quit"}, Run: run},
+				slash.Command{Name: "load", MinArgs: 1, MaxArgs: 1, Run: run},
+				slash.Command{Name: "edit", MinArgs: 2, MaxArgs: 2, Run: run},
+			)
+
+			err := r.Execute(context.Background(), tc.line)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestRegistry_Complete(t *testing.T) {
+	r := slash.NewRegistry()
+	r.Register(
+		slash.Command{Name: "model", MaxArgs: 1, Complete: func([]string) []string {
+			return []string{"gpt-4o", "gpt-4o-mini", "o3-mini"}
+		}},
+		slash.Command{Name: "models"},
+		slash.Command{Name: "reset"},
+	)
+
+	assert.Equal(t, []string{"/model ", "/models "}, r.Complete("/mo"))
+	assert.Equal(t, []string{"/reset "}, r.Complete("/r"))
+	assert.Equal(t, []string{"/model gpt-4o", "/model gpt-4o-mini"}, r.Complete("/model gpt"))
+	assert.Equal(t, []string{"/model gpt-4o", "/model gpt-4o-mini", "/model o3-mini"}, r.Complete("/model "))
+	assert.Empty(t, r.Complete("/reset "))
+	assert.Empty(t, r.Complete("hello"))
+}
+
+func TestIsCommand(t *testing.T) {
+	assert.True(t, slash.IsCommand("/help"))
+	assert.False(t, slash.IsCommand("//etc/hosts"))
+	assert.False(t, slash.IsCommand("help"))
+	assert.Equal(t, "/etc/hosts", slash.Unescape("//etc/hosts"))
+	assert.Equal(t, "hello", slash.Unescape("hello"))
+}
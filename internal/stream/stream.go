@@ -0,0 +1,21 @@
+// Package stream passes the pieces of an answer from the provider to the caller as they come.
+package stream
+
+import "context"
+
+type handlerKey struct{}
+
+// Handler gets the pieces of the content of an answer in order.
+type Handler func(piece string)
+
+// WithHandler makes the providers that stream pass the pieces of the answers to the handler,
+// the answer is still returned whole. Only the requests for a single answer are streamed.
+func WithHandler(ctx context.Context, handler Handler) context.Context {
+	return context.WithValue(ctx, handlerKey{}, handler)
+}
+
+// FromContext returns the handler set by WithHandler.
+func FromContext(ctx context.Context) (Handler, bool) {
+	handler, ok := ctx.Value(handlerKey{}).(Handler)
+	return handler, ok && handler != nil
+}
@@ -0,0 +1,162 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	dirName   = "templates"
+	extension = ".tmpl"
+)
+
+var (
+	ErrNotFound    = errors.New("template not found")
+	ErrExists      = errors.New("template already exists")
+	ErrInvalidName = errors.New("invalid template name")
+
+	validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// skeleton is the content of a new template.
+const skeleton = `---
+description: %s
+# model: gpt-4o
+# Variables without a value ask for one, defaults, {{if .name}} and {{or .name "x"}} make them optional.
+required: [text]
+defaults:
+  tone: friendly
+---
+Rewrite the text below in a {{.tone}} tone.
+
+{{.text}}
+`
+
+// Store keeps the templates as files in the templates folder of the config directory.
+type Store struct {
+	dir string
+}
+
+func New(configDir string) *Store {
+	return &Store{
+		dir: filepath.Join(configDir, dirName),
+	}
+}
+
+// Dir returns the folder of the templates.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Load reads and parses the template by its name.
+func (s *Store) Load(name string) (*Template, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+
+	data, err := os.ReadFile(s.Path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read template: %w", err)
+	}
+
+	t, err := Parse(name, string(data))
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+
+	return t, nil
+}
+
+// List returns all templates in alphabetical order.
+func (s *Store) List() ([]*Template, error) {
+	names, err := s.Names()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*Template, 0, len(names))
+	for _, name := range names {
+		t, err := s.Load(name)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, t)
+	}
+
+	return list, nil
+}
+
+// Names returns the names of all templates in alphabetical order.
+func (s *Store) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read templates dir: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), extension) {
+			continue
+		}
+
+		names = append(names, strings.TrimSuffix(entry.Name(), extension))
+	}
+
+	return names, nil
+}
+
+// Skeleton returns the content of a new template with the description.
+func Skeleton(description string) string {
+	if description == "" {
+		description = "What the template is for"
+	}
+
+	return fmt.Sprintf(skeleton, description)
+}
+
+// Create writes a new template after checking that it parses, an existing template is never overwritten.
+func (s *Store) Create(name, content string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("%w %q", ErrInvalidName, name)
+	}
+
+	if _, err := Parse(name, content); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("create templates dir: %w", err)
+	}
+
+	f, err := os.OpenFile(s.Path(name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%w: %s", ErrExists, name)
+	}
+	if err != nil {
+		return fmt.Errorf("create template: %w", err)
+	}
+	defer f.Close()
+
+	if _, err = f.WriteString(content); err != nil {
+		return fmt.Errorf("write template: %w", err)
+	}
+
+	return nil
+}
+
+// Path returns the location of the template file.
+func (s *Store) Path(name string) string {
+	return filepath.Join(s.dir, name+extension)
+}
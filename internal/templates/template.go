@@ -0,0 +1,222 @@
+// Package templates keeps the library of prompt templates.
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+// Template is a prompt written with text/template. The optional YAML front matter
+// between two --- lines describes it:
+//
+//	---
+//	description: Review a diff
+//	model: gpt-4o
+//	required: [diff]
+//	defaults:
+//	  language: Go
+//	---
+//	Review this {{.language}} change: {{.diff}}
+type Template struct {
+	Name        string            `yaml:"-"`
+	Description string            `yaml:"description"`
+	Model       string            `yaml:"model"`
+	Required    []string          `yaml:"required"`
+	Defaults    map[string]string `yaml:"defaults"`
+	Body        string            `yaml:"-"`
+
+	tmpl *template.Template
+}
+
+// Parse reads the front matter and compiles the body of the template.
+func Parse(name, content string) (*Template, error) {
+	t := &Template{Name: name}
+
+	// The templates written on Windows end the lines with \r\n.
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	body := content
+	if rest, ok := strings.CutPrefix(content, frontMatterDelimiter+"\n"); ok {
+		// The newline is put back for an empty header closed on the next line.
+		header, after, found := strings.Cut("\n"+rest, "\n"+frontMatterDelimiter)
+		if !found {
+			return nil, errors.New("front matter is not closed with ---")
+		}
+
+		if err := yaml.Unmarshal([]byte(header), t); err != nil {
+			return nil, fmt.Errorf("parse front matter: %w", err)
+		}
+
+		body = strings.TrimPrefix(strings.TrimLeft(after, " \t"), "\n")
+	}
+
+	t.Body = body
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+
+	t.tmpl = tmpl
+
+	return t, nil
+}
+
+// Variables returns the names of the required variables and the ones the body uses, sorted.
+func (t *Template) Variables() []string {
+	f := t.fields()
+	for _, name := range t.Required {
+		f.used[name] = true
+	}
+
+	return sortedNames(f.used)
+}
+
+// Missing returns the required variables that have neither a value nor a default. A variable is required
+// when the front matter lists it or the body uses it directly. The ones only tested, as in {{if .lang}},
+// or given a fallback, as in {{or .lang "Go"}}, are optional and empty without a value.
+func (t *Template) Missing(vars map[string]string) []string {
+	f := t.fields()
+	for _, name := range t.Required {
+		f.required[name] = true
+	}
+
+	var missing []string
+	for _, name := range sortedNames(f.required) {
+		if _, ok := vars[name]; ok {
+			continue
+		}
+		if _, ok := t.Defaults[name]; ok {
+			continue
+		}
+
+		missing = append(missing, name)
+	}
+
+	return missing
+}
+
+// Render executes the template with the variables on top of the defaults.
+func (t *Template) Render(vars map[string]string) (string, error) {
+	if missing := t.Missing(vars); len(missing) > 0 {
+		return "", fmt.Errorf("missing variables: %s", strings.Join(missing, ", "))
+	}
+
+	data := make(map[string]string, len(t.Defaults)+len(vars))
+	for _, name := range t.Variables() {
+		data[name] = ""
+	}
+	for name, value := range t.Defaults {
+		data[name] = value
+	}
+	for name, value := range vars {
+		data[name] = value
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render template: %w", err)
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// fields are the top-level fields the body uses, such as diff in {{.diff}}, and the ones of them it uses directly.
+type fields struct {
+	used     map[string]bool
+	required map[string]bool
+}
+
+func (t *Template) fields() fields {
+	f := fields{used: make(map[string]bool), required: make(map[string]bool)}
+	if t.tmpl.Tree != nil {
+		f.collect(t.tmpl.Tree.Root, nil, false)
+	}
+
+	return f
+}
+
+// collect adds the fields used by the node. The fields of a condition are optional and guard their uses
+// in its block. The fields inside range and with blocks refer to other values and are skipped.
+func (f fields) collect(node parse.Node, guarded map[string]bool, optional bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			f.collect(child, guarded, false)
+		}
+	case *parse.ActionNode:
+		f.collect(n.Pipe, guarded, optional)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			f.collectCommand(cmd, guarded, optional)
+		}
+	case *parse.ChainNode:
+		f.collect(n.Node, guarded, optional)
+	case *parse.FieldNode:
+		name := n.Ident[0]
+		f.used[name] = true
+		if !optional && !guarded[name] {
+			f.required[name] = true
+		}
+	case *parse.IfNode:
+		f.collect(n.Pipe, guarded, true)
+		f.collect(n.List, guard(guarded, n.Pipe), false)
+		f.collect(n.ElseList, guarded, false)
+	case *parse.RangeNode:
+		f.collect(n.Pipe, guarded, true)
+		f.collect(n.ElseList, guarded, false)
+	case *parse.WithNode:
+		f.collect(n.Pipe, guarded, true)
+		f.collect(n.ElseList, guarded, false)
+	}
+}
+
+// collectCommand adds the fields of the arguments, the ones before the last argument of or have a fallback.
+func (f fields) collectCommand(cmd *parse.CommandNode, guarded map[string]bool, optional bool) {
+	fallback := -1
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); ok && ident.Ident == "or" {
+		fallback = len(cmd.Args) - 1
+	}
+
+	for i, arg := range cmd.Args {
+		f.collect(arg, guarded, optional || i < fallback)
+	}
+}
+
+// guard returns the guarded fields with the ones tested by the condition.
+func guard(guarded map[string]bool, cond *parse.PipeNode) map[string]bool {
+	f := fields{used: make(map[string]bool), required: make(map[string]bool)}
+	f.collect(cond, nil, true)
+
+	for name := range guarded {
+		f.used[name] = true
+	}
+
+	return f.used
+}
+
+func sortedNames(names map[string]bool) []string {
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
+
+	sort.Strings(list)
+
+	return list
+}
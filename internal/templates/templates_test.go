@@ -0,0 +1,159 @@
+package templates_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/templates"
+)
+
+const review = `---
+description: Review a diff
+model: gpt-4o
+required: [diff]
+defaults:
+  language: Go
+---
+Review this {{.language}} change{{if .focus}} with a focus on {{.focus}}{{end}}:
+{{with .files}}Files: {{.}}{{end}}
+{{.diff}}
+`
+
+func TestParse(t *testing.T) {
+	tmpl, err := templates.Parse("review", review)
+	assert.NoError(t, err)
+	assert.Equal(t, "Review a diff", tmpl.Description)
+	assert.Equal(t, "gpt-4o", tmpl.Model)
+	assert.True(t, strings.HasPrefix(tmpl.Body, "Review this"))
+	assert.Equal(t, []string{"diff", "files", "focus", "language"}, tmpl.Variables())
+	assert.Equal(t, []string{"diff"}, tmpl.Missing(map[string]string{}))
+
+	_, err = tmpl.Render(map[string]string{})
+	assert.ErrorContains(t, err, "missing variables: diff")
+
+	// The variables only tested by if and with are optional.
+	prompt, err := tmpl.Render(map[string]string{"diff": "+x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Review this Go change:\n\n+x", prompt)
+
+	prompt, err = tmpl.Render(map[string]string{"diff": "+x", "focus": "naming", "files": ""})
+	assert.NoError(t, err)
+	assert.Equal(t, "Review this Go change with a focus on naming:\n\n+x", prompt)
+
+	tmpl, err = templates.Parse("plain", "Hello {{.name}}")
+	assert.NoError(t, err)
+	assert.Empty(t, tmpl.Description)
+	assert.Equal(t, []string{"name"}, tmpl.Variables())
+
+	_, err = templates.Parse("broken", "---\ndescription: x\nHello")
+	assert.Error(t, err)
+
+	_, err = templates.Parse("broken", "Hello {{.name")
+	assert.Error(t, err)
+}
+
+func TestTemplate_Missing(t *testing.T) {
+	tests := []struct {
+		body    string
+		missing []string
+	}{
+		{body: "{{.text}}", missing: []string{"text"}},
+		{body: `{{or .lang "Go"}}`, missing: nil},
+		{body: "{{or .lang .fallback}}", missing: []string{"fallback"}},
+		{body: "{{if .lang}}in {{.lang}}{{end}}", missing: nil},
+		{body: "{{if .lang}}in {{.lang}} for {{.team}}{{else}}{{.other}}{{end}}", missing: []string{"other", "team"}},
+		{body: `{{if eq .lang "Go"}}gofmt{{end}}`, missing: nil},
+		{body: "{{range .items}}{{.name}}{{end}}", missing: nil},
+		{body: "{{printf \"%s\" .text}}", missing: []string{"text"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			tmpl, err := templates.Parse("test", tt.body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.missing, tmpl.Missing(map[string]string{}))
+		})
+	}
+}
+
+func TestParse_FrontMatter(t *testing.T) {
+	// The lines may end with \r\n.
+	tmpl, err := templates.Parse("crlf", strings.ReplaceAll(review, "\n", "\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Review a diff", tmpl.Description)
+	assert.Equal(t, map[string]string{"language": "Go"}, tmpl.Defaults)
+
+	prompt, err := tmpl.Render(map[string]string{"diff": "+x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Review this Go change:\n\n+x", prompt)
+
+	// The header may be empty.
+	tmpl, err = templates.Parse("empty", "---\n---\nHello {{.name}}")
+	assert.NoError(t, err)
+	assert.Empty(t, tmpl.Description)
+	assert.Equal(t, "Hello {{.name}}", tmpl.Body)
+}
+
+func TestParseVar(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "diff.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("+x\n"), 0o600))
+
+	tests := []struct {
+		arg   string
+		name  string
+		value string
+	}{
+		{arg: "name=value", name: "name", value: "value"},
+		{arg: "name=a=b", name: "name", value: "a=b"},
+		{arg: "name=", name: "name", value: ""},
+		{arg: "diff=@" + path, name: "diff", value: "+x"},
+		{arg: "input=@-", name: "input", value: "from stdin"},
+		{arg: "branch=!echo main", name: "branch", value: "main"},
+		{arg: `mail=\@home`, name: "mail", value: "@home"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			name, value, err := templates.ParseVar(ctx, tt.arg, strings.NewReader("from stdin\n"))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.value, value)
+		})
+	}
+
+	for _, arg := range []string{"novalue", "=value", "x=@" + filepath.Join(dir, "missing"), "x=!exit 1"} {
+		_, _, err := templates.ParseVar(ctx, arg, strings.NewReader(""))
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := templates.New(t.TempDir())
+
+	names, err := store.Names()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	_, err = store.Load("review")
+	assert.ErrorIs(t, err, templates.ErrNotFound)
+
+	assert.NoError(t, store.Create("review", review))
+	assert.NoError(t, store.Create("rewrite", templates.Skeleton("")))
+	assert.ErrorIs(t, store.Create("review", review), templates.ErrExists)
+	assert.ErrorIs(t, store.Create("../review", review), templates.ErrInvalidName)
+	assert.Error(t, store.Create("broken", "{{"))
+
+	list, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, "review", list[0].Name)
+	assert.Equal(t, []string{"text", "tone"}, list[1].Variables())
+}
@@ -0,0 +1,54 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ParseVar reads a --var flag value in the form name=value. The value can also come from
+// a file with name=@path, from the standard input with name=@- or from the output
+// of a shell command with name=!command. A leading backslash keeps the value as is, e.g. name=\@home.
+func ParseVar(ctx context.Context, arg string, stdin io.Reader) (string, string, error) {
+	name, value, ok := strings.Cut(arg, "=")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid variable %q, expected name=value", arg)
+	}
+
+	switch {
+	case strings.HasPrefix(value, `\`):
+		return name, value[1:], nil
+	case value == "@-":
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", "", fmt.Errorf("read variable %s from stdin: %w", name, err)
+		}
+		return name, trimNewline(string(data)), nil
+	case strings.HasPrefix(value, "@"):
+		data, err := os.ReadFile(value[1:])
+		if err != nil {
+			return "", "", fmt.Errorf("read variable %s: %w", name, err)
+		}
+		return name, trimNewline(string(data)), nil
+	case strings.HasPrefix(value, "!"):
+		out, err := exec.CommandContext(ctx, "sh", "-c", value[1:]).Output() //nolint:gosec // the command is given by the user
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				err = fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", "", fmt.Errorf("run command of variable %s: %w", name, err)
+		}
+		return name, trimNewline(string(out)), nil
+	default:
+		return name, value, nil
+	}
+}
+
+func trimNewline(s string) string {
+	return strings.TrimRight(s, "\r\n")
+}
@@ -0,0 +1,38 @@
+// Package tokens estimates token counts without a tokenizer.
+package tokens
+
+import (
+	"unicode/utf8"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const (
+	// charsPerToken is an average for English text with the OpenAI tokenizers.
+	charsPerToken = 4
+	// messageOverhead covers the role and the separators of every message.
+	messageOverhead = 4
+	// replyOverhead is added once for the assistant reply priming.
+	replyOverhead = 3
+)
+
+// Estimate returns an approximate number of tokens in the text.
+func Estimate(text string) int {
+	n := utf8.RuneCountInString(text)
+
+	return (n + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateMessages returns an approximate number of prompt tokens for the messages.
+func EstimateMessages(messages []dto.Message) int {
+	if len(messages) == 0 {
+		return 0
+	}
+
+	total := replyOverhead
+	for _, message := range messages {
+		total += messageOverhead + Estimate(message.Content)
+	}
+
+	return total
+}
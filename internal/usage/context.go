@@ -0,0 +1,16 @@
+package usage
+
+import "context"
+
+type sessionKey struct{}
+
+// WithSession marks the requests made with the context as a part of the session.
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// SessionFromContext returns the session set by WithSession.
+func SessionFromContext(ctx context.Context) string {
+	session, _ := ctx.Value(sessionKey{}).(string)
+	return session
+}
@@ -0,0 +1,107 @@
+// Package usage keeps the ledger of the tokens spent on requests.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+)
+
+const fileName = "usage.jsonl"
+
+// Ledger appends usage records to a JSON lines file in the data directory.
+type Ledger struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLedger(dataDir string) *Ledger {
+	return &Ledger{
+		path: filepath.Join(dataDir, fileName),
+	}
+}
+
+// Record appends the record to the ledger.
+func (l *Ledger) Record(record dto.UsageRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("marshal usage record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("create data dir: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write usage ledger: %w", err)
+	}
+
+	return nil
+}
+
+// Stat returns the size and the modification time of the ledger, they change with every record
+// of any process. They are zero if nothing was recorded yet.
+func (l *Ledger) Stat() (int64, time.Time, error) {
+	info, err := os.Stat(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("stat usage ledger: %w", err)
+	}
+
+	return info.Size(), info.ModTime(), nil
+}
+
+// Records returns the records made at or after since, a zero since returns all of them.
+func (l *Ledger) Records(since time.Time) ([]dto.UsageRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var records []dto.UsageRecord
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record dto.UsageRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A line torn by a crash must not hide the rest of the ledger.
+			continue
+		}
+
+		if !record.Time.Before(since) {
+			records = append(records, record)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read usage ledger: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,72 @@
+package usage
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+)
+
+// GroupBy returns the key a record is accounted under.
+type GroupBy func(record dto.UsageRecord) string
+
+var (
+	ByDay     GroupBy = func(r dto.UsageRecord) string { return r.Time.Local().Format(time.DateOnly) }
+	ByModel   GroupBy = func(r dto.UsageRecord) string { return r.Model }
+	BySession GroupBy = func(r dto.UsageRecord) string { return r.Session }
+)
+
+type pricer interface {
+	Cost(model string, usage dto.Usage) (float64, bool)
+}
+
+// Row is the usage summed up for a key.
+type Row struct {
+	Key      string
+	Requests int
+	dto.Usage
+	Cost float64
+	// Unpriced lists the models the cost of which is unknown.
+	Unpriced []string
+}
+
+// Summarize sums up the records by the key in the key order and returns the total.
+func Summarize(records []dto.UsageRecord, groupBy GroupBy, prices pricer) ([]Row, Row) {
+	rows := make(map[string]*Row)
+	total := Row{}
+
+	for _, record := range records {
+		key := groupBy(record)
+
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{Key: key}
+			rows[key] = row
+		}
+
+		cost, priced := prices.Cost(record.Model, record.Usage)
+		cost *= pricing.Discount(record)
+		for _, r := range []*Row{row, &total} {
+			r.Requests++
+			r.Usage = r.Usage.Add(record.Usage)
+			r.Cost += cost
+
+			if !priced && !slices.Contains(r.Unpriced, record.Model) {
+				r.Unpriced = append(r.Unpriced, record.Model)
+			}
+		}
+	}
+
+	list := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, *row)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+
+	return list, total
+}
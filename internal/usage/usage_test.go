@@ -0,0 +1,75 @@
+package usage_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/andrian0vv/chatgpt-cli/internal/dto"
+	"github.com/andrian0vv/chatgpt-cli/internal/pricing"
+	"github.com/andrian0vv/chatgpt-cli/internal/usage"
+)
+
+func TestLedger(t *testing.T) {
+	ledger := usage.NewLedger(t.TempDir())
+
+	records, err := ledger.Records(time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	day := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	expected := []dto.UsageRecord{
+		{Time: day, Model: "gpt-4o", Session: "chat-1", Usage: dto.Usage{PromptTokens: 10, CompletionTokens: 5}},
+		{Time: day.Add(24 * time.Hour), Model: "o3-mini", Usage: dto.Usage{PromptTokens: 20, CompletionTokens: 50, ReasoningTokens: 40}},
+	}
+
+	for _, record := range expected {
+		assert.NoError(t, ledger.Record(record))
+	}
+
+	records, err = ledger.Records(time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, records)
+
+	records, err = ledger.Records(day.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, expected[1:], records)
+}
+
+func TestSummarize(t *testing.T) {
+	records := []dto.UsageRecord{
+		{Model: "gpt-4o", Session: "b", Usage: dto.Usage{PromptTokens: 1_000_000, CachedTokens: 500_000}},
+		{Model: "gpt-4o-mini", Session: "a", Usage: dto.Usage{CompletionTokens: 1_000_000}},
+		{Model: "custom", Session: "a", Usage: dto.Usage{PromptTokens: 100}},
+		{Model: "gpt-4o-2024-08-06", Session: "b", Usage: dto.Usage{CompletionTokens: 100_000}},
+		{Model: "gpt-4o-mini", Session: "c", Batch: "batch-1", Usage: dto.Usage{CompletionTokens: 1_000_000}},
+	}
+
+	rows, total := usage.Summarize(records, usage.BySession, pricing.New(nil))
+
+	assert.Equal(t, []usage.Row{
+		{
+			Key:      "a",
+			Requests: 2,
+			Usage:    dto.Usage{PromptTokens: 100, CompletionTokens: 1_000_000},
+			Cost:     0.6,
+			Unpriced: []string{"custom"},
+		},
+		{
+			Key:      "b",
+			Requests: 2,
+			Usage:    dto.Usage{PromptTokens: 1_000_000, CachedTokens: 500_000, CompletionTokens: 100_000},
+			Cost:     1.25 + 0.625 + 1,
+		},
+		{
+			Key:      "c",
+			Requests: 1,
+			Usage:    dto.Usage{CompletionTokens: 1_000_000},
+			Cost:     0.3,
+		},
+	}, rows)
+	assert.Equal(t, 5, total.Requests)
+	assert.InDelta(t, 3.775, total.Cost, 1e-9)
+	assert.Equal(t, []string{"custom"}, total.Unpriced)
+}
@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/andrian0vv/chatgpt-cli/cmd"
+	"github.com/andrian0vv/chatgpt-cli/internal/failure"
 )
 
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 
-	if err := cmd.Execute(ctx); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	// The first Ctrl+C cancels the running request, the second one kills the process.
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := cmd.Execute(ctx)
+	stop()
+
+	os.Exit(failure.ExitCode(err))
 }